@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Embedder turns text into a vector that can be compared by cosine similarity.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// HashEmbedder is a deterministic embedder that runs locally without network access.
+// It hashes words and character trigrams into a fixed number of buckets, so texts that
+// share vocabulary end up close to each other. It is meant for tests and local development.
+type HashEmbedder struct {
+	dimensions int
+}
+
+func NewHashEmbedder(dimensions int) *HashEmbedder {
+	if dimensions <= 0 {
+		dimensions = 256
+	}
+	return &HashEmbedder{dimensions: dimensions}
+}
+
+func (e *HashEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float32, e.dimensions)
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return nil, fmt.Errorf("cannot embed empty text")
+	}
+
+	for _, word := range words {
+		e.add(vector, "w:"+word, 1)
+
+		// Character trigrams make the embedding tolerant to small spelling differences
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			e.add(vector, "t:"+string(padded[i:i+3]), 0.5)
+		}
+	}
+
+	normalize(vector)
+	return vector, nil
+}
+
+func (e *HashEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+
+	// Use one bit of the hash as the sign to reduce the bias of bucket collisions
+	if sum&1 == 1 {
+		weight = -weight
+	}
+	vector[(sum>>1)%uint64(len(vector))] += weight
+}
+
+func normalize(vector []float32) {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+}
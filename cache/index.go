@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// Entry is a cached prompt and the response that was returned for it
+type Entry struct {
+	Text     string
+	Response params.Response
+}
+
+// Match is the nearest entry found in an index and its cosine similarity to the query
+type Match struct {
+	Entry      Entry
+	Similarity float32
+}
+
+// Index stores vectors per namespace and finds the nearest one to a query vector
+type Index interface {
+	Add(namespace string, vector []float32, entry Entry) error
+	Nearest(namespace string, vector []float32) (*Match, error)
+}
+
+type indexItem struct {
+	vector []float32
+	entry  Entry
+}
+
+// MemoryIndex is an in-memory brute-force index. It compares the query against every
+// vector in the namespace, which is fast enough for caches up to tens of thousands of entries.
+type MemoryIndex struct {
+	mu         sync.RWMutex
+	items      map[string][]indexItem
+	maxEntries int
+}
+
+// NewMemoryIndex creates an index that keeps at most maxEntries per namespace,
+// evicting the oldest entry first. A maxEntries of 0 means the index is unbounded.
+func NewMemoryIndex(maxEntries int) *MemoryIndex {
+	return &MemoryIndex{
+		items:      make(map[string][]indexItem),
+		maxEntries: maxEntries,
+	}
+}
+
+func (i *MemoryIndex) Add(namespace string, vector []float32, entry Entry) error {
+	if len(vector) == 0 {
+		return fmt.Errorf("cannot add empty vector to index")
+	}
+
+	normalized := make([]float32, len(vector))
+	copy(normalized, vector)
+	normalize(normalized)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	items := append(i.items[namespace], indexItem{vector: normalized, entry: entry})
+	if i.maxEntries > 0 && len(items) > i.maxEntries {
+		items = items[len(items)-i.maxEntries:]
+	}
+	i.items[namespace] = items
+	return nil
+}
+
+func (i *MemoryIndex) Nearest(namespace string, vector []float32) (*Match, error) {
+	query := make([]float32, len(vector))
+	copy(query, vector)
+	normalize(query)
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	var best *Match
+	for _, item := range i.items[namespace] {
+		if len(item.vector) != len(query) {
+			return nil, fmt.Errorf("vector dimension mismatch: index has %d, query has %d", len(item.vector), len(query))
+		}
+
+		similarity := dot(item.vector, query)
+		if best == nil || similarity > best.Similarity {
+			best = &Match{Entry: item.entry, Similarity: similarity}
+		}
+	}
+	return best, nil
+}
+
+func dot(a []float32, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+const defaultThreshold = 0.95
+
+type SemanticCacheConfig struct {
+	Embedder Embedder
+	// Index defaults to an unbounded MemoryIndex if nil
+	Index Index
+	// Threshold is the minimum cosine similarity for a cache hit, between 0 and 1. Defaults to 0.95.
+	Threshold float32
+}
+
+// SemanticCache returns a previous response when the final user message of a prompt
+// is similar enough to one that was already answered for the same preset.
+type SemanticCache struct {
+	embedder  Embedder
+	index     Index
+	threshold float32
+}
+
+func NewSemanticCache(config SemanticCacheConfig) (*SemanticCache, error) {
+	if config.Embedder == nil {
+		return nil, fmt.Errorf("semantic cache requires an embedder")
+	}
+	if config.Threshold < 0 || config.Threshold > 1 {
+		return nil, fmt.Errorf("semantic cache threshold must be between 0 and 1, got %v", config.Threshold)
+	}
+
+	index := config.Index
+	if index == nil {
+		index = NewMemoryIndex(0)
+	}
+
+	threshold := config.Threshold
+	if threshold == 0 {
+		threshold = defaultThreshold
+	}
+
+	return &SemanticCache{
+		embedder:  config.Embedder,
+		index:     index,
+		threshold: threshold,
+	}, nil
+}
+
+// Lookup returns the cached response for the nearest previous prompt, if its similarity is above the threshold.
+// If the prompt has a ResponseFormat, the cached content is unmarshalled into it.
+func (c *SemanticCache) Lookup(ctx context.Context, presetName string, prompt params.Prompt) (*params.Response, bool, error) {
+	text, ok := finalUserMessage(prompt)
+	if !ok {
+		return nil, false, nil
+	}
+
+	vector, err := c.embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to embed prompt: %w", err)
+	}
+
+	match, err := c.index.Nearest(namespace(presetName, prompt), vector)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to search index: %w", err)
+	}
+	if match == nil || match.Similarity < c.threshold {
+		return nil, false, nil
+	}
+
+	response := match.Entry.Response
+	response.Parsed = nil
+
+	if prompt.ResponseFormat != nil {
+		if reflect.TypeOf(prompt.ResponseFormat).Kind() != reflect.Ptr {
+			return nil, false, nil
+		}
+		if err := json.Unmarshal([]byte(response.Content), prompt.ResponseFormat); err != nil {
+			// The cached answer doesn't fit the requested format, treat it as a miss
+			return nil, false, nil
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+
+	return &response, true, nil
+}
+
+// Store records the response for the final user message of the prompt
+func (c *SemanticCache) Store(ctx context.Context, presetName string, prompt params.Prompt, response *params.Response) error {
+	text, ok := finalUserMessage(prompt)
+	if !ok || response == nil {
+		return nil
+	}
+
+	vector, err := c.embedder.Embed(ctx, text)
+	if err != nil {
+		return fmt.Errorf("failed to embed prompt: %w", err)
+	}
+
+	entry := Entry{
+		Text:     text,
+		Response: *response,
+	}
+	// Parsed points to the caller's struct, which must not be shared with later hits
+	entry.Response.Parsed = nil
+
+	if err := c.index.Add(namespace(presetName, prompt), vector, entry); err != nil {
+		return fmt.Errorf("failed to add prompt to index: %w", err)
+	}
+	return nil
+}
+
+func finalUserMessage(prompt params.Prompt) (string, bool) {
+	if i := finalUserMessageIndex(prompt); i >= 0 {
+		return prompt.Messages[i].Content, prompt.Messages[i].Content != ""
+	}
+	return "", false
+}
+
+func finalUserMessageIndex(prompt params.Prompt) int {
+	for i := len(prompt.Messages) - 1; i >= 0; i-- {
+		if prompt.Messages[i].Role == params.MessageRoleUser {
+			return i
+		}
+	}
+	return -1
+}
+
+// namespaceKey is everything about a prompt besides its final user message that can change the answer
+type namespaceKey struct {
+	SystemMessage      string
+	History            []params.Message
+	ResponseFormat     string
+	PreviousResponseID string
+	Documents          []params.Document
+}
+
+// namespace separates entries by preset and by the rest of the prompt. Only the final user message
+// is compared by similarity, so the same question with a different system message, earlier turns,
+// response format, previous response or documents never shares an answer.
+func namespace(presetName string, prompt params.Prompt) string {
+	key := namespaceKey{
+		SystemMessage:      prompt.SystemMessage,
+		PreviousResponseID: prompt.PreviousResponseID,
+		Documents:          prompt.Documents,
+	}
+	if i := finalUserMessageIndex(prompt); i >= 0 {
+		key.History = append(prompt.Messages[:i:i], prompt.Messages[i+1:]...)
+	}
+	if prompt.ResponseFormat != nil {
+		key.ResponseFormat = reflect.TypeOf(prompt.ResponseFormat).String()
+	}
+
+	// Marshalling a struct of strings and slices can't fail
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return presetName + ":" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,230 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+type answer struct {
+	Text string `json:"text"`
+}
+
+type otherAnswer struct {
+	Text string `json:"text"`
+}
+
+// similarity returns the similarity the index reports between two texts
+func similarity(t *testing.T, embedder Embedder, a string, b string) float32 {
+	t.Helper()
+	ctx := context.Background()
+
+	index := NewMemoryIndex(0)
+	vector, err := embedder.Embed(ctx, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.Add("test", vector, Entry{Text: a}); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := embedder.Embed(ctx, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	match, err := index.Nearest("test", query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return match.Similarity
+}
+
+func newTestCache(t *testing.T, threshold float32) *SemanticCache {
+	t.Helper()
+	semanticCache, err := NewSemanticCache(SemanticCacheConfig{
+		Embedder:  NewHashEmbedder(256),
+		Threshold: threshold,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return semanticCache
+}
+
+func TestHashEmbedderIsDeterministic(t *testing.T) {
+	ctx := context.Background()
+	a, err := NewHashEmbedder(64).Embed(ctx, "What is the capital of France?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewHashEmbedder(64).Embed(ctx, "What is the capital of France?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("embeddings differ at %d: %v != %v", i, a[i], b[i])
+		}
+	}
+
+	if _, err := NewHashEmbedder(64).Embed(ctx, "?"); err == nil {
+		t.Error("expected an error for text without words")
+	}
+}
+
+func TestLookupThreshold(t *testing.T) {
+	ctx := context.Background()
+	stored := "What is the capital of France?"
+	asked := "what's the capital of france"
+
+	threshold := similarity(t, NewHashEmbedder(256), stored, asked)
+	if threshold <= 0 || threshold >= 1 {
+		t.Fatalf("expected similar but different texts, got similarity %v", threshold)
+	}
+
+	tests := []struct {
+		name      string
+		threshold float32
+		wantHit   bool
+	}{
+		{name: "at threshold", threshold: threshold, wantHit: true},
+		{name: "above similarity", threshold: math.Nextafter32(threshold, 1), wantHit: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			semanticCache := newTestCache(t, tt.threshold)
+			err := semanticCache.Store(ctx, "fast", params.NewSimplePrompt("", stored), &params.Response{Content: "Paris"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			response, hit, err := semanticCache.Lookup(ctx, "fast", params.NewSimplePrompt("", asked))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hit != tt.wantHit {
+				t.Fatalf("expected hit %v, got %v", tt.wantHit, hit)
+			}
+			if hit && response.Content != "Paris" {
+				t.Errorf("expected cached content Paris, got %q", response.Content)
+			}
+		})
+	}
+}
+
+func TestLookupUnmarshalsResponseFormat(t *testing.T) {
+	ctx := context.Background()
+	semanticCache := newTestCache(t, 0.95)
+
+	stored := params.NewSimplePrompt("", "Capital of France?")
+	stored.ResponseFormat = &answer{}
+	if err := semanticCache.Store(ctx, "fast", stored, &params.Response{Content: `{"text":"Paris"}`}); err != nil {
+		t.Fatal(err)
+	}
+
+	var target answer
+	asked := params.NewSimplePrompt("", "Capital of France?")
+	asked.ResponseFormat = &target
+	response, hit, err := semanticCache.Lookup(ctx, "fast", asked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hit || target.Text != "Paris" || response.Parsed != &target {
+		t.Errorf("expected the cached answer in the response format, got hit %v and %+v", hit, target)
+	}
+}
+
+func TestNamespaceIsolation(t *testing.T) {
+	ctx := context.Background()
+
+	conversation := func(question string, reply string) params.Prompt {
+		return params.Prompt{
+			Messages: []params.Message{
+				{Role: params.MessageRoleUser, Content: question},
+				{Role: params.MessageRoleAssistant, Content: reply},
+				{Role: params.MessageRoleUser, Content: "yes"},
+			},
+		}
+	}
+	withResponseFormat := func(format any) params.Prompt {
+		prompt := params.NewSimplePrompt("", "yes")
+		prompt.ResponseFormat = format
+		return prompt
+	}
+	withPreviousResponse := func(id string) params.Prompt {
+		prompt := params.NewSimplePrompt("", "yes")
+		prompt.PreviousResponseID = id
+		return prompt
+	}
+	withDocument := func(text string) params.Prompt {
+		prompt := params.NewSimplePrompt("", "yes")
+		prompt.Documents = []params.Document{{ID: "doc", Text: text}}
+		return prompt
+	}
+
+	tests := []struct {
+		name         string
+		storedPreset string
+		stored       params.Prompt
+		askedPreset  string
+		asked        params.Prompt
+		wantHit      bool
+	}{
+		{
+			name:         "same prompt",
+			storedPreset: "fast", stored: conversation("Do you want tea?", "Milk?"),
+			askedPreset: "fast", asked: conversation("Do you want tea?", "Milk?"),
+			wantHit: true,
+		},
+		{
+			name:         "preset",
+			storedPreset: "fast", stored: params.NewSimplePrompt("", "yes"),
+			askedPreset: "slow", asked: params.NewSimplePrompt("", "yes"),
+		},
+		{
+			name:         "system message",
+			storedPreset: "fast", stored: params.NewSimplePrompt("Answer in French.", "yes"),
+			askedPreset: "fast", asked: params.NewSimplePrompt("Answer in German.", "yes"),
+		},
+		{
+			name:         "earlier turns",
+			storedPreset: "fast", stored: conversation("Do you want tea?", "Milk?"),
+			askedPreset: "fast", asked: conversation("Shall I delete the database?", "Are you sure?"),
+		},
+		{
+			name:         "response format type",
+			storedPreset: "fast", stored: withResponseFormat(&answer{}),
+			askedPreset: "fast", asked: withResponseFormat(&otherAnswer{}),
+		},
+		{
+			name:         "previous response",
+			storedPreset: "fast", stored: withPreviousResponse("resp_1"),
+			askedPreset: "fast", asked: withPreviousResponse("resp_2"),
+		},
+		{
+			name:         "documents",
+			storedPreset: "fast", stored: withDocument("Refunds within 30 days."),
+			askedPreset: "fast", asked: withDocument("No refunds."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			semanticCache := newTestCache(t, 0.95)
+			if err := semanticCache.Store(ctx, tt.storedPreset, tt.stored, &params.Response{Content: `{"text":"ok"}`}); err != nil {
+				t.Fatal(err)
+			}
+
+			_, hit, err := semanticCache.Lookup(ctx, tt.askedPreset, tt.asked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if hit != tt.wantHit {
+				t.Errorf("expected hit %v, got %v", tt.wantHit, hit)
+			}
+		})
+	}
+}
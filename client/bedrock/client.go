@@ -0,0 +1,336 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+type ClientConfig struct {
+	// Region defaults to the AWS_REGION or AWS_DEFAULT_REGION environment variable
+	Region string `json:"region,omitempty"`
+	// The credentials default to the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+	// environment variables. SessionToken is only needed for temporary credentials.
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	SessionToken    string `json:"session_token,omitempty"`
+	// BaseURL defaults to the Bedrock runtime endpoint of the region
+	BaseURL string `json:"base_url,omitempty"`
+	// StructuredOutputMode defaults to native support, which forces a tool call with the response schema
+	StructuredOutputMode params.StructuredOutputMode `json:"structured_output_mode,omitempty"`
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client `json:"-"`
+}
+
+// Client talks to the Bedrock Converse API, which serves Claude, Llama and other models with one format
+type Client struct {
+	baseURL              string
+	region               string
+	credentials          credentials
+	httpClient           *http.Client
+	structuredOutputMode params.StructuredOutputMode
+	now                  func() time.Time
+}
+
+func NewBedrockClient(config ClientConfig) (*Client, error) {
+	region := firstNonEmpty(config.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return nil, errors.New("bedrock region is required")
+	}
+
+	creds := credentials{
+		AccessKeyID:     config.AccessKeyID,
+		SecretAccessKey: config.SecretAccessKey,
+		SessionToken:    config.SessionToken,
+	}
+	if creds.AccessKeyID == "" && creds.SecretAccessKey == "" {
+		creds = credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("aws access key id and secret access key are required")
+	}
+
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://bedrock-runtime." + region + ".amazonaws.com"
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:              baseURL,
+		region:               region,
+		credentials:          creds,
+		httpClient:           httpClient,
+		structuredOutputMode: config.StructuredOutputMode,
+		now:                  time.Now,
+	}, nil
+}
+
+// ValidateSettings reports settings that this client cannot send, so presets can be rejected up front
+func (c *Client) ValidateSettings(settings params.Settings) error {
+	return validateSettings(settings)
+}
+
+func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	request, err := c.mapPromptToRequest(prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, settings.ModelName, "converse", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send converse request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var converse converseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&converse); err != nil {
+		return nil, fmt.Errorf("failed to decode converse response: %w", err)
+	}
+
+	content, reasoning := mapContent(converse.Output.Message)
+	if err := contentFilterError(converse.StopReason, content); err != nil {
+		return nil, err
+	}
+
+	response := &params.Response{
+		ResponseID: resp.Header.Get("X-Amzn-Requestid"),
+		Content:    content,
+		Parsed:     nil,
+	}
+	if settings.IncludeReasoning {
+		response.Reasoning = reasoning
+	}
+
+	// If response format is specified, validate the reply and unmarshal into that type
+	if prompt.ResponseFormat != nil {
+		if err := structured.Parse(response.Content, prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+
+	return response, nil
+}
+
+// streamEvent holds the fields of every ConverseStream event type that are used
+type streamEvent struct {
+	Delta *struct {
+		Text             string `json:"text"`
+		ReasoningContent *struct {
+			Text string `json:"text"`
+		} `json:"reasoningContent"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+	} `json:"delta"`
+	StopReason string `json:"stopReason"`
+}
+
+func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
+	request, err := c.mapPromptToRequest(prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, settings.ModelName, "converse-stream", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send converse stream request: %w", err)
+	}
+
+	chunks := make(chan params.StreamChunk)
+
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		var stopReason string
+		var content strings.Builder
+		decoder := newEventStreamDecoder(resp.Body)
+		for {
+			msg, err := decoder.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Errorf("streaming error: %w", err),
+				}
+				return
+			}
+			if err := streamError(msg); err != nil {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   err,
+				}
+				return
+			}
+
+			var event streamEvent
+			if err := json.Unmarshal(msg.Payload, &event); err != nil {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Errorf("failed to decode stream event: %w", err),
+				}
+				return
+			}
+
+			switch msg.Headers[":event-type"] {
+			case "messageStop":
+				stopReason = event.StopReason
+			case "contentBlockDelta":
+				if event.Delta == nil {
+					continue
+				}
+				if event.Delta.ReasoningContent != nil {
+					if settings.IncludeReasoning && event.Delta.ReasoningContent.Text != "" {
+						chunks <- params.StreamChunk{
+							Kind:    params.StreamChunkKindReasoning,
+							Content: event.Delta.ReasoningContent.Text,
+							Done:    false,
+							Error:   nil,
+						}
+					}
+					continue
+				}
+
+				// The response format tool's input is streamed as partial JSON, which is the answer
+				text := event.Delta.Text
+				if event.Delta.ToolUse != nil {
+					text = event.Delta.ToolUse.Input
+				}
+				if text != "" {
+					content.WriteString(text)
+					chunks <- params.StreamChunk{
+						Content: text,
+						Done:    false,
+						Error:   nil,
+					}
+				}
+			}
+		}
+
+		// Send final chunk to indicate completion
+		chunks <- params.StreamChunk{
+			Content:    "",
+			Done:       true,
+			Error:      contentFilterError(stopReason, content.String()),
+			ResponseID: resp.Header.Get("X-Amzn-Requestid"),
+		}
+	}()
+
+	return chunks, nil
+}
+
+// streamError converts an exception or error message of the event stream
+func streamError(msg *eventMessage) error {
+	switch msg.Headers[":message-type"] {
+	case "exception":
+		var exception struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(msg.Payload, &exception)
+		return fmt.Errorf("streaming error: %s: %s", msg.Headers[":exception-type"], exception.Message)
+	case "error":
+		return fmt.Errorf("streaming error: %s: %s", msg.Headers[":error-code"], msg.Headers[":error-message"])
+	}
+	return nil
+}
+
+// post sends a signed request to an operation of the model. The caller closes the body.
+func (c *Client) post(ctx context.Context, modelName string, operation string, request *converseRequest) (*http.Response, error) {
+	body, err := marshalRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Model IDs contain colons and inference profile ARNs contain slashes, so escape them
+	endpoint := c.baseURL + "/model/" + escape(modelName) + "/" + operation
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signRequest(req, body, c.credentials, c.region, c.now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, providerError(resp)
+	}
+	return resp, nil
+}
+
+// errorKinds classifies the error types whose status doesn't tell, e.g. AWS rejects invalid credentials with 403
+var errorKinds = map[string]params.ErrorKind{
+	"UnrecognizedClientException":         params.ErrorKindAuthentication,
+	"InvalidSignatureException":           params.ErrorKindAuthentication,
+	"IncompleteSignatureException":        params.ErrorKindAuthentication,
+	"MissingAuthenticationTokenException": params.ErrorKindAuthentication,
+	"ExpiredTokenException":               params.ErrorKindAuthentication,
+	"ThrottlingException":                 params.ErrorKindRateLimit,
+}
+
+// providerError normalizes an error reply. The error type is sent in the X-Amzn-Errortype header,
+// which looks like ValidationException:http://internal.amazon.com/coral/...
+func providerError(resp *http.Response) *params.ProviderError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	errorType, _, _ := strings.Cut(resp.Header.Get("X-Amzn-Errortype"), ":")
+	providerErr := &params.ProviderError{
+		Provider:   providerName,
+		Kind:       params.ErrorKindFromStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Code:       errorType,
+		Message:    strings.TrimSpace(string(body)),
+	}
+	if kind, exists := errorKinds[errorType]; exists {
+		providerErr.Kind = kind
+	}
+
+	var apiErr struct {
+		Message      string `json:"message"`
+		MessageUpper string `json:"Message"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil {
+		if message := firstNonEmpty(apiErr.Message, apiErr.MessageUpper); message != "" {
+			providerErr.Message = message
+		}
+	}
+	return providerErr
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,236 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+const testModelID = "anthropic.claude-3-5-sonnet-20240620-v1:0"
+
+// newTestClient returns a client for a local server that answers every request with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewBedrockClient(ClientConfig{
+		Region:          "us-east-1",
+		AccessKeyID:     exampleCredentials.AccessKeyID,
+		SecretAccessKey: exampleCredentials.SecretAccessKey,
+		BaseURL:         server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.now = func() time.Time { return exampleTime }
+	return c
+}
+
+// frame encodes an event stream message with string headers
+func frame(headers map[string]string, payload string) []byte {
+	var headerBytes bytes.Buffer
+	for name, value := range headers {
+		headerBytes.WriteByte(byte(len(name)))
+		headerBytes.WriteString(name)
+		headerBytes.WriteByte(headerTypeString)
+		binary.Write(&headerBytes, binary.BigEndian, uint16(len(value)))
+		headerBytes.WriteString(value)
+	}
+
+	totalLength := preludeLength + headerBytes.Len() + len(payload) + messageCRCLength
+	message := make([]byte, 0, totalLength)
+	message = binary.BigEndian.AppendUint32(message, uint32(totalLength))
+	message = binary.BigEndian.AppendUint32(message, uint32(headerBytes.Len()))
+	message = binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+	message = append(message, headerBytes.Bytes()...)
+	message = append(message, payload...)
+	return binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+}
+
+// malformedFrame encodes a message with the given lengths and valid checksums, padded to totalLength
+func malformedFrame(totalLength uint32, headersLength uint32) []byte {
+	message := binary.BigEndian.AppendUint32(nil, totalLength)
+	message = binary.BigEndian.AppendUint32(message, headersLength)
+	message = binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+	message = append(message, make([]byte, int(totalLength)-len(message)-messageCRCLength)...)
+	return binary.BigEndian.AppendUint32(message, crc32.ChecksumIEEE(message))
+}
+
+func event(eventType string, payload string) []byte {
+	return frame(map[string]string{
+		":message-type": "event",
+		":event-type":   eventType,
+		":content-type": "application/json",
+	}, payload)
+}
+
+// serveMessages answers with the framed messages, flushing after each like Bedrock does
+func serveMessages(messages ...[]byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		w.Header().Set("X-Amzn-Requestid", "request-1")
+		for _, message := range messages {
+			w.Write(message)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func collect(t *testing.T, c *Client, settings params.Settings) (content string, reasoning string, final params.StreamChunk) {
+	t.Helper()
+	chunks, err := c.StreamCompletionMessage(context.Background(), params.NewSimplePrompt("", "Greet me"), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contentBuilder strings.Builder
+	var reasoningBuilder strings.Builder
+	for chunk := range chunks {
+		switch {
+		case chunk.Done:
+			final = chunk
+		case chunk.Kind == params.StreamChunkKindReasoning:
+			reasoningBuilder.WriteString(chunk.Content)
+		default:
+			contentBuilder.WriteString(chunk.Content)
+		}
+	}
+	return contentBuilder.String(), reasoningBuilder.String(), final
+}
+
+func TestStreamCompletionMessage(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.EscapedPath(); got != "/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/converse-stream" {
+			t.Errorf("unexpected path %s", got)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/bedrock/") {
+			t.Errorf("unexpected authorization %s", r.Header.Get("Authorization"))
+		}
+		serveMessages(
+			event("messageStart", `{"role":"assistant"}`),
+			event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"reasoningContent":{"text":"Hmm"}}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"Hello"}}`),
+			event("contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":" world"}}`),
+			event("contentBlockStop", `{"contentBlockIndex":1}`),
+			event("messageStop", `{"stopReason":"end_turn"}`),
+			event("metadata", `{"usage":{"inputTokens":3,"outputTokens":2}}`),
+		)(w, r)
+	})
+
+	content, reasoning, final := collect(t, c, params.Settings{ModelName: testModelID, IncludeReasoning: true})
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content != "Hello world" {
+		t.Errorf("expected Hello world, got %q", content)
+	}
+	if reasoning != "Hmm" {
+		t.Errorf("expected reasoning Hmm, got %q", reasoning)
+	}
+	if final.ResponseID != "request-1" {
+		t.Errorf("expected response ID request-1, got %q", final.ResponseID)
+	}
+}
+
+func TestStreamCompletionMessageErrors(t *testing.T) {
+	hello := event("contentBlockDelta", `{"contentBlockIndex":0,"delta":{"text":"Hello"}}`)
+
+	badPrelude := bytes.Clone(hello)
+	badPrelude[8] ^= 0xff
+	badMessage := bytes.Clone(hello)
+	badMessage[len(badMessage)-1] ^= 0xff
+	// A headers length near 2^32 makes the lengths wrap around in uint32 to fit the total length
+	overflowingHeaders := malformedFrame(uint32(len(hello)), math.MaxUint32-preludeLength-messageCRCLength+1)
+	oversizedHeaders := malformedFrame(uint32(len(hello)), uint32(len(hello)))
+
+	tests := []struct {
+		name     string
+		messages [][]byte
+		expected string
+	}{
+		{"bad prelude checksum", [][]byte{hello, badPrelude}, "prelude checksum mismatch"},
+		{"bad message checksum", [][]byte{hello, badMessage}, "message checksum mismatch"},
+		{"truncated message", [][]byte{hello, hello[:len(hello)-6]}, "truncated event stream message"},
+		{"overflowing headers length", [][]byte{hello, overflowingHeaders}, "invalid event stream message length"},
+		{"headers longer than the message", [][]byte{hello, oversizedHeaders}, "invalid event stream message length"},
+		{"exception", [][]byte{hello, frame(map[string]string{
+			":message-type":   "exception",
+			":exception-type": "throttlingException",
+			":content-type":   "application/json",
+		}, `{"message":"Too many requests, please wait before trying again."}`)}, "throttlingException: Too many requests"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, serveMessages(tt.messages...))
+
+			content, _, final := collect(t, c, params.Settings{ModelName: testModelID})
+			if final.Error == nil || !strings.Contains(final.Error.Error(), tt.expected) {
+				t.Fatalf("expected an error containing %q, got %v", tt.expected, final.Error)
+			}
+			if content != "Hello" {
+				t.Errorf("expected the content before the error, got %q", content)
+			}
+		})
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	tests := []struct {
+		status    int
+		errorType string
+		body      string
+		kind      params.ErrorKind
+		message   string
+	}{
+		{400, "ValidationException:http://internal.amazon.com/coral/com.amazon.bedrock/",
+			`{"message":"The provided model identifier is invalid."}`, params.ErrorKindInvalidRequest, "The provided model identifier is invalid."},
+		{403, "AccessDeniedException:http://internal.amazon.com/coral/com.amazon.coral.service/",
+			`{"Message":"You don't have access to the model with the specified model ID."}`, params.ErrorKindPermission, "You don't have access to the model with the specified model ID."},
+		{403, "UnrecognizedClientException:http://internal.amazon.com/coral/com.amazon.coral.service/",
+			`{"message":"The security token included in the request is invalid."}`, params.ErrorKindAuthentication, "The security token included in the request is invalid."},
+		{400, "ExpiredTokenException:http://internal.amazon.com/coral/com.amazon.coral.service/",
+			`{"message":"The security token included in the request is expired"}`, params.ErrorKindAuthentication, "The security token included in the request is expired"},
+		{429, "ThrottlingException:http://internal.amazon.com/coral/com.amazon.bedrock/",
+			`{"message":"Too many requests, please wait before trying again."}`, params.ErrorKindRateLimit, "Too many requests, please wait before trying again."},
+		{503, "ServiceUnavailableException:http://internal.amazon.com/coral/com.amazon.bedrock/",
+			`Service Unavailable`, params.ErrorKindServer, "Service Unavailable"},
+	}
+
+	for _, tt := range tests {
+		code, _, _ := strings.Cut(tt.errorType, ":")
+		t.Run(code, func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Amzn-Errortype", tt.errorType)
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := c.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: testModelID})
+			var providerErr *params.ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("expected a provider error, got %v", err)
+			}
+			if providerErr.Provider != providerName || providerErr.StatusCode != tt.status || providerErr.Kind != tt.kind {
+				t.Errorf("expected %s status %d, got %+v", tt.kind, tt.status, providerErr)
+			}
+			if providerErr.Code != code {
+				t.Errorf("expected code %s, got %q", code, providerErr.Code)
+			}
+			if providerErr.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, providerErr.Message)
+			}
+		})
+	}
+}
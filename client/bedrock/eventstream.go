@@ -0,0 +1,143 @@
+package bedrock
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Messages of the AWS event stream encoding, which ConverseStream responds with, are framed as
+//
+//	total length (4) | headers length (4) | prelude CRC (4) | headers | payload | message CRC (4)
+//
+// with big endian integers and CRC32 (IEEE) checksums.
+const (
+	preludeLength    = 12
+	messageCRCLength = 4
+	// maxMessageLength guards against allocating huge buffers for a corrupt length
+	maxMessageLength = 16 * 1024 * 1024
+)
+
+// Header value types of the event stream encoding
+const (
+	headerTypeTrue = iota
+	headerTypeFalse
+	headerTypeByte
+	headerTypeShort
+	headerTypeInteger
+	headerTypeLong
+	headerTypeByteArray
+	headerTypeString
+	headerTypeTimestamp
+	headerTypeUUID
+)
+
+type eventMessage struct {
+	// Headers only keeps string headers such as :event-type and :message-type
+	Headers map[string]string
+	Payload []byte
+}
+
+type eventStreamDecoder struct {
+	reader io.Reader
+}
+
+func newEventStreamDecoder(reader io.Reader) *eventStreamDecoder {
+	return &eventStreamDecoder{reader: reader}
+}
+
+// Next returns the next message, or io.EOF at the end of the stream
+func (d *eventStreamDecoder) Next() (*eventMessage, error) {
+	prelude := make([]byte, preludeLength)
+	if _, err := io.ReadFull(d.reader, prelude); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated event stream prelude: %w", err)
+		}
+		return nil, err
+	}
+
+	totalLength := binary.BigEndian.Uint32(prelude[0:4])
+	headersLength := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, errors.New("event stream prelude checksum mismatch")
+	}
+	// Add in uint64, as a corrupt headers length would wrap around in uint32 and pass the check
+	if totalLength > maxMessageLength || uint64(totalLength) < preludeLength+messageCRCLength+uint64(headersLength) {
+		return nil, fmt.Errorf("invalid event stream message length %d", totalLength)
+	}
+
+	message := make([]byte, totalLength)
+	copy(message, prelude)
+	if _, err := io.ReadFull(d.reader, message[preludeLength:]); err != nil {
+		return nil, fmt.Errorf("truncated event stream message: %w", err)
+	}
+
+	crcOffset := totalLength - messageCRCLength
+	if crc32.ChecksumIEEE(message[:crcOffset]) != binary.BigEndian.Uint32(message[crcOffset:]) {
+		return nil, errors.New("event stream message checksum mismatch")
+	}
+
+	headersEnd := preludeLength + headersLength
+	headers, err := decodeHeaders(message[preludeLength:headersEnd])
+	if err != nil {
+		return nil, err
+	}
+
+	return &eventMessage{
+		Headers: headers,
+		Payload: message[headersEnd:crcOffset],
+	}, nil
+}
+
+func decodeHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLength := int(data[0])
+		if len(data) < 1+nameLength+1 {
+			return nil, errors.New("truncated event stream header")
+		}
+		name := string(data[1 : 1+nameLength])
+		valueType := data[1+nameLength]
+		data = data[2+nameLength:]
+
+		var valueLength int
+		switch valueType {
+		case headerTypeTrue, headerTypeFalse:
+			valueLength = 0
+		case headerTypeByte:
+			valueLength = 1
+		case headerTypeShort:
+			valueLength = 2
+		case headerTypeInteger:
+			valueLength = 4
+		case headerTypeLong, headerTypeTimestamp:
+			valueLength = 8
+		case headerTypeUUID:
+			valueLength = 16
+		case headerTypeByteArray, headerTypeString:
+			if len(data) < 2 {
+				return nil, errors.New("truncated event stream header")
+			}
+			length := int(binary.BigEndian.Uint16(data[0:2]))
+			data = data[2:]
+			if len(data) < length {
+				return nil, errors.New("truncated event stream header")
+			}
+			if valueType == headerTypeString {
+				headers[name] = string(data[:length])
+			}
+			data = data[length:]
+			continue
+		default:
+			return nil, fmt.Errorf("unknown event stream header type %d", valueType)
+		}
+
+		if len(data) < valueLength {
+			return nil, errors.New("truncated event stream header")
+		}
+		data = data[valueLength:]
+	}
+	return headers, nil
+}
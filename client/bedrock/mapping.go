@@ -0,0 +1,298 @@
+package bedrock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+type converseRequest struct {
+	Messages                     []message        `json:"messages"`
+	System                       []contentBlock   `json:"system,omitempty"`
+	InferenceConfig              *inferenceConfig `json:"inferenceConfig,omitempty"`
+	ToolConfig                   *toolConfig      `json:"toolConfig,omitempty"`
+	AdditionalModelRequestFields map[string]any   `json:"additionalModelRequestFields,omitempty"`
+	GuardrailConfig              *Guardrail       `json:"guardrailConfig,omitempty"`
+}
+
+type message struct {
+	Role    string         `json:"role"`
+	Content []contentBlock `json:"content"`
+}
+
+// contentBlock is a union, exactly one field is set
+type contentBlock struct {
+	Text             string            `json:"text,omitempty"`
+	ReasoningContent *reasoningContent `json:"reasoningContent,omitempty"`
+	ToolUse          *toolUse          `json:"toolUse,omitempty"`
+}
+
+type reasoningContent struct {
+	ReasoningText   *reasoningText `json:"reasoningText,omitempty"`
+	RedactedContent []byte         `json:"redactedContent,omitempty"`
+}
+
+type reasoningText struct {
+	Text      string `json:"text"`
+	Signature string `json:"signature,omitempty"`
+}
+
+type toolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type inferenceConfig struct {
+	MaxTokens     *int     `json:"maxTokens,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	TopP          *float64 `json:"topP,omitempty"`
+	StopSequences []string `json:"stopSequences,omitempty"`
+}
+
+type toolConfig struct {
+	Tools      []tool      `json:"tools"`
+	ToolChoice *toolChoice `json:"toolChoice,omitempty"`
+}
+
+type tool struct {
+	ToolSpec toolSpec `json:"toolSpec"`
+}
+
+type toolSpec struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema inputSchema `json:"inputSchema"`
+}
+
+type inputSchema struct {
+	JSON any `json:"json"`
+}
+
+type toolChoice struct {
+	Tool *namedTool `json:"tool,omitempty"`
+}
+
+type namedTool struct {
+	Name string `json:"name"`
+}
+
+type converseResponse struct {
+	Output struct {
+		Message message `json:"message"`
+	} `json:"output"`
+	StopReason string `json:"stopReason"`
+}
+
+// Stop reasons when a guardrail or the model's own filter blocked the reply
+var blockedStopReasons = map[string]bool{
+	"guardrail_intervened": true,
+	"content_filtered":     true,
+}
+
+// validateSettings rejects settings that the Converse API has no equivalent for
+func validateSettings(settings params.Settings) error {
+	switch {
+	case settings.Seed != nil:
+		return errors.New("seed is not supported by Bedrock")
+	case settings.PresencePenalty != nil, settings.FrequencyPenalty != nil:
+		return errors.New("presence and frequency penalties are not supported by Bedrock")
+	case len(settings.LogitBias) > 0:
+		return errors.New("logit bias is not supported by Bedrock")
+	case settings.CandidateCount != nil && *settings.CandidateCount > 1:
+		return errors.New("multiple candidates are not supported by Bedrock")
+	case settings.Logprobs, settings.TopLogprobs != nil:
+		return errors.New("logprobs are not supported by Bedrock")
+	case settings.IsSearchEnabled:
+		return errors.New("search is not supported by Bedrock")
+	case settings.Safety != nil:
+		return errors.New("safety settings are not supported by Bedrock, use a guardrail in bedrock.Options instead")
+	case settings.ThinkingTokens != nil && *settings.ThinkingTokens < 0:
+		return errors.New("dynamic thinking is not supported by Bedrock, set an explicit thinking budget")
+	case settings.TopK != nil && !isAnthropicModel(settings.ModelName):
+		return fmt.Errorf("top k is only supported by Claude models on Bedrock, not %s", settings.ModelName)
+	case getThinkingBudget(settings) > 0 && !isAnthropicModel(settings.ModelName):
+		return fmt.Errorf("thinking is only supported by Claude models on Bedrock, not %s", settings.ModelName)
+	}
+	return nil
+}
+
+// isAnthropicModel reports whether a model ID, inference profile ID or ARN of either is a Claude model,
+// which is the only one that takes top_k and thinking as model fields
+func isAnthropicModel(modelID string) bool {
+	id := modelID[strings.LastIndex(modelID, "/")+1:]
+	if strings.HasPrefix(id, "anthropic.") {
+		return true
+	}
+	// Cross-region inference profiles prefix the model ID with a geography, e.g. us.anthropic.claude-sonnet-4
+	_, model, found := strings.Cut(id, ".")
+	return found && strings.HasPrefix(model, "anthropic.")
+}
+
+func (c *Client) mapPromptToRequest(prompt params.Prompt, settings params.Settings) (*converseRequest, error) {
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+	if prompt.PreviousResponseID != "" {
+		return nil, errors.New("previous response IDs are not supported by Bedrock")
+	}
+	if len(prompt.Documents) > 0 {
+		return nil, errors.New("documents are not supported by Bedrock")
+	}
+
+	request := &converseRequest{}
+	config := inferenceConfig{
+		MaxTokens:     settings.MaxOutputTokens,
+		Temperature:   settings.Temperature,
+		TopP:          settings.TopP,
+		StopSequences: settings.StopSequences,
+	}
+	if !reflect.ValueOf(config).IsZero() {
+		request.InferenceConfig = &config
+	}
+
+	// Claude takes top_k and thinking as model specific fields. validateSettings rejects them for other models.
+	fields := map[string]any{}
+	if settings.TopK != nil {
+		fields["top_k"] = *settings.TopK
+	}
+	budget := getThinkingBudget(settings)
+	if budget > 0 {
+		fields["thinking"] = map[string]any{"type": "enabled", "budget_tokens": budget}
+	}
+	if len(fields) > 0 {
+		request.AdditionalModelRequestFields = fields
+	}
+
+	if prompt.ResponseFormat != nil {
+		// Bedrock has no JSON mode, and Claude rejects a forced tool choice while thinking.
+		// In those cases the schema is described in the system message instead.
+		useTool := budget == 0
+		switch c.structuredOutputMode {
+		case params.StructuredOutputJSONObject, params.StructuredOutputNone:
+			useTool = false
+		}
+
+		if useTool {
+			request.ToolConfig = mapResponseFormatToTool(prompt.ResponseFormat)
+		} else {
+			var err error
+			if prompt, err = structured.WithSchemaInstruction(prompt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if prompt.SystemMessage != "" {
+		request.System = []contentBlock{{Text: prompt.SystemMessage}}
+	}
+	request.Messages = mapPromptToMessages(prompt)
+
+	applyOptions(request, optionsFromSettings(settings))
+	return request, nil
+}
+
+// mapResponseFormatToTool forces the model to call a tool whose input is the response format,
+// which is how the Converse API returns JSON that matches a schema
+func mapResponseFormatToTool(responseFormat any) *toolConfig {
+	t := reflect.TypeOf(responseFormat)
+	name := schema.Name(t)
+	return &toolConfig{
+		Tools: []tool{{
+			ToolSpec: toolSpec{
+				Name:        name,
+				Description: "Respond with JSON that matches this schema",
+				InputSchema: inputSchema{JSON: schema.ForGemini(t)},
+			},
+		}},
+		ToolChoice: &toolChoice{Tool: &namedTool{Name: name}},
+	}
+}
+
+// mapPromptToMessages merges consecutive messages of the same role,
+// because the Converse API requires user and assistant turns to alternate
+func mapPromptToMessages(prompt params.Prompt) []message {
+	messages := []message{}
+	for _, msg := range prompt.Messages {
+		role := "user"
+		if msg.Role == params.MessageRoleAssistant {
+			role = "assistant"
+		}
+
+		block := contentBlock{Text: msg.Content}
+		if last := len(messages) - 1; last >= 0 && messages[last].Role == role {
+			messages[last].Content = append(messages[last].Content, block)
+			continue
+		}
+		messages = append(messages, message{Role: role, Content: []contentBlock{block}})
+	}
+	return messages
+}
+
+// getThinkingBudget returns the thinking budget in tokens, or 0 if thinking is disabled.
+// Claude requires a budget of at least 1024 tokens.
+func getThinkingBudget(settings params.Settings) int {
+	if settings.ThinkingTokens != nil {
+		return *settings.ThinkingTokens
+	}
+
+	switch settings.ThinkingBudget {
+	case params.MinimalThinkingBudget:
+		return 1024
+	case params.SmallThinkingBudget:
+		return 2048
+	case params.MediumThinkingBudget:
+		return 4096
+	case params.LargeThinkingBudget:
+		return 8192
+	default:
+		return 0
+	}
+}
+
+// mapContent returns the answer and the reasoning of the reply. If the model called the response
+// format tool, its input is the answer.
+func mapContent(msg message) (content string, reasoning string) {
+	var text, thinking strings.Builder
+	var toolInput string
+	for _, block := range msg.Content {
+		switch {
+		case block.ToolUse != nil:
+			toolInput = string(block.ToolUse.Input)
+		case block.ReasoningContent != nil && block.ReasoningContent.ReasoningText != nil:
+			thinking.WriteString(block.ReasoningContent.ReasoningText.Text)
+		default:
+			text.WriteString(block.Text)
+		}
+	}
+
+	if toolInput != "" {
+		return toolInput, thinking.String()
+	}
+	return text.String(), thinking.String()
+}
+
+func contentFilterError(stopReason string, content string) error {
+	if !blockedStopReasons[stopReason] {
+		return nil
+	}
+	return &params.ContentFilterError{
+		PromptBlocked: false,
+		Reason:        stopReason,
+		Message:       content,
+	}
+}
+
+func marshalRequest(request *converseRequest) ([]byte, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,67 @@
+package bedrock
+
+import (
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+func TestIsAnthropicModel(t *testing.T) {
+	tests := map[string]bool{
+		"anthropic.claude-3-5-sonnet-20240620-v1:0":                                                             true,
+		"us.anthropic.claude-sonnet-4-20250514-v1:0":                                                            true,
+		"global.anthropic.claude-sonnet-4-20250514-v1:0":                                                        true,
+		"arn:aws:bedrock:us-east-1::foundation-model/anthropic.claude-3-haiku-20240307-v1:0":                    true,
+		"arn:aws:bedrock:us-east-1:123456789012:inference-profile/eu.anthropic.claude-3-7-sonnet-20250219-v1:0": true,
+		"meta.llama3-1-70b-instruct-v1:0":                                                                       false,
+		"us.meta.llama3-2-90b-instruct-v1:0":                                                                    false,
+		"amazon.nova-pro-v1:0":                                                                                  false,
+		"mistral.mistral-large-2407-v1:0":                                                                       false,
+		"us.amazon.anthropic-lookalike-v1:0":                                                                    false,
+	}
+
+	for modelID, expected := range tests {
+		if got := isAnthropicModel(modelID); got != expected {
+			t.Errorf("%s: expected %v, got %v", modelID, expected, got)
+		}
+	}
+}
+
+func TestClaudeOnlyModelFields(t *testing.T) {
+	topK := 40
+	thinking := 2048
+	maxOutputTokens := 8192
+	c := &Client{}
+
+	for _, settings := range []params.Settings{
+		{TopK: &topK},
+		{ThinkingTokens: &thinking, MaxOutputTokens: &maxOutputTokens},
+		{ThinkingBudget: params.SmallThinkingBudget},
+	} {
+		settings.ModelName = "meta.llama3-1-70b-instruct-v1:0"
+		if err := c.ValidateSettings(settings); err == nil {
+			t.Errorf("expected %+v to be rejected for a non-Claude model", settings)
+		}
+		if _, err := c.mapPromptToRequest(params.NewSimplePrompt("", "Hi"), settings); err == nil {
+			t.Errorf("expected no request for %+v on a non-Claude model", settings)
+		}
+
+		settings.ModelName = "us.anthropic.claude-sonnet-4-20250514-v1:0"
+		request, err := c.mapPromptToRequest(params.NewSimplePrompt("", "Hi"), settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(request.AdditionalModelRequestFields) == 0 {
+			t.Errorf("expected model fields for Claude with %+v", settings)
+		}
+	}
+
+	// Without top k or thinking, other models get no model fields
+	request, err := c.mapPromptToRequest(params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "amazon.nova-pro-v1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.AdditionalModelRequestFields != nil {
+		t.Errorf("expected no model fields, got %v", request.AdditionalModelRequestFields)
+	}
+}
@@ -0,0 +1,66 @@
+package bedrock
+
+import (
+	"maps"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+const providerName = "bedrock"
+
+// Options are Bedrock specific settings. Add them to params.Settings.ProviderOptions.
+type Options struct {
+	// AdditionalModelRequestFields are passed to the model as is, for model specific parameters
+	AdditionalModelRequestFields map[string]any
+	// Guardrail applies a Bedrock guardrail to the prompt and the reply
+	Guardrail *Guardrail
+}
+
+type Guardrail struct {
+	Identifier string `json:"guardrailIdentifier"`
+	Version    string `json:"guardrailVersion"`
+	// Trace is "enabled" or "disabled"
+	Trace string `json:"trace,omitempty"`
+}
+
+func (o Options) ProviderName() string {
+	return providerName
+}
+
+// optionsFromSettings returns the Bedrock options of the settings, ignoring other providers' options.
+// If several are given, later options override earlier ones field by field.
+func optionsFromSettings(settings params.Settings) Options {
+	var result Options
+	for _, providerOptions := range settings.ProviderOptions {
+		var opts Options
+		switch o := providerOptions.(type) {
+		case Options:
+			opts = o
+		case *Options:
+			if o == nil {
+				continue
+			}
+			opts = *o
+		default:
+			continue
+		}
+
+		if opts.AdditionalModelRequestFields != nil {
+			result.AdditionalModelRequestFields = opts.AdditionalModelRequestFields
+		}
+		if opts.Guardrail != nil {
+			result.Guardrail = opts.Guardrail
+		}
+	}
+	return result
+}
+
+func applyOptions(request *converseRequest, opts Options) {
+	if opts.AdditionalModelRequestFields != nil {
+		if request.AdditionalModelRequestFields == nil {
+			request.AdditionalModelRequestFields = map[string]any{}
+		}
+		maps.Copy(request.AdditionalModelRequestFields, opts.AdditionalModelRequestFields)
+	}
+	request.GuardrailConfig = opts.Guardrail
+}
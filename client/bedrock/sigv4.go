@@ -0,0 +1,165 @@
+package bedrock
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	signingService   = "bedrock"
+	amzDateFormat    = "20060102T150405Z"
+	shortDateFormat  = "20060102"
+)
+
+type credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// signRequest adds AWS Signature Version 4 headers to req. The body must be the exact bytes sent.
+func signRequest(req *http.Request, body []byte, creds credentials, region string, now time.Time) {
+	now = now.UTC()
+	payloadHash := hashHex(body)
+
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	authorize(req, payloadHash, creds, region, signingService, now)
+}
+
+// authorize signs the request as it is, including the X-Amz-Date header, and sets the Authorization header
+func authorize(req *http.Request, payloadHash string, creds credentials, region string, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateFormat)
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req),
+		canonicalQuery(req),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(shortDateFormat), region, service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := signingKey(creds.SecretAccessKey, now, region, service)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func signingKey(secretAccessKey string, now time.Time, region string, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), now.UTC().Format(shortDateFormat))
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+// canonicalURI encodes the already escaped path a second time, as every service except S3 expects
+func canonicalURI(req *http.Request) string {
+	path := req.URL.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, escape(key)+"="+escape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// canonicalizeHeaders signs the host, content type and every x-amz- header
+func canonicalizeHeaders(req *http.Request) (canonical string, signed string) {
+	headers := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		headers["host"] = req.Host
+	}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, value := range values {
+				trimmed[i] = strings.Join(strings.Fields(value), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return builder.String(), strings.Join(names, ";")
+}
+
+// escape percent-encodes everything except the unreserved characters of RFC 3986
+func escape(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			builder.WriteByte(c)
+		} else {
+			fmt.Fprintf(&builder, "%%%02X", c)
+		}
+	}
+	return builder.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,103 @@
+package bedrock
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The credentials and date of the AWS Signature Version 4 test suite
+var (
+	exampleCredentials = credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	exampleTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+)
+
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func TestSigningKey(t *testing.T) {
+	// The key derivation example of the AWS documentation
+	key := signingKey(exampleCredentials.SecretAccessKey, time.Date(2012, 2, 15, 0, 0, 0, 0, time.UTC), "us-east-1", "iam")
+	expected := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got := hex.EncodeToString(key); got != expected {
+		t.Errorf("expected signing key %s, got %s", expected, got)
+	}
+}
+
+func TestAuthorizeTestSuite(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		url       string
+		signature string
+	}{
+		{"get-vanilla", http.MethodGet, "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"post-vanilla", http.MethodPost, "https://example.amazonaws.com/", "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b"},
+		{"get-vanilla-query-order-key-case", http.MethodGet, "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Amz-Date", "20150830T123600Z")
+
+			authorize(req, emptyPayloadHash, exampleCredentials, "us-east-1", "service", exampleTime)
+
+			expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=host;x-amz-date, Signature=" + tt.signature
+			if got := req.Header.Get("Authorization"); got != expected {
+				t.Errorf("expected\n%s\ngot\n%s", expected, got)
+			}
+		})
+	}
+}
+
+func TestCanonicalURIEncodesModelIDTwice(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost,
+		"https://bedrock-runtime.us-east-1.amazonaws.com/model/"+escape("anthropic.claude-3-5-sonnet-20240620-v1:0")+"/converse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := req.URL.EscapedPath(); got != "/model/anthropic.claude-3-5-sonnet-20240620-v1%3A0/converse" {
+		t.Errorf("expected the colon to be escaped once in the path, got %s", got)
+	}
+	if got := canonicalURI(req); got != "/model/anthropic.claude-3-5-sonnet-20240620-v1%253A0/converse" {
+		t.Errorf("expected the colon to be escaped twice in the canonical URI, got %s", got)
+	}
+}
+
+func TestSignRequestSignsPayloadAndSessionToken(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://bedrock-runtime.us-east-1.amazonaws.com/model/m/converse", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	creds := exampleCredentials
+	creds.SessionToken = "token"
+
+	signRequest(req, []byte(`{}`), creds, "us-east-1", exampleTime)
+
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != hashHex([]byte(`{}`)) {
+		t.Errorf("expected the payload hash header, got %s", got)
+	}
+	if got := req.Header.Get("X-Amz-Security-Token"); got != "token" {
+		t.Errorf("expected the session token header, got %s", got)
+	}
+	authorization := req.Header.Get("Authorization")
+	for _, part := range []string{
+		"Credential=AKIDEXAMPLE/20150830/us-east-1/bedrock/aws4_request",
+		"SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token",
+	} {
+		if !strings.Contains(authorization, part) {
+			t.Errorf("expected %s in %s", part, authorization)
+		}
+	}
+}
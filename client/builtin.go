@@ -0,0 +1,149 @@
+package client
+
+import (
+	"github.com/jamesleeht/llm-gopher/client/bedrock"
+	"github.com/jamesleeht/llm-gopher/client/cohere"
+	"github.com/jamesleeht/llm-gopher/client/mistral"
+	"github.com/jamesleeht/llm-gopher/client/oai"
+	"github.com/jamesleeht/llm-gopher/client/ollama"
+	"github.com/jamesleeht/llm-gopher/client/vertex"
+)
+
+// The built-in providers accept their own config type or the shared ClientConfig
+func init() {
+	registerBuiltin(ClientTypeOpenAI,
+		func(config oai.ClientConfig) (ProviderClient, error) {
+			return oai.NewOpenAIClient(config), nil
+		},
+		func(config ClientConfig) oai.ClientConfig {
+			return oai.ClientConfig{
+				APIKey:               config.APIKey,
+				BaseURL:              config.BaseURL,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeOpenAIResponses,
+		func(config oai.ClientConfig) (ProviderClient, error) {
+			config.UseResponsesAPI = true
+			return oai.NewOpenAIClient(config), nil
+		},
+		func(config ClientConfig) oai.ClientConfig {
+			return oai.ClientConfig{
+				APIKey:               config.APIKey,
+				BaseURL:              config.BaseURL,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeAzureOpenAI,
+		func(config oai.AzureConfig) (ProviderClient, error) {
+			return nilOnError(oai.NewAzureOpenAIClient(config))
+		},
+		func(config ClientConfig) oai.AzureConfig {
+			return oai.AzureConfig{
+				Endpoint:             config.BaseURL,
+				APIVersion:           config.AzureAPIVersion,
+				Deployments:          config.AzureDeployments,
+				APIKey:               config.APIKey,
+				TokenProvider:        config.AzureTokenProvider,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeVertex,
+		func(config vertex.ClientConfig) (ProviderClient, error) {
+			return nilOnError(vertex.NewVertexAIClient(config))
+		},
+		func(config ClientConfig) vertex.ClientConfig {
+			return vertex.ClientConfig{
+				ProjectID:             config.ProjectID,
+				Location:              config.Location,
+				CredentialsPath:       config.VertexCredentialsPath,
+				CredentialsJSONString: config.VertexCredentialsJSON,
+				StructuredOutputMode:  config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeGemini,
+		func(config vertex.ClientConfig) (ProviderClient, error) {
+			return nilOnError(vertex.NewGeminiClient(config))
+		},
+		func(config ClientConfig) vertex.ClientConfig {
+			return vertex.ClientConfig{
+				APIKey:               config.APIKey,
+				BaseURL:              config.BaseURL,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeOllama,
+		func(config ollama.ClientConfig) (ProviderClient, error) {
+			return ollama.NewOllamaClient(config), nil
+		},
+		func(config ClientConfig) ollama.ClientConfig {
+			return ollama.ClientConfig{
+				BaseURL:              config.BaseURL,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeBedrock,
+		func(config bedrock.ClientConfig) (ProviderClient, error) {
+			return nilOnError(bedrock.NewBedrockClient(config))
+		},
+		func(config ClientConfig) bedrock.ClientConfig {
+			return bedrock.ClientConfig{
+				Region:               config.AWSRegion,
+				AccessKeyID:          config.AWSAccessKeyID,
+				SecretAccessKey:      config.AWSSecretAccessKey,
+				SessionToken:         config.AWSSessionToken,
+				BaseURL:              config.BaseURL,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeMistral,
+		func(config mistral.ClientConfig) (ProviderClient, error) {
+			return nilOnError(mistral.NewMistralClient(config))
+		},
+		func(config ClientConfig) mistral.ClientConfig {
+			return mistral.ClientConfig{
+				APIKey:               config.APIKey,
+				BaseURL:              config.BaseURL,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+
+	registerBuiltin(ClientTypeCohere,
+		func(config cohere.ClientConfig) (ProviderClient, error) {
+			return nilOnError(cohere.NewCohereClient(config))
+		},
+		func(config ClientConfig) cohere.ClientConfig {
+			return cohere.ClientConfig{
+				APIKey:               config.APIKey,
+				BaseURL:              config.BaseURL,
+				StructuredOutputMode: config.StructuredOutputMode,
+			}
+		})
+}
+
+// registerBuiltin registers a provider that also accepts the shared ClientConfig,
+// which is converted to the provider's config
+func registerBuiltin[C any](clientType ClientType,
+	factory func(config C) (ProviderClient, error),
+	fromClientConfig func(config ClientConfig) C) {
+
+	typed := typedFactory(clientType, factory)
+	RegisterFactory(clientType, func(config any) (ProviderClient, error) {
+		switch c := config.(type) {
+		case ClientConfig:
+			return factory(fromClientConfig(c))
+		case *ClientConfig:
+			if c != nil {
+				return factory(fromClientConfig(*c))
+			}
+		}
+		return typed(config)
+	})
+}
@@ -2,25 +2,45 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/jamesleeht/llm-gopher/client/oai"
-	"github.com/jamesleeht/llm-gopher/client/vertex"
 	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
 )
 
 type Client struct {
-	OpenAIClient   ProviderClient
-	VertexAIClient ProviderClient
-	ClientType     ClientType
+	// Provider sends the requests, and may implement TokenCounter, EmbeddingClient and SettingsValidator
+	Provider   ProviderClient
+	ClientType ClientType
 }
 
+// ClientConfig is a shared config that every built-in provider accepts. Providers also accept their own
+// config type, which exposes settings that aren't listed here.
 type ClientConfig struct {
 	APIKey string
 
-	// OpenAI only
+	// StructuredOutputMode is how the backend supports structured output. Defaults to native JSON schema support.
+	// With JSON object mode or none, the schema is described in the system message instead.
+	StructuredOutputMode params.StructuredOutputMode
+
+	// OpenAI, Gemini, Ollama, Bedrock, Mistral and Cohere only. For Azure OpenAI, this is the resource endpoint.
 	BaseURL string
 
+	// Azure OpenAI only
+	AzureAPIVersion string
+	// AzureDeployments maps model names to deployment names. Unlisted models are used as the deployment name.
+	AzureDeployments map[string]string
+	// AzureTokenProvider authenticates with bearer tokens, e.g. from Microsoft Entra ID, instead of APIKey
+	AzureTokenProvider oai.TokenProvider
+
+	// Bedrock only. The region and credentials default to the standard AWS environment variables.
+	AWSRegion          string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+
 	// Vertex only
 	ProjectID             string
 	Location              string
@@ -33,59 +53,153 @@ type ProviderClient interface {
 	StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error)
 }
 
-func NewClient(config ClientConfig, clientType ClientType) (*Client, error) {
-	var openAIClient *oai.Client
-	var vertexAIClient *vertex.Client
-
-	switch clientType {
-	case ClientTypeOpenAI:
-		openAIClient = oai.NewOpenAIClient(oai.ClientConfig{
-			APIKey:  config.APIKey,
-			BaseURL: config.BaseURL,
-		})
-	case ClientTypeVertex:
-		var err error
-		if vertexAIClient, err = vertex.NewVertexAIClient(vertex.ClientConfig{
-			ProjectID:             config.ProjectID,
-			Location:              config.Location,
-			CredentialsPath:       config.VertexCredentialsPath,
-			CredentialsJSONString: config.VertexCredentialsJSON,
-		}); err != nil {
-			return nil, err
-		}
+// TokenCounter is implemented by provider clients that can count the tokens of a prompt before sending it
+type TokenCounter interface {
+	CountTokens(ctx context.Context, prompt params.Prompt, settings params.Settings) (int, error)
+}
+
+// EmbeddingClient is implemented by provider clients that can create embeddings.
+// Inputs larger than the provider's maximum batch size are split into several requests.
+type EmbeddingClient interface {
+	CreateEmbeddings(ctx context.Context, texts []string, settings params.EmbeddingSettings) (*params.EmbeddingResponse, error)
+}
+
+// SettingsValidator is implemented by provider clients that reject some settings,
+// such as sampling parameters the provider has no equivalent for
+type SettingsValidator interface {
+	ValidateSettings(settings params.Settings) error
+}
+
+// NewClient creates a client of a registered client type. The config is either the provider's own config,
+// e.g. ollama.ClientConfig or a third party provider's config, or a ClientConfig for the built-in providers.
+func NewClient(config any, clientType ClientType) (*Client, error) {
+	factory, ok := lookupFactory(clientType)
+	if !ok {
+		return nil, fmt.Errorf("client type %s is not registered", clientType)
+	}
+
+	provider, err := factory(config)
+	if err != nil {
+		return nil, err
+	}
+	if isNil(provider) {
+		return nil, fmt.Errorf("client type %s created no provider", clientType)
 	}
 
 	return &Client{
-		OpenAIClient:   openAIClient,
-		VertexAIClient: vertexAIClient,
-		ClientType:     clientType,
+		Provider:   provider,
+		ClientType: clientType,
 	}, nil
 }
 
+// SendMessage sends the prompt to the provider. If the prompt has a response format and the reply
+// doesn't match its schema, the reply is sent back for repair up to settings.RepairAttempts times.
 func (c *Client) SendMessage(ctx context.Context,
 	prompt params.Prompt,
 	settings params.Settings) (*params.Response, error) {
 
-	switch c.ClientType {
-	case ClientTypeOpenAI:
-		return c.OpenAIClient.SendCompletionMessage(ctx, prompt, settings)
-	case ClientTypeVertex:
-		return c.VertexAIClient.SendCompletionMessage(ctx, prompt, settings)
+	provider, err := c.providerClient()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("client type not supported")
+	attemptPrompt := prompt
+	for attempt := 1; ; attempt++ {
+		response, err := provider.SendCompletionMessage(ctx, attemptPrompt, settings)
+
+		var outputErr *params.StructuredOutputError
+		if !errors.As(err, &outputErr) {
+			return response, err
+		}
+
+		outputErr.Attempts = attempt
+		if attempt > settings.RepairAttempts {
+			return nil, outputErr
+		}
+		attemptPrompt = structured.RepairPrompt(attemptPrompt, outputErr)
+	}
 }
 
+func (c *Client) Embed(ctx context.Context,
+	texts []string,
+	settings params.EmbeddingSettings) (*params.EmbeddingResponse, error) {
+
+	provider, err := c.providerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	embeddingClient, ok := provider.(EmbeddingClient)
+	if !ok {
+		return nil, fmt.Errorf("client type %s does not support embeddings", c.ClientType)
+	}
+	return embeddingClient.CreateEmbeddings(ctx, texts, settings)
+}
+
+// StreamMessage streams the reply to the prompt. If the prompt has a response format, chunks carry
+// a progressively populated Partial value and the final chunk carries the validated Parsed value.
+// Repair attempts are not made in streaming mode.
 func (c *Client) StreamMessage(ctx context.Context,
 	prompt params.Prompt,
 	settings params.Settings) (<-chan params.StreamChunk, error) {
 
-	switch c.ClientType {
-	case ClientTypeOpenAI:
-		return c.OpenAIClient.StreamCompletionMessage(ctx, prompt, settings)
-	case ClientTypeVertex:
-		return c.VertexAIClient.StreamCompletionMessage(ctx, prompt, settings)
+	provider, err := c.providerClient()
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := provider.StreamCompletionMessage(ctx, prompt, settings)
+	if err != nil || prompt.ResponseFormat == nil {
+		return chunks, err
 	}
+	return structured.StreamPartial(chunks, prompt.ResponseFormat), nil
+}
 
-	return nil, fmt.Errorf("client type not supported")
+// CountTokens returns the number of input tokens the prompt uses.
+// It returns an error if the client type doesn't support token counting.
+func (c *Client) CountTokens(ctx context.Context,
+	prompt params.Prompt,
+	settings params.Settings) (int, error) {
+
+	provider, err := c.providerClient()
+	if err != nil {
+		return 0, err
+	}
+
+	tokenCounter, ok := provider.(TokenCounter)
+	if !ok {
+		return 0, fmt.Errorf("client type %s does not support token counting", c.ClientType)
+	}
+	return tokenCounter.CountTokens(ctx, prompt, settings)
+}
+
+// ValidateSettings returns an error if the provider cannot send the settings
+func (c *Client) ValidateSettings(settings params.Settings) error {
+	provider, err := c.providerClient()
+	if err != nil {
+		return err
+	}
+
+	validator, ok := provider.(SettingsValidator)
+	if !ok {
+		return nil
+	}
+	return validator.ValidateSettings(settings)
+}
+
+// SupportsTokenCounting reports whether CountTokens is available for this client
+func (c *Client) SupportsTokenCounting() bool {
+	provider, err := c.providerClient()
+	if err != nil {
+		return false
+	}
+	_, ok := provider.(TokenCounter)
+	return ok
+}
+
+func (c *Client) providerClient() (ProviderClient, error) {
+	if c.Provider == nil {
+		return nil, fmt.Errorf("client type %s has no provider", c.ClientType)
+	}
+	return c.Provider, nil
 }
@@ -0,0 +1,105 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/client/oai"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+// replyProvider answers with the next reply in order and parses it like the provider clients do
+type replyProvider struct {
+	replies []string
+	prompts []params.Prompt
+}
+
+func (p *replyProvider) SendCompletionMessage(_ context.Context, prompt params.Prompt, _ params.Settings) (*params.Response, error) {
+	reply := p.replies[min(len(p.prompts), len(p.replies)-1)]
+	p.prompts = append(p.prompts, prompt)
+
+	response := &params.Response{Content: reply}
+	if prompt.ResponseFormat != nil {
+		if err := structured.Parse(reply, prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+	return response, nil
+}
+
+func (p *replyProvider) StreamCompletionMessage(context.Context, params.Prompt, params.Settings) (<-chan params.StreamChunk, error) {
+	return nil, errors.New("streaming is not supported")
+}
+
+func TestSendMessageRepairsInvalidReply(t *testing.T) {
+	provider := &replyProvider{replies: []string{`{"city":"Paris"}`, `{"city":"Paris","temperature":21}`}}
+	c := &client.Client{Provider: provider, ClientType: client.ClientTypeOpenAI}
+
+	var result weather
+	prompt := params.NewSimplePrompt("", "Weather in Paris?")
+	prompt.ResponseFormat = &result
+
+	response, err := c.SendMessage(context.Background(), prompt, params.Settings{RepairAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Parsed != &result || result.Temperature != 21 {
+		t.Errorf("expected the repaired reply to be parsed, got %+v", result)
+	}
+	if len(provider.prompts) != 2 || len(provider.prompts[1].Messages) != 3 {
+		t.Errorf("expected the invalid reply to be sent back for repair, got %d requests", len(provider.prompts))
+	}
+}
+
+func TestSendMessageFailsAfterRepairAttempts(t *testing.T) {
+	provider := &replyProvider{replies: []string{`{"city":"Paris"}`}}
+	c := &client.Client{Provider: provider, ClientType: client.ClientTypeOpenAI}
+
+	prompt := params.NewSimplePrompt("", "Weather in Paris?")
+	prompt.ResponseFormat = &weather{}
+
+	_, err := c.SendMessage(context.Background(), prompt, params.Settings{RepairAttempts: 2})
+	var outputErr *params.StructuredOutputError
+	if !errors.As(err, &outputErr) {
+		t.Fatalf("expected a structured output error, got %v", err)
+	}
+	if outputErr.Attempts != 3 || len(provider.prompts) != 3 {
+		t.Errorf("expected RepairAttempts+1 attempts, got %d attempts and %d requests", outputErr.Attempts, len(provider.prompts))
+	}
+}
+
+func TestNewClientAzureOpenAIUsesTokenProvider(t *testing.T) {
+	var path, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o",`+
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	c, err := client.NewClient(client.ClientConfig{
+		BaseURL:          server.URL,
+		AzureDeployments: map[string]string{"gpt-4o": "prod-gpt-4o"},
+		AzureTokenProvider: oai.TokenProviderFunc(func(ctx context.Context) (string, error) {
+			return "entra-token", nil
+		}),
+	}, client.ClientTypeAzureOpenAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.SendMessage(context.Background(), params.NewSimplePrompt("", "Hello"), params.Settings{ModelName: "gpt-4o"}); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/openai/deployments/prod-gpt-4o/chat/completions" || authorization != "Bearer entra-token" {
+		t.Errorf("expected the deployment and bearer token, got %s and %q", path, authorization)
+	}
+}
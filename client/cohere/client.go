@@ -0,0 +1,296 @@
+package cohere
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+const defaultBaseURL = "https://api.cohere.com/v2"
+
+type ClientConfig struct {
+	// APIKey defaults to the CO_API_KEY environment variable
+	APIKey string `json:"api_key,omitempty"`
+	// BaseURL defaults to https://api.cohere.com/v2
+	BaseURL string `json:"base_url,omitempty"`
+	// StructuredOutputMode defaults to native JSON schema support
+	StructuredOutputMode params.StructuredOutputMode `json:"structured_output_mode,omitempty"`
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client `json:"-"`
+}
+
+// Client talks to Cohere's v2 Chat API, which grounds replies in documents and cites them
+type Client struct {
+	apiKey               string
+	baseURL              string
+	httpClient           *http.Client
+	structuredOutputMode params.StructuredOutputMode
+}
+
+func NewCohereClient(config ClientConfig) (*Client, error) {
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("CO_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, errors.New("cohere api key is required")
+	}
+
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		apiKey:               apiKey,
+		baseURL:              baseURL,
+		httpClient:           httpClient,
+		structuredOutputMode: config.StructuredOutputMode,
+	}, nil
+}
+
+// ValidateSettings reports settings that this client cannot send, so presets can be rejected up front
+func (c *Client) ValidateSettings(settings params.Settings) error {
+	return validateSettings(settings)
+}
+
+func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	request, err := c.mapPromptToRequest(prompt, settings, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/chat", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var chat chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("failed to decode chat response: %w", err)
+	}
+
+	content, reasoning := mapContent(&chat)
+	response := &params.Response{
+		ResponseID: chat.ID,
+		Content:    content,
+		Parsed:     nil,
+		Grounding:  mapCitations(chat.Message.Citations, content),
+	}
+	if settings.IncludeReasoning {
+		response.Reasoning = reasoning
+	}
+
+	// If response format is specified, validate the reply and unmarshal into that type
+	if prompt.ResponseFormat != nil {
+		if err := structured.Parse(response.Content, prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+
+	return response, nil
+}
+
+// streamEvent is a streamed event. The shape of the delta depends on the type, e.g. message-start
+// has a list of citations while citation-start has a single one, so it is decoded per type.
+type streamEvent struct {
+	Type  string          `json:"type"`
+	ID    string          `json:"id"`
+	Delta json.RawMessage `json:"delta"`
+}
+
+type contentDelta struct {
+	Message struct {
+		Content struct {
+			Text     string `json:"text"`
+			Thinking string `json:"thinking"`
+		} `json:"content"`
+	} `json:"message"`
+}
+
+type citationDelta struct {
+	Message struct {
+		Citations *citation `json:"citations"`
+	} `json:"message"`
+}
+
+func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
+	request, err := c.mapPromptToRequest(prompt, settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/chat", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat message: %w", err)
+	}
+
+	chunks := make(chan params.StreamChunk)
+
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		var responseID string
+		var content strings.Builder
+		// Citations refer to the whole reply, so they are resolved once it is complete
+		var citations []citation
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "" || data == "[DONE]" {
+				continue
+			}
+
+			var event streamEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Errorf("failed to decode stream event: %w", err),
+				}
+				return
+			}
+
+			switch event.Type {
+			case "message-start":
+				responseID = event.ID
+			case "citation-start":
+				var delta citationDelta
+				if err := json.Unmarshal(event.Delta, &delta); err != nil {
+					chunks <- params.StreamChunk{
+						Content: "",
+						Done:    true,
+						Error:   fmt.Errorf("failed to decode citation: %w", err),
+					}
+					return
+				}
+				if delta.Message.Citations != nil {
+					citations = append(citations, *delta.Message.Citations)
+				}
+			case "content-delta":
+				var contentEvent contentDelta
+				if err := json.Unmarshal(event.Delta, &contentEvent); err != nil {
+					chunks <- params.StreamChunk{
+						Content: "",
+						Done:    true,
+						Error:   fmt.Errorf("failed to decode content delta: %w", err),
+					}
+					return
+				}
+				delta := contentEvent.Message.Content
+				if settings.IncludeReasoning && delta.Thinking != "" {
+					chunks <- params.StreamChunk{
+						Kind:    params.StreamChunkKindReasoning,
+						Content: delta.Thinking,
+						Done:    false,
+						Error:   nil,
+					}
+				}
+				if delta.Text != "" {
+					content.WriteString(delta.Text)
+					chunks <- params.StreamChunk{
+						Content: delta.Text,
+						Done:    false,
+						Error:   nil,
+					}
+				}
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			chunks <- params.StreamChunk{
+				Content: "",
+				Done:    true,
+				Error:   fmt.Errorf("streaming error: %w", err),
+			}
+			return
+		}
+
+		// Send final chunk to indicate completion
+		chunks <- params.StreamChunk{
+			Content:    "",
+			Done:       true,
+			Error:      nil,
+			Grounding:  mapCitations(citations, content.String()),
+			ResponseID: responseID,
+		}
+	}()
+
+	return chunks, nil
+}
+
+// post sends body as JSON and returns the response if it succeeded. The caller closes the body.
+func (c *Client) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, providerError(resp)
+	}
+	return resp, nil
+}
+
+// statusInvalidToken is Cohere's status for an invalid API key
+const statusInvalidToken = 498
+
+// providerError normalizes an error reply, which carries a message
+func providerError(resp *http.Response) *params.ProviderError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	providerErr := &params.ProviderError{
+		Provider:   providerName,
+		Kind:       params.ErrorKindFromStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+	if resp.StatusCode == statusInvalidToken {
+		providerErr.Kind = params.ErrorKindAuthentication
+	}
+
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+		providerErr.Message = apiErr.Message
+	}
+	return providerErr
+}
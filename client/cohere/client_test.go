@@ -0,0 +1,228 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// newTestClient returns a client for a local server that answers every request with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewCohereClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// serveRecording answers with a recorded reply from testdata, after checking the request
+func serveRecording(t *testing.T, name string, request *chatRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(name, ".json") {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.Write(data)
+	}
+}
+
+// openingHoursPrompt asks a question about a document, which the recorded replies cite
+func openingHoursPrompt() params.Prompt {
+	prompt := params.NewSimplePrompt("", "Quand ouvre le café de Zürich ?")
+	prompt.Documents = []params.Document{{
+		ID:    "doc-0",
+		Title: "Horaires",
+		URL:   "https://example.com/horaires",
+		Text:  "Le café de Zürich est ouvert de 8h à 18h.",
+	}}
+	return prompt
+}
+
+// checkGrounding checks the citations of the recorded replies, whose character offsets
+// must be converted to byte offsets because of the accented characters before them
+func checkGrounding(t *testing.T, content string, grounding *params.Grounding) {
+	t.Helper()
+	if grounding == nil {
+		t.Fatal("expected grounding")
+	}
+	if len(grounding.Sources) != 1 || grounding.Sources[0].DocumentID != "doc-0" || grounding.Sources[0].Title != "Horaires" {
+		t.Errorf("expected the document as the only source, got %+v", grounding.Sources)
+	}
+
+	expected := []params.Citation{
+		{URL: "https://example.com/horaires", Title: "Horaires", DocumentID: "doc-0", StartIndex: 12, EndIndex: 19, Text: "Zürich"},
+		{URL: "https://example.com/horaires", Title: "Horaires", DocumentID: "doc-0", StartIndex: 29, EndIndex: 31, Text: "8h"},
+	}
+	if len(grounding.Citations) != len(expected) {
+		t.Fatalf("expected %d citations, got %+v", len(expected), grounding.Citations)
+	}
+	for i, citation := range grounding.Citations {
+		if citation != expected[i] {
+			t.Errorf("expected citation %+v, got %+v", expected[i], citation)
+		}
+		if content[citation.StartIndex:citation.EndIndex] != citation.Text {
+			t.Errorf("expected the citation offsets to select %q, got %q", citation.Text, content[citation.StartIndex:citation.EndIndex])
+		}
+	}
+}
+
+func TestSendCompletionMessage(t *testing.T) {
+	var request chatRequest
+	c := newTestClient(t, serveRecording(t, "chat.json", &request))
+
+	thinkingTokens := 1024
+	response, err := c.SendCompletionMessage(context.Background(), openingHoursPrompt(),
+		params.Settings{ModelName: "command-a-reasoning-08-2025", IncludeReasoning: true, ThinkingTokens: &thinkingTokens})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Content != "Le café de Zürich ouvre à 8h." {
+		t.Errorf("unexpected content %q", response.Content)
+	}
+	if response.Reasoning != "The opening hours document says the café opens at 8h." {
+		t.Errorf("unexpected reasoning %q", response.Reasoning)
+	}
+	if response.ResponseID != "5b3c1f0e-8d2a-4c6b-9e7f-1a2b3c4d5e6f" {
+		t.Errorf("unexpected response ID %q", response.ResponseID)
+	}
+	checkGrounding(t, response.Content, response.Grounding)
+
+	if len(request.Documents) != 1 || request.Documents[0].ID != "doc-0" || request.Documents[0].Data["title"] != "Horaires" {
+		t.Errorf("expected the document in the request, got %+v", request.Documents)
+	}
+	if request.Thinking == nil || request.Thinking.Type != "enabled" || *request.Thinking.TokenBudget != 1024 {
+		t.Errorf("expected thinking with a 1024 token budget, got %+v", request.Thinking)
+	}
+}
+
+func TestStreamCompletionMessage(t *testing.T) {
+	var request chatRequest
+	c := newTestClient(t, serveRecording(t, "chat_stream.txt", &request))
+
+	chunks, err := c.StreamCompletionMessage(context.Background(), openingHoursPrompt(),
+		params.Settings{ModelName: "command-a-reasoning-08-2025", IncludeReasoning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content strings.Builder
+	var reasoning strings.Builder
+	var final params.StreamChunk
+	for chunk := range chunks {
+		switch {
+		case chunk.Done:
+			final = chunk
+		case chunk.Kind == params.StreamChunkKindReasoning:
+			reasoning.WriteString(chunk.Content)
+		default:
+			content.WriteString(chunk.Content)
+		}
+	}
+
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content.String() != "Le café de Zürich ouvre à 8h." {
+		t.Errorf("unexpected content %q", content.String())
+	}
+	if reasoning.String() != "The opening hours document says the café opens at 8h." {
+		t.Errorf("unexpected reasoning %q", reasoning.String())
+	}
+	if final.ResponseID != "9c8b7a6d-5e4f-4a3b-2c1d-0e9f8a7b6c5d" {
+		t.Errorf("unexpected response ID %q", final.ResponseID)
+	}
+	checkGrounding(t, content.String(), final.Grounding)
+	if !request.Stream {
+		t.Error("expected a streaming request")
+	}
+}
+
+func TestByteOffsetClampsToContent(t *testing.T) {
+	if got := byteOffset("café", 10); got != len("café") {
+		t.Errorf("expected the offset to be clamped to %d, got %d", len("café"), got)
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	tests := []struct {
+		status  int
+		body    string
+		kind    params.ErrorKind
+		message string
+	}{
+		{400, `{"id":"1f2e3d4c","message":"invalid request: message must be at least 1 token long or tool results must be specified."}`,
+			params.ErrorKindInvalidRequest, "invalid request: message must be at least 1 token long or tool results must be specified."},
+		{401, `{"id":"2e3d4c5b","message":"no api key supplied"}`,
+			params.ErrorKindAuthentication, "no api key supplied"},
+		{402, `{"id":"3d4c5b6a","message":"Please add or update your payment method at https://dashboard.cohere.com/billing"}`,
+			params.ErrorKindInvalidRequest, "Please add or update your payment method at https://dashboard.cohere.com/billing"},
+		{404, `{"id":"4c5b6a79","message":"model 'command-z' not found, make sure the correct model ID was used and that you have access to the model."}`,
+			params.ErrorKindNotFound, "model 'command-z' not found, make sure the correct model ID was used and that you have access to the model."},
+		{422, `{"id":"5b6a7988","message":"invalid request: too many tokens: total number of tokens in the prompt cannot exceed 256000"}`,
+			params.ErrorKindInvalidRequest, "invalid request: too many tokens: total number of tokens in the prompt cannot exceed 256000"},
+		{429, `{"id":"6a798897","message":"You are using a Trial key, which is limited to 10 API calls / minute."}`,
+			params.ErrorKindRateLimit, "You are using a Trial key, which is limited to 10 API calls / minute."},
+		{498, `{"id":"798897a6","message":"invalid api token"}`,
+			params.ErrorKindAuthentication, "invalid api token"},
+		{500, `{"id":"8897a6b5","message":"internal server error, this has been reported to our developers."}`,
+			params.ErrorKindServer, "internal server error, this has been reported to our developers."},
+		{503, `upstream connect error`,
+			params.ErrorKindServer, "upstream connect error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := c.SendCompletionMessage(context.Background(),
+				params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "command-a-03-2025"})
+			var providerErr *params.ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("expected a provider error, got %v", err)
+			}
+			if providerErr.Provider != providerName || providerErr.StatusCode != tt.status || providerErr.Kind != tt.kind {
+				t.Errorf("expected %s status %d, got %+v", tt.kind, tt.status, providerErr)
+			}
+			if providerErr.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, providerErr.Message)
+			}
+
+			// Streams fail before the first chunk
+			if _, err := c.StreamCompletionMessage(context.Background(),
+				params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "command-a-03-2025"}); !errors.As(err, &providerErr) {
+				t.Errorf("expected a provider error from the stream, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package cohere
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+type chatRequest struct {
+	Model            string           `json:"model"`
+	Messages         []chatMessage    `json:"messages"`
+	Documents        []document       `json:"documents,omitempty"`
+	Stream           bool             `json:"stream,omitempty"`
+	Temperature      *float64         `json:"temperature,omitempty"`
+	P                *float64         `json:"p,omitempty"`
+	K                *int             `json:"k,omitempty"`
+	Seed             *int             `json:"seed,omitempty"`
+	StopSequences    []string         `json:"stop_sequences,omitempty"`
+	PresencePenalty  *float64         `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64         `json:"frequency_penalty,omitempty"`
+	MaxTokens        *int             `json:"max_tokens,omitempty"`
+	ResponseFormat   *responseFormat  `json:"response_format,omitempty"`
+	SafetyMode       string           `json:"safety_mode,omitempty"`
+	CitationOptions  *citationOptions `json:"citation_options,omitempty"`
+	Thinking         *thinking        `json:"thinking,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type document struct {
+	ID   string            `json:"id,omitempty"`
+	Data map[string]string `json:"data"`
+}
+
+type responseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema any    `json:"json_schema,omitempty"`
+}
+
+type citationOptions struct {
+	Mode string `json:"mode"`
+}
+
+type thinking struct {
+	Type        string `json:"type"`
+	TokenBudget *int   `json:"token_budget,omitempty"`
+}
+
+type chatResponse struct {
+	ID      string `json:"id"`
+	Message struct {
+		Content []struct {
+			Type     string `json:"type"`
+			Text     string `json:"text"`
+			Thinking string `json:"thinking"`
+		} `json:"content"`
+		Citations []citation `json:"citations"`
+	} `json:"message"`
+}
+
+// citation offsets are character offsets into the reply
+type citation struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Text    string `json:"text"`
+	Sources []struct {
+		Type     string         `json:"type"`
+		ID       string         `json:"id"`
+		Document map[string]any `json:"document"`
+	} `json:"sources"`
+}
+
+// validateSettings rejects settings that this client has no equivalent for
+func validateSettings(settings params.Settings) error {
+	switch {
+	case len(settings.LogitBias) > 0:
+		return errors.New("logit bias is not supported by Cohere")
+	case settings.CandidateCount != nil && *settings.CandidateCount > 1:
+		return errors.New("multiple candidates are not supported by Cohere")
+	case settings.Logprobs, settings.TopLogprobs != nil:
+		return errors.New("logprobs are not supported by the Cohere client")
+	case settings.IsSearchEnabled:
+		return errors.New("search is not supported by Cohere, pass documents in the prompt instead")
+	case settings.Safety != nil:
+		return errors.New("safety settings are not supported by Cohere, use SafetyMode in cohere.Options instead")
+	}
+	return nil
+}
+
+func (c *Client) mapPromptToRequest(prompt params.Prompt, settings params.Settings, stream bool) (*chatRequest, error) {
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+	if prompt.PreviousResponseID != "" {
+		return nil, errors.New("previous response IDs are not supported by Cohere")
+	}
+
+	opts := optionsFromSettings(settings)
+	request := &chatRequest{
+		Model:            settings.ModelName,
+		Documents:        mapDocuments(prompt.Documents),
+		Stream:           stream,
+		Temperature:      settings.Temperature,
+		P:                settings.TopP,
+		K:                settings.TopK,
+		Seed:             settings.Seed,
+		StopSequences:    settings.StopSequences,
+		PresencePenalty:  settings.PresencePenalty,
+		FrequencyPenalty: settings.FrequencyPenalty,
+		MaxTokens:        settings.MaxOutputTokens,
+		SafetyMode:       opts.SafetyMode,
+		Thinking:         mapThinking(settings),
+	}
+	if opts.CitationMode != "" {
+		request.CitationOptions = &citationOptions{Mode: opts.CitationMode}
+	}
+
+	switch c.structuredOutputMode {
+	case params.StructuredOutputJSONObject, params.StructuredOutputNone:
+		// Describe the schema in the system message instead, the reply is still validated against it
+		if prompt.ResponseFormat != nil {
+			var err error
+			if prompt, err = structured.WithSchemaInstruction(prompt); err != nil {
+				return nil, err
+			}
+			if c.structuredOutputMode == params.StructuredOutputJSONObject {
+				request.ResponseFormat = &responseFormat{Type: "json_object"}
+			}
+		}
+	default:
+		if prompt.ResponseFormat != nil {
+			request.ResponseFormat = &responseFormat{
+				Type:       "json_object",
+				JSONSchema: schema.Generate(reflect.TypeOf(prompt.ResponseFormat)),
+			}
+		}
+	}
+
+	request.Messages = mapPromptToMessages(prompt)
+	return request, nil
+}
+
+func mapPromptToMessages(prompt params.Prompt) []chatMessage {
+	messages := []chatMessage{}
+	if prompt.SystemMessage != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: prompt.SystemMessage})
+	}
+
+	for _, message := range prompt.Messages {
+		role := "user"
+		if message.Role == params.MessageRoleAssistant {
+			role = "assistant"
+		}
+		messages = append(messages, chatMessage{Role: role, Content: message.Content})
+	}
+	return messages
+}
+
+func mapDocuments(documents []params.Document) []document {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	result := make([]document, 0, len(documents))
+	for _, doc := range documents {
+		data := make(map[string]string, len(doc.Metadata)+3)
+		for key, value := range doc.Metadata {
+			data[key] = value
+		}
+		if doc.Title != "" {
+			data["title"] = doc.Title
+		}
+		if doc.URL != "" {
+			data["url"] = doc.URL
+		}
+		data["text"] = doc.Text
+		result = append(result, document{ID: doc.ID, Data: data})
+	}
+	return result
+}
+
+// mapThinking enables or disables thinking for reasoning models. If nothing is set, the model's default is used.
+func mapThinking(settings params.Settings) *thinking {
+	var budget int
+	switch {
+	case settings.ThinkingTokens != nil:
+		budget = *settings.ThinkingTokens
+	case settings.ThinkingBudget != "":
+		budget = getThinkingBudget(settings.ThinkingBudget)
+	default:
+		return nil
+	}
+
+	switch {
+	case budget == 0:
+		return &thinking{Type: "disabled"}
+	case budget < 0:
+		// Without a budget, the model decides how long to think
+		return &thinking{Type: "enabled"}
+	default:
+		return &thinking{Type: "enabled", TokenBudget: &budget}
+	}
+}
+
+func getThinkingBudget(thinkingBudget params.ThinkingBudget) int {
+	switch thinkingBudget {
+	case params.MinimalThinkingBudget:
+		return 512
+	case params.SmallThinkingBudget:
+		return 1024
+	case params.MediumThinkingBudget:
+		return 2048
+	case params.LargeThinkingBudget:
+		return 4096
+	default:
+		return 0
+	}
+}
+
+// groundingBuilder collects document citations, converting their character offsets to byte offsets
+type groundingBuilder struct {
+	grounding *params.Grounding
+	seen      map[string]bool
+}
+
+func (b *groundingBuilder) add(c citation, content string) {
+	if b.grounding == nil {
+		b.grounding = &params.Grounding{}
+		b.seen = make(map[string]bool)
+	}
+
+	start := byteOffset(content, c.Start)
+	end := byteOffset(content, c.End)
+	if end < start {
+		end = start
+	}
+
+	for _, source := range c.Sources {
+		if source.Type != "document" {
+			continue
+		}
+
+		title, _ := source.Document["title"].(string)
+		url, _ := source.Document["url"].(string)
+		if !b.seen[source.ID] {
+			b.seen[source.ID] = true
+			b.grounding.Sources = append(b.grounding.Sources, params.Source{
+				URL:        url,
+				Title:      title,
+				DocumentID: source.ID,
+			})
+		}
+		b.grounding.Citations = append(b.grounding.Citations, params.Citation{
+			URL:        url,
+			Title:      title,
+			DocumentID: source.ID,
+			StartIndex: start,
+			EndIndex:   end,
+			Text:       content[start:end],
+		})
+	}
+}
+
+func mapCitations(citations []citation, content string) *params.Grounding {
+	var builder groundingBuilder
+	for _, c := range citations {
+		builder.add(c, content)
+	}
+	return builder.grounding
+}
+
+// byteOffset converts a character offset into s to a byte offset, clamped to the length of s
+func byteOffset(s string, chars int) int {
+	offset := 0
+	for i := 0; i < chars && offset < len(s); i++ {
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// mapContent returns the answer and the thinking of a reply
+func mapContent(resp *chatResponse) (content string, reasoning string) {
+	var text, thoughts strings.Builder
+	for _, item := range resp.Message.Content {
+		switch item.Type {
+		case "text":
+			text.WriteString(item.Text)
+		case "thinking":
+			thoughts.WriteString(item.Thinking)
+		}
+	}
+	return text.String(), thoughts.String()
+}
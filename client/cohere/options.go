@@ -0,0 +1,45 @@
+package cohere
+
+import "github.com/jamesleeht/llm-gopher/params"
+
+const providerName = "cohere"
+
+// Options are Cohere specific settings. Add them to params.Settings.ProviderOptions.
+type Options struct {
+	// SafetyMode is "CONTEXTUAL", "STRICT" or "OFF". Cohere defaults to CONTEXTUAL.
+	SafetyMode string
+	// CitationMode is "FAST", "ACCURATE" or "OFF", and controls how document citations are generated
+	CitationMode string
+}
+
+func (o Options) ProviderName() string {
+	return providerName
+}
+
+// optionsFromSettings returns the Cohere options of the settings, ignoring other providers' options.
+// If several are given, later options override earlier ones field by field.
+func optionsFromSettings(settings params.Settings) Options {
+	var result Options
+	for _, providerOptions := range settings.ProviderOptions {
+		var opts Options
+		switch o := providerOptions.(type) {
+		case Options:
+			opts = o
+		case *Options:
+			if o == nil {
+				continue
+			}
+			opts = *o
+		default:
+			continue
+		}
+
+		if opts.SafetyMode != "" {
+			result.SafetyMode = opts.SafetyMode
+		}
+		if opts.CitationMode != "" {
+			result.CitationMode = opts.CitationMode
+		}
+	}
+	return result
+}
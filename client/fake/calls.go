@@ -0,0 +1,83 @@
+package fake
+
+import (
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// Call is a request the provider received
+type Call struct {
+	Prompt   params.Prompt
+	Settings params.Settings
+	Stream   bool
+}
+
+// T is the subset of testing.TB that assertions use
+type T interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// Calls returns every request received so far, in order
+func (p *Provider) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Call(nil), p.calls...)
+}
+
+// LastCall returns the latest request, or false if there was none
+func (p *Provider) LastCall() (Call, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.calls) == 0 {
+		return Call{}, false
+	}
+	return p.calls[len(p.calls)-1], true
+}
+
+// AssertCallCount fails the test unless exactly n requests were received
+func (p *Provider) AssertCallCount(t T, n int) {
+	t.Helper()
+	if calls := p.Calls(); len(calls) != n {
+		t.Errorf("fake: expected %d calls, got %d", n, len(calls))
+	}
+}
+
+// AssertCalled fails the test unless some request matches
+func (p *Provider) AssertCalled(t T, match func(call Call) bool) {
+	t.Helper()
+	if !p.called(match) {
+		t.Errorf("fake: no call matched")
+	}
+}
+
+// AssertPromptContains fails the test unless some request has substr in its system message or a message
+func (p *Provider) AssertPromptContains(t T, substr string) {
+	t.Helper()
+	contains := func(call Call) bool {
+		if strings.Contains(call.Prompt.SystemMessage, substr) {
+			return true
+		}
+		for _, message := range call.Prompt.Messages {
+			if strings.Contains(message.Content, substr) {
+				return true
+			}
+		}
+		return false
+	}
+	if !p.called(contains) {
+		t.Errorf("fake: no prompt contained %q", substr)
+	}
+}
+
+func (p *Provider) called(match func(call Call) bool) bool {
+	for _, call := range p.Calls() {
+		if match(call) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,319 @@
+// Package fake provides a scriptable provider for testing code that uses clients or the router
+// without calling a real provider.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+const providerName = "fake"
+
+// ClientType is the client type of clients returned by Provider.Client
+const ClientType client.ClientType = "fake"
+
+// ErrNoReply is returned when no rule matches a request
+var ErrNoReply = errors.New("fake: no reply scripted for the request")
+
+type Provider struct {
+	mu         sync.Mutex
+	rules      []*Rule
+	fixtures   map[reflect.Type]any
+	calls      []Call
+	latency    time.Duration
+	chunkSize  int
+	chunkDelay time.Duration
+}
+
+// Option configures the provider
+type Option func(*Provider)
+
+// WithLatency delays every reply, which is useful to test timeouts and cancellation
+func WithLatency(latency time.Duration) Option {
+	return func(p *Provider) {
+		p.latency = latency
+	}
+}
+
+// WithChunkSize sets how many characters each streamed chunk has. It defaults to 8.
+func WithChunkSize(size int) Option {
+	return func(p *Provider) {
+		p.chunkSize = size
+	}
+}
+
+// WithChunkDelay delays every streamed chunk
+func WithChunkDelay(delay time.Duration) Option {
+	return func(p *Provider) {
+		p.chunkDelay = delay
+	}
+}
+
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		fixtures:  make(map[reflect.Type]any),
+		chunkSize: 8,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Client wraps the provider in a client, which can be added to a router.ClientMap
+func (p *Provider) Client() *client.Client {
+	return &client.Client{
+		Provider:   p,
+		ClientType: ClientType,
+	}
+}
+
+// OnAny answers every request that earlier rules don't match
+func (p *Provider) OnAny() *Rule {
+	return p.addRule(matchAny)
+}
+
+// OnModel answers requests for a model name
+func (p *Provider) OnModel(modelName string) *Rule {
+	return p.addRule(matchModel(modelName))
+}
+
+// OnPreset answers requests whose settings equal the preset's, as given in the router.PresetMap
+func (p *Provider) OnPreset(preset params.Settings) *Rule {
+	return p.addRule(matchSettings(preset))
+}
+
+// OnMessageContaining answers requests whose last message contains substr
+func (p *Provider) OnMessageContaining(substr string) *Rule {
+	return p.addRule(matchContaining(substr))
+}
+
+// OnPrompt answers requests that match returns true for
+func (p *Provider) OnPrompt(match func(prompt params.Prompt, settings params.Settings) bool) *Rule {
+	return p.addRule(match)
+}
+
+func (p *Provider) addRule(match func(params.Prompt, params.Settings) bool) *Rule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rule := &Rule{provider: p, match: match}
+	p.rules = append(p.rules, rule)
+	return rule
+}
+
+// AddFixture fills in every response format of the fixture's type that no rule gives content for.
+// Pass a value or a pointer of the response format type.
+func (p *Provider) AddFixture(fixture any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fixtures[derefType(reflect.TypeOf(fixture))] = fixture
+}
+
+// Reset removes every rule, fixture and recorded call
+func (p *Provider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rules = nil
+	p.fixtures = make(map[reflect.Type]any)
+	p.calls = nil
+}
+
+func (p *Provider) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	reply, responseID, err := p.handle(ctx, prompt, settings, false)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Err != nil {
+		return nil, reply.Err
+	}
+
+	response := &params.Response{
+		ResponseID:    responseID,
+		Content:       reply.Content,
+		Parsed:        nil,
+		Grounding:     reply.Grounding,
+		SafetyRatings: reply.SafetyRatings,
+	}
+	if settings.IncludeReasoning {
+		response.Reasoning = reply.Reasoning
+	}
+
+	// If response format is specified, validate the reply and unmarshal into that type
+	if prompt.ResponseFormat != nil {
+		if err := structured.Parse(response.Content, prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+
+	return response, nil
+}
+
+func (p *Provider) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
+	reply, responseID, err := p.handle(ctx, prompt, settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	chunkSize, chunkDelay := max(p.chunkSize, 1), p.chunkDelay
+	p.mu.Unlock()
+
+	chunks := make(chan params.StreamChunk)
+
+	go func() {
+		defer close(chunks)
+
+		send := func(chunk params.StreamChunk) bool {
+			if chunkDelay > 0 {
+				if err := sleep(ctx, chunkDelay); err != nil {
+					chunks <- params.StreamChunk{Done: true, Error: err}
+					return false
+				}
+			}
+			chunks <- chunk
+			return true
+		}
+
+		if settings.IncludeReasoning {
+			for _, text := range split(reply.Reasoning, chunkSize) {
+				if !send(params.StreamChunk{Kind: params.StreamChunkKindReasoning, Content: text}) {
+					return
+				}
+			}
+		}
+		for _, text := range split(reply.Content, chunkSize) {
+			if !send(params.StreamChunk{Content: text}) {
+				return
+			}
+		}
+
+		// Send final chunk to indicate completion
+		chunks <- params.StreamChunk{
+			Content:    "",
+			Done:       true,
+			Error:      reply.Err,
+			Grounding:  reply.Grounding,
+			ResponseID: responseID,
+		}
+	}()
+
+	return chunks, nil
+}
+
+// handle records the call, finds the reply and waits for the latency
+func (p *Provider) handle(ctx context.Context, prompt params.Prompt, settings params.Settings, stream bool) (Reply, string, error) {
+	p.mu.Lock()
+	p.calls = append(p.calls, Call{Prompt: prompt, Settings: settings, Stream: stream})
+	responseID := fmt.Sprintf("fake-%d", len(p.calls))
+
+	reply, found := p.findReply(prompt, settings)
+	latency := p.latency
+	p.mu.Unlock()
+
+	if !found {
+		return Reply{}, "", ErrNoReply
+	}
+	if err := sleep(ctx, latency+reply.Latency); err != nil {
+		return Reply{}, "", err
+	}
+
+	if prompt.ResponseFormat != nil && reply.Content == "" && reply.Err == nil {
+		content, err := p.fixtureContent(reply.Fixture, prompt.ResponseFormat)
+		if err != nil {
+			return Reply{}, "", err
+		}
+		reply.Content = content
+	}
+	return reply, responseID, nil
+}
+
+// findReply returns the reply of the first matching rule. The caller holds the lock.
+func (p *Provider) findReply(prompt params.Prompt, settings params.Settings) (Reply, bool) {
+	for _, rule := range p.rules {
+		if rule.remaining < 0 || !rule.match(prompt, settings) {
+			continue
+		}
+		if rule.remaining > 0 {
+			rule.remaining--
+			if rule.remaining == 0 {
+				// Exhausted rules are skipped from now on
+				rule.remaining = -1
+			}
+		}
+		return rule.reply, true
+	}
+	return Reply{}, false
+}
+
+// fixtureContent returns the JSON of the rule's fixture, or of the fixture added for the response format's type
+func (p *Provider) fixtureContent(fixture any, responseFormat any) (string, error) {
+	if fixture == nil {
+		p.mu.Lock()
+		fixture = p.fixtures[derefType(reflect.TypeOf(responseFormat))]
+		p.mu.Unlock()
+	}
+	if fixture == nil {
+		return "", fmt.Errorf("fake: no fixture for response format %T", responseFormat)
+	}
+
+	if s, ok := fixture.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(fixture)
+	if err != nil {
+		return "", fmt.Errorf("fake: failed to marshal fixture: %w", err)
+	}
+	return string(data), nil
+}
+
+// split cuts text into chunks of size characters
+func split(text string, size int) []string {
+	var parts []string
+	for len(text) > 0 {
+		end, count := 0, 0
+		for end < len(text) && count < size {
+			_, width := utf8.DecodeRuneInString(text[end:])
+			end += width
+			count++
+		}
+		parts = append(parts, text[:end])
+		text = text[end:]
+	}
+	return parts
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
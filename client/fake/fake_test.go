@@ -0,0 +1,297 @@
+package fake_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jamesleeht/llm-gopher/client/fake"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+func send(t *testing.T, p *fake.Provider, content string) (*params.Response, error) {
+	t.Helper()
+	return p.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", content), params.Settings{ModelName: "model"})
+}
+
+func TestFirstMatchingRuleAnswers(t *testing.T) {
+	p := fake.New()
+	p.OnMessageContaining("refund").Reply("refund policy")
+	p.OnModel("model").Reply("model reply")
+	p.OnAny().Reply("anything")
+
+	tests := map[string]string{
+		"I want a refund": "refund policy",
+		"Hello":           "model reply",
+	}
+	for content, expected := range tests {
+		response, err := send(t, p, content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Content != expected {
+			t.Errorf("%s: expected %q, got %q", content, expected, response.Content)
+		}
+	}
+
+	response, err := p.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hello"), params.Settings{ModelName: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "anything" {
+		t.Errorf("expected the catch-all rule, got %q", response.Content)
+	}
+}
+
+func TestNoMatchingRule(t *testing.T) {
+	p := fake.New()
+	p.OnModel("other").Reply("unused")
+
+	if _, err := send(t, p, "Hello"); !errors.Is(err, fake.ErrNoReply) {
+		t.Errorf("expected ErrNoReply, got %v", err)
+	}
+	// Unanswered requests are still recorded
+	p.AssertCallCount(t, 1)
+}
+
+func TestTimesFallsThroughWhenExhausted(t *testing.T) {
+	p := fake.New()
+	p.OnAny().FailWith(params.ErrorKindRateLimit).Times(2)
+	p.OnAny().Reply("recovered").Times(1)
+
+	for range 2 {
+		_, err := send(t, p, "Hello")
+		var providerErr *params.ProviderError
+		if !errors.As(err, &providerErr) || providerErr.Kind != params.ErrorKindRateLimit || !providerErr.Retryable() {
+			t.Fatalf("expected a retryable rate limit error, got %v", err)
+		}
+	}
+
+	response, err := send(t, p, "Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "recovered" {
+		t.Errorf("expected recovered, got %q", response.Content)
+	}
+
+	if _, err := send(t, p, "Hello"); !errors.Is(err, fake.ErrNoReply) {
+		t.Errorf("expected ErrNoReply once every rule is exhausted, got %v", err)
+	}
+	p.AssertCallCount(t, 4)
+}
+
+type movieReview struct {
+	Title  string `json:"title"`
+	Rating int    `json:"rating"`
+}
+
+type weather struct {
+	City string `json:"city"`
+}
+
+func withResponseFormat(content string, responseFormat any) params.Prompt {
+	prompt := params.NewSimplePrompt("", content)
+	prompt.ResponseFormat = responseFormat
+	return prompt
+}
+
+func TestFixturesFillResponseFormat(t *testing.T) {
+	p := fake.New()
+	p.OnMessageContaining("Heat").ReplyFixture(movieReview{Title: "Heat", Rating: 9})
+	p.OnMessageContaining("raw").ReplyFixture(`{"title":"Ronin","rating":8}`)
+	p.OnAny()
+	p.AddFixture(&movieReview{Title: "Alien", Rating: 10})
+
+	tests := map[string]movieReview{
+		"Review Heat":      {Title: "Heat", Rating: 9},
+		"Review raw JSON":  {Title: "Ronin", Rating: 8},
+		"Review any movie": {Title: "Alien", Rating: 10},
+	}
+	for content, expected := range tests {
+		var review movieReview
+		response, err := p.SendCompletionMessage(context.Background(),
+			withResponseFormat(content, &review), params.Settings{ModelName: "model"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if review != expected {
+			t.Errorf("%s: expected %+v, got %+v", content, expected, review)
+		}
+		if response.Parsed != &review {
+			t.Errorf("%s: expected Parsed to be the response format", content)
+		}
+	}
+
+	// Response formats without a fixture fail like a reply that doesn't parse would
+	_, err := p.SendCompletionMessage(context.Background(),
+		withResponseFormat("Weather?", &weather{}), params.Settings{ModelName: "model"})
+	if err == nil {
+		t.Error("expected an error for a response format without a fixture")
+	}
+}
+
+func TestStreamSplitsReplyIntoChunks(t *testing.T) {
+	p := fake.New(fake.WithChunkSize(4))
+	p.OnAny().ReplyWith(fake.Reply{Content: "Héllo, 世界!", Reasoning: "Greeting"})
+
+	chunks, err := p.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "model", IncludeReasoning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	var final params.StreamChunk
+	for chunk := range chunks {
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		got = append(got, fmt.Sprintf("%s:%s", chunk.Kind, chunk.Content))
+	}
+
+	// Chunks are counted in characters, not bytes, and reasoning comes first
+	expected := []string{
+		fmt.Sprintf("%s:Gree", params.StreamChunkKindReasoning),
+		fmt.Sprintf("%s:ting", params.StreamChunkKindReasoning),
+		fmt.Sprintf("%s:Héll", params.StreamChunkKindText),
+		fmt.Sprintf("%s:o, 世", params.StreamChunkKindText),
+		fmt.Sprintf("%s:界!", params.StreamChunkKindText),
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("expected chunks %q, got %q", expected, got)
+	}
+	if final.Error != nil || final.ResponseID != "fake-1" {
+		t.Errorf("unexpected final chunk %+v", final)
+	}
+	p.AssertCalled(t, func(call fake.Call) bool { return call.Stream })
+}
+
+func TestLatencyIsCancelledWithContext(t *testing.T) {
+	p := fake.New(fake.WithLatency(time.Hour))
+	p.OnAny().Reply("too late")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p.SendCompletionMessage(ctx, params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "model"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the latency to be cut short, took %s", elapsed)
+	}
+}
+
+func TestChunkDelayIsCancelledWithContext(t *testing.T) {
+	p := fake.New(fake.WithChunkDelay(time.Hour))
+	p.OnAny().Reply("never streamed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := p.StreamCompletionMessage(ctx, params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "model"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	var final params.StreamChunk
+	for chunk := range chunks {
+		final = chunk
+	}
+	if !final.Done || !errors.Is(final.Error, context.Canceled) {
+		t.Errorf("expected a final chunk with context.Canceled, got %+v", final)
+	}
+}
+
+func TestClientWorksWithRouter(t *testing.T) {
+	presetMap := router.PresetMap{
+		"fast":  {ModelName: "gpt-4o-mini"},
+		"smart": {ModelName: "gpt-4o", IncludeReasoning: true},
+	}
+	p := fake.New()
+	p.OnPreset(presetMap["fast"]).Reply("fast reply")
+	p.OnModel("gpt-4o").ReplyWith(fake.Reply{Content: "smart reply", Reasoning: "thought"})
+
+	r, err := router.NewRouter(router.ClientMap{
+		"gpt-4o-mini": {p.Client()},
+		"gpt-4o":      {p.Client()},
+	}, presetMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := r.SendPrompt(context.Background(), "fast", params.NewSimplePrompt("", "Hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "fast reply" {
+		t.Errorf("expected fast reply, got %q", response.Content)
+	}
+
+	response, err = r.SendPrompt(context.Background(), "smart", params.NewSimplePrompt("Be smart.", "Hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "smart reply" || response.Reasoning != "thought" {
+		t.Errorf("expected the smart reply with reasoning, got %+v", response)
+	}
+
+	p.AssertCallCount(t, 2)
+	p.AssertPromptContains(t, "Be smart.")
+	call, _ := p.LastCall()
+	if call.Settings.ModelName != "gpt-4o" {
+		t.Errorf("expected the last call to use gpt-4o, got %s", call.Settings.ModelName)
+	}
+}
+
+func TestClientRepairsInvalidReply(t *testing.T) {
+	p := fake.New()
+	p.OnAny().ReplyFixture(`{"title":"Heat","rating":"nine"}`).Times(1)
+	p.OnAny().ReplyFixture(movieReview{Title: "Heat", Rating: 9})
+
+	var review movieReview
+	response, err := p.Client().SendMessage(context.Background(),
+		withResponseFormat("Review Heat", &review), params.Settings{ModelName: "model", RepairAttempts: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Parsed != &review || review.Rating != 9 {
+		t.Errorf("expected the repaired reply to be parsed, got %+v", review)
+	}
+
+	p.AssertCallCount(t, 2)
+	call, _ := p.LastCall()
+	if len(call.Prompt.Messages) != 3 {
+		t.Errorf("expected the invalid reply to be sent back for repair, got %d messages", len(call.Prompt.Messages))
+	}
+}
+
+func TestRulesCanChangeWhileAnswering(t *testing.T) {
+	p := fake.New()
+	rule := p.OnAny().Reply("first")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 100 {
+			rule.Reply(fmt.Sprintf("reply %d", i)).WithLatency(0).Times(0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			if _, err := send(t, p, "Hi"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+}
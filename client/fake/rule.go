@@ -0,0 +1,141 @@
+package fake
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// Reply is what the provider answers a matching request with
+type Reply struct {
+	Content   string
+	Reasoning string
+	// Fixture fills in the prompt's response format. It is marshalled to JSON as the content,
+	// unless it is a string, which is used as the JSON as is.
+	Fixture any
+	// Grounding and SafetyRatings are copied to the response
+	Grounding     *params.Grounding
+	SafetyRatings []params.SafetyRating
+	// Err is returned instead of a response. Streams send it on the final chunk.
+	Err error
+	// Latency is added to the provider's latency before answering
+	Latency time.Duration
+}
+
+// Rule answers the requests that it matches. Rules are created with the provider's On methods
+// and are tried in the order they were added. Rules can be changed while requests are being answered.
+type Rule struct {
+	// provider guards the reply and the remaining count with its lock
+	provider  *Provider
+	match     func(prompt params.Prompt, settings params.Settings) bool
+	reply     Reply
+	remaining int
+}
+
+// Reply answers with content
+func (r *Rule) Reply(content string) *Rule {
+	r.provider.mu.Lock()
+	defer r.provider.mu.Unlock()
+
+	r.reply.Content = content
+	return r
+}
+
+// ReplyFixture answers with a fixture of the response format, see Reply.Fixture
+func (r *Rule) ReplyFixture(fixture any) *Rule {
+	r.provider.mu.Lock()
+	defer r.provider.mu.Unlock()
+
+	r.reply.Fixture = fixture
+	return r
+}
+
+// ReplyWith answers with a full reply
+func (r *Rule) ReplyWith(reply Reply) *Rule {
+	r.provider.mu.Lock()
+	defer r.provider.mu.Unlock()
+
+	r.reply = reply
+	return r
+}
+
+// Fail returns err, e.g. a *params.ContentFilterError or *params.ContextLengthError
+func (r *Rule) Fail(err error) *Rule {
+	r.provider.mu.Lock()
+	defer r.provider.mu.Unlock()
+
+	r.reply.Err = err
+	return r
+}
+
+// FailWith returns a *params.ProviderError of the kind
+func (r *Rule) FailWith(kind params.ErrorKind) *Rule {
+	return r.Fail(Error(kind))
+}
+
+// WithLatency delays the reply, on top of the provider's latency
+func (r *Rule) WithLatency(latency time.Duration) *Rule {
+	r.provider.mu.Lock()
+	defer r.provider.mu.Unlock()
+
+	r.reply.Latency = latency
+	return r
+}
+
+// Times limits how many requests the rule answers, after which later rules are tried.
+// It can script a failure followed by a success.
+func (r *Rule) Times(n int) *Rule {
+	r.provider.mu.Lock()
+	defer r.provider.mu.Unlock()
+
+	r.remaining = n
+	return r
+}
+
+// statusCodes are typical HTTP statuses of each error kind
+var statusCodes = map[params.ErrorKind]int{
+	params.ErrorKindInvalidRequest: 400,
+	params.ErrorKindAuthentication: 401,
+	params.ErrorKindPermission:     403,
+	params.ErrorKindNotFound:       404,
+	params.ErrorKindRateLimit:      429,
+	params.ErrorKindServer:         500,
+}
+
+// Error returns a provider error of the kind, as a real provider would return it
+func Error(kind params.ErrorKind) *params.ProviderError {
+	return &params.ProviderError{
+		Provider:   providerName,
+		Kind:       kind,
+		StatusCode: statusCodes[kind],
+		Message:    "injected " + string(kind) + " error",
+	}
+}
+
+func matchAny(params.Prompt, params.Settings) bool {
+	return true
+}
+
+func matchModel(modelName string) func(params.Prompt, params.Settings) bool {
+	return func(_ params.Prompt, settings params.Settings) bool {
+		return settings.ModelName == modelName
+	}
+}
+
+func matchSettings(preset params.Settings) func(params.Prompt, params.Settings) bool {
+	return func(_ params.Prompt, settings params.Settings) bool {
+		return reflect.DeepEqual(settings, preset)
+	}
+}
+
+// matchContaining matches the last message of the prompt
+func matchContaining(substr string) func(params.Prompt, params.Settings) bool {
+	return func(prompt params.Prompt, _ params.Settings) bool {
+		if len(prompt.Messages) == 0 {
+			return false
+		}
+		return strings.Contains(prompt.Messages[len(prompt.Messages)-1].Content, substr)
+	}
+}
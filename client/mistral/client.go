@@ -0,0 +1,266 @@
+package mistral
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+const defaultBaseURL = "https://api.mistral.ai/v1"
+
+type ClientConfig struct {
+	// APIKey defaults to the MISTRAL_API_KEY environment variable
+	APIKey string `json:"api_key,omitempty"`
+	// BaseURL defaults to https://api.mistral.ai/v1
+	BaseURL string `json:"base_url,omitempty"`
+	// StructuredOutputMode defaults to native JSON schema support
+	StructuredOutputMode params.StructuredOutputMode `json:"structured_output_mode,omitempty"`
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client `json:"-"`
+}
+
+// Client talks to Mistral's native API, which supports safe prompts and returns Magistral's thinking
+type Client struct {
+	apiKey               string
+	baseURL              string
+	httpClient           *http.Client
+	structuredOutputMode params.StructuredOutputMode
+}
+
+func NewMistralClient(config ClientConfig) (*Client, error) {
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("MISTRAL_API_KEY")
+	}
+	if apiKey == "" {
+		return nil, errors.New("mistral api key is required")
+	}
+
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		apiKey:               apiKey,
+		baseURL:              baseURL,
+		httpClient:           httpClient,
+		structuredOutputMode: config.StructuredOutputMode,
+	}, nil
+}
+
+// ValidateSettings reports settings that this client cannot send, so presets can be rejected up front
+func (c *Client) ValidateSettings(settings params.Settings) error {
+	return validateSettings(settings)
+}
+
+func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	request, err := c.mapPromptToRequest(prompt, settings, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/chat/completions", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var chat chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("failed to decode chat response: %w", err)
+	}
+	if len(chat.Choices) == 0 {
+		return nil, errors.New("no choices in response")
+	}
+
+	content, reasoning := mapContent(chat.Choices[0].Message.Content)
+	response := &params.Response{
+		ResponseID: chat.ID,
+		Content:    content,
+		Parsed:     nil,
+	}
+	if settings.IncludeReasoning {
+		response.Reasoning = reasoning
+	}
+	if len(chat.Choices) > 1 {
+		for _, choice := range chat.Choices {
+			candidate, _ := mapContent(choice.Message.Content)
+			response.Candidates = append(response.Candidates, candidate)
+		}
+	}
+
+	// If response format is specified, validate the reply and unmarshal into that type
+	if prompt.ResponseFormat != nil {
+		if err := structured.Parse(response.Content, prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+
+	return response, nil
+}
+
+func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
+	request, err := c.mapPromptToRequest(prompt, settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/chat/completions", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat message: %w", err)
+	}
+
+	chunks := make(chan params.StreamChunk)
+
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		var responseID string
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				break
+			}
+
+			var chunk chatChunk
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Errorf("failed to decode stream event: %w", err),
+				}
+				return
+			}
+			responseID = chunk.ID
+			if len(chunk.Choices) == 0 {
+				continue
+			}
+
+			// Only the first candidate is streamed
+			if chunk.Choices[0].Index != 0 {
+				continue
+			}
+			content, reasoning := mapContent(chunk.Choices[0].Delta.Content)
+			if settings.IncludeReasoning && reasoning != "" {
+				chunks <- params.StreamChunk{
+					Kind:    params.StreamChunkKindReasoning,
+					Content: reasoning,
+					Done:    false,
+					Error:   nil,
+				}
+			}
+			if content != "" {
+				chunks <- params.StreamChunk{
+					Content: content,
+					Done:    false,
+					Error:   nil,
+				}
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			chunks <- params.StreamChunk{
+				Content: "",
+				Done:    true,
+				Error:   fmt.Errorf("streaming error: %w", err),
+			}
+			return
+		}
+
+		// Send final chunk to indicate completion
+		chunks <- params.StreamChunk{
+			Content:    "",
+			Done:       true,
+			Error:      nil,
+			ResponseID: responseID,
+		}
+	}()
+
+	return chunks, nil
+}
+
+// post sends body as JSON and returns the response if it succeeded. The caller closes the body.
+func (c *Client) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, providerError(resp)
+	}
+	return resp, nil
+}
+
+// providerError normalizes an error reply. Mistral returns a message string for most errors,
+// a detail string for unknown routes and a list of validation errors in detail for malformed requests.
+func providerError(resp *http.Response) *params.ProviderError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	providerErr := &params.ProviderError{
+		Provider:   providerName,
+		Kind:       params.ErrorKindFromStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+
+	var apiErr struct {
+		Message json.RawMessage `json:"message"`
+		Type    string          `json:"type"`
+		Detail  json.RawMessage `json:"detail"`
+	}
+	if json.Unmarshal(body, &apiErr) != nil {
+		return providerErr
+	}
+
+	providerErr.Code = apiErr.Type
+	var message string
+	switch {
+	case json.Unmarshal(apiErr.Message, &message) == nil && message != "":
+		providerErr.Message = message
+	case len(apiErr.Message) > 0:
+		providerErr.Message = string(apiErr.Message)
+	case json.Unmarshal(apiErr.Detail, &message) == nil && message != "":
+		providerErr.Message = message
+	case len(apiErr.Detail) > 0:
+		providerErr.Message = string(apiErr.Detail)
+	}
+	return providerErr
+}
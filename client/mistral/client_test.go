@@ -0,0 +1,226 @@
+package mistral
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// newTestClient returns a client for a local server that answers every request with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewMistralClient(ClientConfig{APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// serveRecording answers with a recorded reply from testdata, after checking the request
+func serveRecording(t *testing.T, name string, request *chatRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Error(err)
+		}
+
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(name, ".json") {
+			w.Header().Set("Content-Type", "application/json")
+		} else {
+			w.Header().Set("Content-Type", "text/event-stream")
+		}
+		w.Write(data)
+	}
+}
+
+func collect(t *testing.T, c *Client, settings params.Settings) (content string, reasoning string, final params.StreamChunk) {
+	t.Helper()
+	chunks, err := c.StreamCompletionMessage(context.Background(), params.NewSimplePrompt("", "What is the capital of France?"), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var contentBuilder strings.Builder
+	var reasoningBuilder strings.Builder
+	for chunk := range chunks {
+		switch {
+		case chunk.Done:
+			final = chunk
+		case chunk.Kind == params.StreamChunkKindReasoning:
+			reasoningBuilder.WriteString(chunk.Content)
+		default:
+			contentBuilder.WriteString(chunk.Content)
+		}
+	}
+	return contentBuilder.String(), reasoningBuilder.String(), final
+}
+
+func TestSendCompletionMessage(t *testing.T) {
+	var request chatRequest
+	c := newTestClient(t, serveRecording(t, "chat.json", &request))
+
+	response, err := c.SendCompletionMessage(context.Background(),
+		params.NewSimplePrompt("Be brief.", "What is the capital of France?"),
+		params.Settings{ModelName: "mistral-small-latest", ProviderOptions: []params.ProviderOptions{Options{SafePrompt: true}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Content != "The capital of France is Paris." {
+		t.Errorf("unexpected content %q", response.Content)
+	}
+	if response.ResponseID != "cmpl-3f1c2a7b9d4e4f0a8b6c5d2e1f0a9b8c" {
+		t.Errorf("unexpected response ID %q", response.ResponseID)
+	}
+	if request.Model != "mistral-small-latest" || request.Stream || !request.SafePrompt {
+		t.Errorf("unexpected request %+v", request)
+	}
+	if len(request.Messages) != 2 || request.Messages[0].Role != "system" {
+		t.Errorf("expected a system and a user message, got %+v", request.Messages)
+	}
+}
+
+func TestSendCompletionMessageMagistral(t *testing.T) {
+	var request chatRequest
+	c := newTestClient(t, serveRecording(t, "magistral.json", &request))
+
+	response, err := c.SendCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "What is 2 + 2?"),
+		params.Settings{
+			ModelName:        "magistral-medium-2509",
+			IncludeReasoning: true,
+			ProviderOptions:  []params.ProviderOptions{Options{PromptMode: "reasoning"}},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Content != "2 + 2 = 4" {
+		t.Errorf("unexpected content %q", response.Content)
+	}
+	if response.Reasoning != "The user asks for 2 + 2, which is 4." {
+		t.Errorf("unexpected reasoning %q", response.Reasoning)
+	}
+	if request.PromptMode != "reasoning" {
+		t.Errorf("expected prompt mode reasoning, got %q", request.PromptMode)
+	}
+}
+
+func TestStreamCompletionMessage(t *testing.T) {
+	var request chatRequest
+	c := newTestClient(t, serveRecording(t, "chat_stream.txt", &request))
+
+	content, _, final := collect(t, c, params.Settings{ModelName: "mistral-small-latest"})
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content != "The capital of France is Paris." {
+		t.Errorf("unexpected content %q", content)
+	}
+	if final.ResponseID != "cmpl-5e6f7a8b9c0d4e1f2a3b4c5d6e7f8a9b" {
+		t.Errorf("unexpected response ID %q", final.ResponseID)
+	}
+	if !request.Stream {
+		t.Error("expected a streaming request")
+	}
+}
+
+func TestStreamCompletionMessageMagistral(t *testing.T) {
+	var request chatRequest
+	c := newTestClient(t, serveRecording(t, "magistral_stream.txt", &request))
+
+	content, reasoning, final := collect(t, c, params.Settings{ModelName: "magistral-medium-2509", IncludeReasoning: true})
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content != "2 + 2 = 4" {
+		t.Errorf("unexpected content %q", content)
+	}
+	if reasoning != "The user asks for 2 + 2, which is 4." {
+		t.Errorf("unexpected reasoning %q", reasoning)
+	}
+
+	// Thinking is only streamed when it is asked for
+	c = newTestClient(t, serveRecording(t, "magistral_stream.txt", &request))
+	if _, reasoning, _ := collect(t, c, params.Settings{ModelName: "magistral-medium-2509"}); reasoning != "" {
+		t.Errorf("expected no reasoning, got %q", reasoning)
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	tests := []struct {
+		status  int
+		body    string
+		kind    params.ErrorKind
+		code    string
+		message string
+	}{
+		{400, `{"object":"error","message":"Invalid model: mistral-tiny-2099","type":"invalid_model","param":null,"code":"1500"}`,
+			params.ErrorKindInvalidRequest, "invalid_model", "Invalid model: mistral-tiny-2099"},
+		{401, `{"message":"Unauthorized","request_id":"5f0e1d2c3b4a"}`,
+			params.ErrorKindAuthentication, "", "Unauthorized"},
+		{403, `{"detail":"You do not have access to this model"}`,
+			params.ErrorKindPermission, "", "You do not have access to this model"},
+		{404, `{"detail":"Not Found"}`,
+			params.ErrorKindNotFound, "", "Not Found"},
+		{422, `{"object":"error","message":{"detail":[{"type":"missing","loc":["body","messages"],"msg":"Field required"}]},"type":"invalid_request_message_error","param":null,"code":null}`,
+			params.ErrorKindInvalidRequest, "invalid_request_message_error", `{"detail":[{"type":"missing","loc":["body","messages"],"msg":"Field required"}]}`},
+		{429, `{"message":"Requests rate limit exceeded"}`,
+			params.ErrorKindRateLimit, "", "Requests rate limit exceeded"},
+		{500, `{"object":"error","message":"Internal server error","type":"internal_server_error","param":null,"code":null}`,
+			params.ErrorKindServer, "internal_server_error", "Internal server error"},
+		{503, `upstream connect error`,
+			params.ErrorKindServer, "", "upstream connect error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := c.SendCompletionMessage(context.Background(),
+				params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "mistral-small-latest"})
+			var providerErr *params.ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("expected a provider error, got %v", err)
+			}
+			if providerErr.Provider != providerName || providerErr.StatusCode != tt.status || providerErr.Kind != tt.kind {
+				t.Errorf("expected %s status %d, got %+v", tt.kind, tt.status, providerErr)
+			}
+			if providerErr.Code != tt.code {
+				t.Errorf("expected code %q, got %q", tt.code, providerErr.Code)
+			}
+			if providerErr.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, providerErr.Message)
+			}
+
+			// Streams fail before the first chunk
+			if _, err := c.StreamCompletionMessage(context.Background(),
+				params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "mistral-small-latest"}); !errors.As(err, &providerErr) {
+				t.Errorf("expected a provider error from the stream, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package mistral
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+type chatRequest struct {
+	Model            string          `json:"model"`
+	Messages         []chatMessage   `json:"messages"`
+	Stream           bool            `json:"stream,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	TopP             *float64        `json:"top_p,omitempty"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	Stop             []string        `json:"stop,omitempty"`
+	RandomSeed       *int            `json:"random_seed,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	N                *int            `json:"n,omitempty"`
+	ResponseFormat   *responseFormat `json:"response_format,omitempty"`
+	SafePrompt       bool            `json:"safe_prompt,omitempty"`
+	PromptMode       string          `json:"prompt_mode,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+	Strict bool   `json:"strict"`
+}
+
+type chatResponse struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Message struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+type chatChunk struct {
+	ID      string `json:"id"`
+	Choices []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// contentChunk is an entry of content that is returned as a list, which Magistral models do
+// to separate their thinking from the answer
+type contentChunk struct {
+	Type     string         `json:"type"`
+	Text     string         `json:"text"`
+	Thinking []contentChunk `json:"thinking"`
+}
+
+// validateSettings rejects settings that Mistral has no equivalent for
+func validateSettings(settings params.Settings) error {
+	switch {
+	case settings.TopK != nil:
+		return errors.New("top k is not supported by Mistral")
+	case len(settings.LogitBias) > 0:
+		return errors.New("logit bias is not supported by Mistral")
+	case settings.Logprobs, settings.TopLogprobs != nil:
+		return errors.New("logprobs are not supported by Mistral")
+	case settings.IsSearchEnabled:
+		return errors.New("search is not supported by Mistral")
+	case settings.Safety != nil:
+		return errors.New("safety settings are not supported by Mistral, use SafePrompt in mistral.Options instead")
+	}
+	return nil
+}
+
+func (c *Client) mapPromptToRequest(prompt params.Prompt, settings params.Settings, stream bool) (*chatRequest, error) {
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+	if prompt.PreviousResponseID != "" {
+		return nil, errors.New("previous response IDs are not supported by Mistral")
+	}
+	if len(prompt.Documents) > 0 {
+		return nil, errors.New("documents are not supported by Mistral")
+	}
+
+	opts := optionsFromSettings(settings)
+	request := &chatRequest{
+		Model:            settings.ModelName,
+		Stream:           stream,
+		Temperature:      settings.Temperature,
+		TopP:             settings.TopP,
+		MaxTokens:        settings.MaxOutputTokens,
+		Stop:             settings.StopSequences,
+		RandomSeed:       settings.Seed,
+		PresencePenalty:  settings.PresencePenalty,
+		FrequencyPenalty: settings.FrequencyPenalty,
+		N:                settings.CandidateCount,
+		SafePrompt:       opts.SafePrompt,
+		PromptMode:       opts.PromptMode,
+	}
+
+	switch c.structuredOutputMode {
+	case params.StructuredOutputJSONObject, params.StructuredOutputNone:
+		// Describe the schema in the system message instead, the reply is still validated against it
+		if prompt.ResponseFormat != nil {
+			var err error
+			if prompt, err = structured.WithSchemaInstruction(prompt); err != nil {
+				return nil, err
+			}
+			if c.structuredOutputMode == params.StructuredOutputJSONObject {
+				request.ResponseFormat = &responseFormat{Type: "json_object"}
+			}
+		}
+	default:
+		if prompt.ResponseFormat != nil {
+			t := reflect.TypeOf(prompt.ResponseFormat)
+			responseSchema, strict, err := schema.ForOpenAI(t)
+			if err != nil {
+				return nil, err
+			}
+			request.ResponseFormat = &responseFormat{
+				Type: "json_schema",
+				JSONSchema: &jsonSchema{
+					Name:   schema.Name(t),
+					Schema: responseSchema,
+					Strict: strict,
+				},
+			}
+		}
+	}
+
+	request.Messages = mapPromptToMessages(prompt)
+	return request, nil
+}
+
+func mapPromptToMessages(prompt params.Prompt) []chatMessage {
+	messages := []chatMessage{}
+	if prompt.SystemMessage != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: prompt.SystemMessage})
+	}
+
+	for _, message := range prompt.Messages {
+		role := "user"
+		if message.Role == params.MessageRoleAssistant {
+			role = "assistant"
+		}
+		messages = append(messages, chatMessage{Role: role, Content: message.Content})
+	}
+	return messages
+}
+
+// mapContent returns the answer and the thinking of a message or delta,
+// whose content is either a string or a list of chunks
+func mapContent(raw json.RawMessage) (content string, reasoning string) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", ""
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, ""
+	}
+
+	var chunks []contentChunk
+	if err := json.Unmarshal(raw, &chunks); err != nil {
+		return "", ""
+	}
+
+	var answer, thinking strings.Builder
+	for _, chunk := range chunks {
+		switch chunk.Type {
+		case "text":
+			answer.WriteString(chunk.Text)
+		case "thinking":
+			for _, thought := range chunk.Thinking {
+				thinking.WriteString(thought.Text)
+			}
+		}
+	}
+	return answer.String(), thinking.String()
+}
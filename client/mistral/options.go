@@ -0,0 +1,45 @@
+package mistral
+
+import "github.com/jamesleeht/llm-gopher/params"
+
+const providerName = "mistral"
+
+// Options are Mistral specific settings. Add them to params.Settings.ProviderOptions.
+type Options struct {
+	// SafePrompt prepends Mistral's safety system prompt to the conversation
+	SafePrompt bool
+	// PromptMode "reasoning" adds Magistral's default reasoning system prompt
+	PromptMode string
+}
+
+func (o Options) ProviderName() string {
+	return providerName
+}
+
+// optionsFromSettings returns the Mistral options of the settings, ignoring other providers' options.
+// If several are given, later options override earlier ones field by field.
+func optionsFromSettings(settings params.Settings) Options {
+	var result Options
+	for _, providerOptions := range settings.ProviderOptions {
+		var opts Options
+		switch o := providerOptions.(type) {
+		case Options:
+			opts = o
+		case *Options:
+			if o == nil {
+				continue
+			}
+			opts = *o
+		default:
+			continue
+		}
+
+		if opts.SafePrompt {
+			result.SafePrompt = true
+		}
+		if opts.PromptMode != "" {
+			result.PromptMode = opts.PromptMode
+		}
+	}
+	return result
+}
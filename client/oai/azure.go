@@ -0,0 +1,206 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/packages/respjson"
+)
+
+const defaultAzureAPIVersion = "2024-10-21"
+
+// TokenProvider returns a bearer token, e.g. from Microsoft Entra ID. It is called for every request,
+// so it should cache the token until it expires.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// TokenProviderFunc adapts a function to a TokenProvider
+type TokenProviderFunc func(ctx context.Context) (string, error)
+
+func (f TokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+type AzureConfig struct {
+	// Endpoint is the resource endpoint, e.g. https://my-resource.openai.azure.com
+	Endpoint string
+	// APIVersion defaults to 2024-10-21
+	APIVersion string
+	// Deployments maps Settings.ModelName to a deployment name.
+	// Models that aren't listed are used as the deployment name.
+	Deployments map[string]string
+	// Set either APIKey, which is sent in the api-key header, or TokenProvider
+	APIKey        string
+	TokenProvider TokenProvider
+	// StructuredOutputMode defaults to native JSON schema support
+	StructuredOutputMode params.StructuredOutputMode
+}
+
+// NewAzureOpenAIClient creates a client for Azure OpenAI, which addresses models by deployment
+func NewAzureOpenAIClient(config AzureConfig) (*Client, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("azure endpoint is required")
+	}
+	if (config.APIKey == "") == (config.TokenProvider == nil) {
+		return nil, errors.New("set either an azure api key or a token provider")
+	}
+
+	apiVersion := config.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+	endpoint := strings.TrimSuffix(config.Endpoint, "/")
+
+	opts := []option.RequestOption{
+		option.WithBaseURL(endpoint + "/openai/"),
+		option.WithQueryAdd("api-version", apiVersion),
+		// Don't send an OpenAI key picked up from the environment
+		option.WithHeaderDel("Authorization"),
+	}
+	if config.APIKey != "" {
+		opts = append(opts, option.WithHeader("api-key", config.APIKey))
+	} else {
+		opts = append(opts, option.WithMiddleware(bearerTokenMiddleware(config.TokenProvider)))
+	}
+
+	internalClient := openai.NewClient(opts...)
+	return &Client{
+		internalClient:       &internalClient,
+		structuredOutputMode: config.StructuredOutputMode,
+		azure: &azureDeployments{
+			endpoint:    endpoint,
+			deployments: config.Deployments,
+		},
+	}, nil
+}
+
+type azureDeployments struct {
+	endpoint    string
+	deployments map[string]string
+}
+
+// requestOptions points a request at the deployment of the model. It is empty for other backends.
+func (c *Client) requestOptions(modelName string) []option.RequestOption {
+	if c.azure == nil {
+		return nil
+	}
+
+	deployment := modelName
+	if name, ok := c.azure.deployments[modelName]; ok {
+		deployment = name
+	}
+	return []option.RequestOption{
+		option.WithBaseURL(c.azure.endpoint + "/openai/deployments/" + url.PathEscape(deployment) + "/"),
+	}
+}
+
+func bearerTokenMiddleware(provider TokenProvider) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		token, err := provider.Token(req.Context())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return next(req)
+	}
+}
+
+// Azure reports its content filter categories under its own names
+var azureHarmCategories = map[string]params.HarmCategory{
+	"hate":      params.HarmCategoryHateSpeech,
+	"sexual":    params.HarmCategorySexuallyExplicit,
+	"violence":  params.HarmCategoryViolence,
+	"self_harm": params.HarmCategorySelfHarm,
+}
+
+type azureFilterResult struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity"`
+	Detected *bool  `json:"detected"`
+}
+
+// contentFilterRatings reads Azure's content_filter_results, or returns nil for other backends
+func contentFilterRatings(fields map[string]respjson.Field) []params.SafetyRating {
+	raw, exists := extraField(fields, "content_filter_results")
+	if !exists {
+		return nil
+	}
+	return parseFilterResults(raw)
+}
+
+func parseFilterResults(raw string) []params.SafetyRating {
+	var results map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(raw), &results); err != nil {
+		return nil
+	}
+
+	var ratings []params.SafetyRating
+	for name, rawResult := range results {
+		var result azureFilterResult
+		if err := json.Unmarshal(rawResult, &result); err != nil {
+			// Some entries such as custom blocklists aren't category results
+			continue
+		}
+
+		category, ok := azureHarmCategories[name]
+		if !ok {
+			category = params.HarmCategory(name)
+		}
+
+		probability := result.Severity
+		if probability == "" && result.Detected != nil {
+			probability = "not_detected"
+			if *result.Detected {
+				probability = "detected"
+			}
+		}
+
+		ratings = append(ratings, params.SafetyRating{
+			Category:    category,
+			Probability: probability,
+			Blocked:     result.Filtered,
+		})
+	}
+
+	// Map order is random, so sort for stable output
+	sort.Slice(ratings, func(i, j int) bool {
+		return ratings[i].Category < ratings[j].Category
+	})
+	return ratings
+}
+
+// promptFilterError converts Azure's content_filter API error, returned when the prompt is blocked.
+// It returns nil for other errors.
+func promptFilterError(err error) *params.ContentFilterError {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != contentFilterFinishReason {
+		return nil
+	}
+
+	filterErr := &params.ContentFilterError{
+		PromptBlocked: true,
+		Reason:        contentFilterFinishReason,
+		Message:       apiErr.Message,
+	}
+
+	if inner, exists := extraField(apiErr.JSON.ExtraFields, "innererror"); exists {
+		var innerErr struct {
+			ContentFilterResult json.RawMessage `json:"content_filter_result"`
+		}
+		if json.Unmarshal([]byte(inner), &innerErr) == nil && innerErr.ContentFilterResult != nil {
+			filterErr.SafetyRatings = parseFilterResults(string(innerErr.ContentFilterResult))
+		}
+	}
+	return filterErr
+}
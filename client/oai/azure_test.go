@@ -0,0 +1,205 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// azureRequest is what the test server saw of a request
+type azureRequest struct {
+	url           *url.URL
+	apiKey        string
+	authorization string
+}
+
+// newTestAzureServer answers chat completions and embeddings and records every request
+func newTestAzureServer(t *testing.T) (*httptest.Server, func() []azureRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []azureRequest
+	var batches [][]string
+	embeddings := serveEmbeddings(t, &batches)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, azureRequest{
+			url:           r.URL,
+			apiKey:        r.Header.Get("api-key"),
+			authorization: r.Header.Get("Authorization"),
+		})
+		mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/embeddings") {
+			embeddings(w, r)
+			return
+		}
+		var body map[string]any
+		if json.NewDecoder(r.Body).Decode(&body); body["stream"] == true {
+			serveEvents(chunkEvent(0, "Hi", "stop"))(w, r)
+			return
+		}
+		serveCompletion("Hi", nil)(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []azureRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]azureRequest{}, requests...)
+	}
+}
+
+func TestAzureRoutesRequestsToDeployments(t *testing.T) {
+	server, requests := newTestAzureServer(t)
+	c, err := NewAzureOpenAIClient(AzureConfig{
+		Endpoint:    server.URL + "/",
+		APIKey:      "secret",
+		Deployments: map[string]string{"gpt-4o": "prod-gpt-4o"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	prompt := params.NewSimplePrompt("", "Hello")
+	if _, err := c.SendCompletionMessage(ctx, prompt, params.Settings{ModelName: "gpt-4o"}); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := c.StreamCompletionMessage(ctx, prompt, params.Settings{ModelName: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, final := collect(t, chunks); final.Error != nil || content != "Hi" {
+		t.Fatalf("expected the streamed reply, got %q and %v", content, final.Error)
+	}
+	// An unlisted model is used as the deployment name
+	if _, err := c.CreateEmbeddings(ctx, []string{"a"}, params.EmbeddingSettings{ModelName: "text-embedding-3-small"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedPaths := []string{
+		"/openai/deployments/prod-gpt-4o/chat/completions",
+		"/openai/deployments/prod-gpt-4o/chat/completions",
+		"/openai/deployments/text-embedding-3-small/embeddings",
+	}
+	seen := requests()
+	if len(seen) != len(expectedPaths) {
+		t.Fatalf("expected %d requests, got %d", len(expectedPaths), len(seen))
+	}
+	for i, request := range seen {
+		if request.url.Path != expectedPaths[i] {
+			t.Errorf("request %d: expected path %s, got %s", i, expectedPaths[i], request.url.Path)
+		}
+		if version := request.url.Query().Get("api-version"); version != defaultAzureAPIVersion {
+			t.Errorf("request %d: expected api-version %s, got %q", i, defaultAzureAPIVersion, version)
+		}
+		if request.apiKey != "secret" || request.authorization != "" {
+			t.Errorf("request %d: expected only the api-key header, got %q and %q", i, request.apiKey, request.authorization)
+		}
+	}
+}
+
+func TestAzureSendsBearerToken(t *testing.T) {
+	server, requests := newTestAzureServer(t)
+	var calls int
+	c, err := NewAzureOpenAIClient(AzureConfig{
+		Endpoint:   server.URL,
+		APIVersion: "2025-01-01-preview",
+		TokenProvider: TokenProviderFunc(func(ctx context.Context) (string, error) {
+			calls++
+			return "entra-token", nil
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hello"), params.Settings{ModelName: "gpt-4o-mini"}); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := requests()
+	if len(seen) != 1 || calls != 1 {
+		t.Fatalf("expected one request and one token call, got %d and %d", len(seen), calls)
+	}
+	request := seen[0]
+	if request.url.Path != "/openai/deployments/gpt-4o-mini/chat/completions" {
+		t.Errorf("expected the model name as deployment, got %s", request.url.Path)
+	}
+	if version := request.url.Query().Get("api-version"); version != "2025-01-01-preview" {
+		t.Errorf("expected the configured api-version, got %q", version)
+	}
+	if request.authorization != "Bearer entra-token" || request.apiKey != "" {
+		t.Errorf("expected only the bearer token, got %q and %q", request.authorization, request.apiKey)
+	}
+}
+
+func TestNewAzureOpenAIClientRequiresOneCredential(t *testing.T) {
+	tokenProvider := TokenProviderFunc(func(ctx context.Context) (string, error) { return "token", nil })
+	for name, config := range map[string]AzureConfig{
+		"no endpoint":    {APIKey: "secret"},
+		"no credentials": {Endpoint: "https://example.openai.azure.com"},
+		"both":           {Endpoint: "https://example.openai.azure.com", APIKey: "secret", TokenProvider: tokenProvider},
+	} {
+		if _, err := NewAzureOpenAIClient(config); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestAzureProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"code":"DeploymentNotFound","message":"The API deployment for this resource does not exist."}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewAzureOpenAIClient(AzureConfig{Endpoint: server.URL, APIKey: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "gpt-4o"})
+	var providerErr *params.ProviderError
+	if !errors.As(err, &providerErr) {
+		t.Fatalf("expected a provider error, got %v", err)
+	}
+	if providerErr.Provider != azureProviderName || providerErr.Kind != params.ErrorKindNotFound || providerErr.Code != "DeploymentNotFound" {
+		t.Errorf("expected a not found error from Azure, got %+v", providerErr)
+	}
+}
+
+func TestAzurePromptFilterError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(400)
+		fmt.Fprint(w, `{"error":{"message":"The response was filtered.","type":null,"param":"prompt","code":"content_filter","status":400,`+
+			`"innererror":{"code":"ResponsibleAIPolicyViolation","content_filter_result":{"violence":{"filtered":true,"severity":"high"}}}}}`)
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := NewAzureOpenAIClient(AzureConfig{Endpoint: server.URL, APIKey: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "gpt-4o"})
+	var filterErr *params.ContentFilterError
+	if !errors.As(err, &filterErr) || !filterErr.PromptBlocked {
+		t.Fatalf("expected a prompt content filter error, got %v", err)
+	}
+	if len(filterErr.SafetyRatings) != 1 || !filterErr.SafetyRatings[0].Blocked {
+		t.Errorf("expected the blocked violence rating, got %+v", filterErr.SafetyRatings)
+	}
+}
@@ -2,24 +2,40 @@ package oai
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"reflect"
+	"strings"
 
 	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
 
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/option"
+	"github.com/openai/openai-go/v3/shared"
 )
 
+// contentFilterFinishReason is the finish reason of a reply that was cut off by the content filter
+const contentFilterFinishReason = "content_filter"
+
 type ClientConfig struct {
 	Name    string `json:"name"`
 	APIKey  string `json:"api_key"`
 	BaseURL string `json:"base_url,omitempty"`
+	// StructuredOutputMode defaults to native JSON schema support.
+	// Many OpenAI compatible vendors only support JSON object mode or nothing at all.
+	StructuredOutputMode params.StructuredOutputMode `json:"structured_output_mode,omitempty"`
+	// UseResponsesAPI sends every request through the Responses API instead of Chat Completions
+	UseResponsesAPI bool `json:"use_responses_api,omitempty"`
 }
 
 type Client struct {
-	internalClient *openai.Client
+	internalClient       *openai.Client
+	structuredOutputMode params.StructuredOutputMode
+	// isOpenAI is false for OpenAI compatible vendors, which lack OpenAI only APIs such as Responses
+	isOpenAI        bool
+	useResponsesAPI bool
+	// azure is set for Azure OpenAI clients, which address models by deployment
+	azure *azureDeployments
 }
 
 func NewOpenAIClient(config ClientConfig) *Client {
@@ -30,44 +46,68 @@ func NewOpenAIClient(config ClientConfig) *Client {
 
 	internalClient := openai.NewClient(opts...)
 	return &Client{
-		internalClient: &internalClient,
+		internalClient:       &internalClient,
+		structuredOutputMode: config.StructuredOutputMode,
+		isOpenAI:             config.BaseURL == "" || strings.Contains(config.BaseURL, "api.openai.com"),
+		useResponsesAPI:      config.UseResponsesAPI,
 	}
 }
 
 func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
-	chatParams := mapSettingsToParams(settings)
-	messages := mapPromptToMessages(prompt)
-	chatParams.Messages = messages
+	mode, err := c.searchMode(settings)
+	if err != nil {
+		return nil, err
+	}
+	if c.useResponsesAPI || mode == searchModeResponses {
+		return c.sendResponse(ctx, prompt, settings)
+	}
 
-	if rf := mapPromptToResponseFormat(prompt); rf != nil {
-		chatParams.ResponseFormat = *rf
+	chatParams, err := c.mapPromptToParams(prompt, settings, mode)
+	if err != nil {
+		return nil, err
 	}
 
-	completion, err := c.internalClient.Chat.Completions.New(ctx, chatParams)
+	completion, err := c.internalClient.Chat.Completions.New(ctx, chatParams, c.requestOptions(settings.ModelName)...)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to send completion message: %w", err)
+		if filterErr := promptFilterError(err); filterErr != nil {
+			return nil, filterErr
+		}
+		return nil, fmt.Errorf("failed to send completion message: %w", c.providerError(err))
+	}
+
+	safetyRatings := contentFilterRatings(completion.Choices[0].JSON.ExtraFields)
+	if completion.Choices[0].FinishReason == contentFilterFinishReason {
+		return nil, &params.ContentFilterError{Reason: contentFilterFinishReason, SafetyRatings: safetyRatings}
 	}
 
 	content := completion.Choices[0].Message.Content
 
 	response := &params.Response{
-		Content: content,
-		Parsed:  nil,
+		ResponseID:    completion.ID,
+		Content:       content,
+		Parsed:        nil,
+		SafetyRatings: safetyRatings,
 	}
 
-	// If response format is specified, unmarshal into that type
-	if prompt.ResponseFormat != nil {
-		// ResponseFormat must be a pointer to unmarshal into
-		responseType := reflect.TypeOf(prompt.ResponseFormat)
-
-		if responseType.Kind() != reflect.Ptr {
-			return nil, fmt.Errorf("response format must be a pointer, got %v", responseType.Kind())
+	if settings.IncludeReasoning {
+		response.Reasoning = reasoningFromExtraFields(completion.Choices[0].Message.JSON.ExtraFields)
+	}
+	response.Grounding = mapAnnotations(completion.Choices[0].Message.Annotations, content)
+	if settings.Logprobs {
+		response.Logprobs = mapLogprobs(completion.Choices[0].Logprobs.Content)
+	}
+	if len(completion.Choices) > 1 {
+		for _, choice := range completion.Choices {
+			response.Candidates = append(response.Candidates, choice.Message.Content)
 		}
+	}
 
+	// If response format is specified, validate the reply and unmarshal into that type
+	if prompt.ResponseFormat != nil {
 		// Unmarshal directly into the pointer provided by the user
-		if err := json.Unmarshal([]byte(content), prompt.ResponseFormat); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response into specified format: %w", err)
+		if err := structured.Parse(content, prompt.ResponseFormat); err != nil {
+			return nil, err
 		}
 
 		// Set the parsed field to the populated pointer
@@ -78,30 +118,72 @@ func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt
 }
 
 func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
-	chatParams := mapSettingsToParams(settings)
-	messages := mapPromptToMessages(prompt)
-	chatParams.Messages = messages
+	mode, err := c.searchMode(settings)
+	if err != nil {
+		return nil, err
+	}
+	if c.useResponsesAPI || mode == searchModeResponses {
+		return c.streamResponse(ctx, prompt, settings)
+	}
 
-	// Note: Response format (structured outputs) are not supported in streaming mode for OpenAI
-	// We ignore the response format if specified
-	if prompt.ResponseFormat != nil {
-		fmt.Println("Warning: Response format is not supported in streaming mode and will be ignored")
+	chatParams, err := c.mapPromptToParams(prompt, settings, mode)
+	if err != nil {
+		return nil, err
 	}
 
-	stream := c.internalClient.Chat.Completions.NewStreaming(ctx, chatParams)
+	stream := c.internalClient.Chat.Completions.NewStreaming(ctx, chatParams, c.requestOptions(settings.ModelName)...)
 
 	chunks := make(chan params.StreamChunk)
 
 	go func() {
 		defer close(chunks)
 
+		// Annotations refer to the whole reply, so they are converted once it has been received
+		var content strings.Builder
+		var annotations []openai.ChatCompletionMessageAnnotation
+
 		acc := stream.Next()
 		for acc {
-			chunk := stream.Current()
+			// With several candidates, chunks of every choice are interleaved. Only the first one is streamed.
+			choice := firstChoice(stream.Current().Choices)
+			if choice == nil {
+				acc = stream.Next()
+				continue
+			}
+
+			content.WriteString(choice.Delta.Content)
+			annotations = append(annotations, annotationsFromExtraFields(choice.Delta.JSON.ExtraFields)...)
+
+			if settings.IncludeReasoning {
+				if reasoning := reasoningFromExtraFields(choice.Delta.JSON.ExtraFields); reasoning != "" {
+					chunks <- params.StreamChunk{
+						Kind:    params.StreamChunkKindReasoning,
+						Content: reasoning,
+						Done:    false,
+						Error:   nil,
+					}
+				}
+			}
 
-			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			if choice.FinishReason == contentFilterFinishReason {
+				// Send the delta that arrived with the filter result before the error
+				if choice.Delta.Content != "" {
+					chunks <- params.StreamChunk{Content: choice.Delta.Content}
+				}
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error: &params.ContentFilterError{
+						Reason:        contentFilterFinishReason,
+						SafetyRatings: contentFilterRatings(choice.JSON.ExtraFields),
+					},
+				}
+				return
+			}
+
+			if choice.Delta.Content != "" {
 				chunks <- params.StreamChunk{
-					Content: chunk.Choices[0].Delta.Content,
+					Content: choice.Delta.Content,
 					Done:    false,
 					Error:   nil,
 				}
@@ -111,21 +193,121 @@ func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prom
 		}
 
 		if err := stream.Err(); err != nil {
+			streamErr := fmt.Errorf("streaming error: %w", c.providerError(err))
+			if filterErr := promptFilterError(err); filterErr != nil {
+				streamErr = filterErr
+			}
 			chunks <- params.StreamChunk{
 				Content: "",
 				Done:    true,
-				Error:   fmt.Errorf("streaming error: %w", err),
+				Error:   streamErr,
 			}
 			return
 		}
 
 		// Send final chunk to indicate completion
 		chunks <- params.StreamChunk{
-			Content: "",
-			Done:    true,
-			Error:   nil,
+			Content:   "",
+			Done:      true,
+			Error:     nil,
+			Grounding: mapAnnotations(annotations, content.String()),
 		}
 	}()
 
 	return chunks, nil
 }
+
+// firstChoice returns the choice of the first candidate, or nil if the chunk has none
+func firstChoice(choices []openai.ChatCompletionChunkChoice) *openai.ChatCompletionChunkChoice {
+	for i := range choices {
+		if choices[i].Index == 0 {
+			return &choices[i]
+		}
+	}
+	return nil
+}
+
+// ValidateSettings reports settings that this client cannot send, so presets can be rejected up front
+func (c *Client) ValidateSettings(settings params.Settings) error {
+	if err := validateSettings(settings); err != nil {
+		return err
+	}
+
+	mode, err := c.searchMode(settings)
+	if err != nil {
+		return err
+	}
+	if c.useResponsesAPI || mode == searchModeResponses {
+		return validateResponsesSettings(settings)
+	}
+	return nil
+}
+
+func (c *Client) mapPromptToParams(prompt params.Prompt, settings params.Settings, mode searchMode) (openai.ChatCompletionNewParams, error) {
+	if prompt.PreviousResponseID != "" {
+		return openai.ChatCompletionNewParams{}, errors.New("previous response id requires the Responses API")
+	}
+	if len(prompt.Documents) > 0 {
+		return openai.ChatCompletionNewParams{}, errors.New("documents are not supported by OpenAI")
+	}
+
+	chatParams, err := mapSettingsToParams(settings)
+	if err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+	if mode == searchModeChat {
+		chatParams.WebSearchOptions = mapSearchOptions(optionsFromSettings(settings))
+	}
+
+	switch c.structuredOutputMode {
+	case params.StructuredOutputJSONObject, params.StructuredOutputNone:
+		// Describe the schema in the system message instead, the reply is still validated against it
+		if prompt.ResponseFormat != nil {
+			if prompt, err = structured.WithSchemaInstruction(prompt); err != nil {
+				return openai.ChatCompletionNewParams{}, err
+			}
+			if c.structuredOutputMode == params.StructuredOutputJSONObject {
+				chatParams.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+					OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+				}
+			}
+		}
+	default:
+		rf, err := mapPromptToResponseFormat(prompt)
+		if err != nil {
+			return openai.ChatCompletionNewParams{}, err
+		}
+		if rf != nil {
+			chatParams.ResponseFormat = *rf
+		}
+	}
+
+	chatParams.Messages = mapPromptToMessages(prompt)
+	return chatParams, nil
+}
+
+// providerError converts an API error of the SDK to a ProviderError, which keeps it as Err.
+// Other errors, e.g. network errors, are returned as they are.
+func (c *Client) providerError(err error) error {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	provider := providerName
+	if c.azure != nil {
+		provider = azureProviderName
+	}
+	code := apiErr.Code
+	if code == "" {
+		code = apiErr.Type
+	}
+	return &params.ProviderError{
+		Provider:   provider,
+		Kind:       params.ErrorKindFromStatus(apiErr.StatusCode),
+		StatusCode: apiErr.StatusCode,
+		Code:       code,
+		Message:    apiErr.Message,
+		Err:        err,
+	}
+}
@@ -0,0 +1,177 @@
+package oai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// newTestClient returns a client for a local server that answers every request with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewOpenAIClient(ClientConfig{APIKey: "test", BaseURL: server.URL})
+}
+
+// serveEvents answers with a server-sent event stream of the given JSON events
+func serveEvents(events ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}
+}
+
+// collect reads a stream, returning the text content and the final chunk
+func collect(t *testing.T, chunks <-chan params.StreamChunk) (string, params.StreamChunk) {
+	t.Helper()
+	var content strings.Builder
+	var final params.StreamChunk
+	for chunk := range chunks {
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		if chunk.Kind == params.StreamChunkKindText {
+			content.WriteString(chunk.Content)
+		}
+	}
+	return content.String(), final
+}
+
+func chunkEvent(index int, content string, finishReason string) string {
+	finish := "null"
+	if finishReason != "" {
+		finish = fmt.Sprintf("%q", finishReason)
+	}
+	return fmt.Sprintf(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"gpt-4o",`+
+		`"choices":[{"index":%d,"delta":{"content":%q},"finish_reason":%s}]}`, index, content, finish)
+}
+
+func TestStreamOnlyFirstCandidate(t *testing.T) {
+	c := newTestClient(t, serveEvents(
+		chunkEvent(0, "Hello", ""),
+		chunkEvent(1, "Bonjour", ""),
+		chunkEvent(1, " le monde", "stop"),
+		chunkEvent(0, " world", "stop"),
+	))
+
+	candidateCount := 2
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Greet me"),
+		params.Settings{ModelName: "gpt-4o", CandidateCount: &candidateCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, final := collect(t, chunks)
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content != "Hello world" {
+		t.Errorf("expected only the first candidate, got %q", content)
+	}
+}
+
+func TestStreamContentFilterKeepsDelta(t *testing.T) {
+	c := newTestClient(t, serveEvents(
+		chunkEvent(0, "Here is", ""),
+		chunkEvent(0, " how", "content_filter"),
+	))
+
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Tell me"), params.Settings{ModelName: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, final := collect(t, chunks)
+	if content != "Here is how" {
+		t.Errorf("expected the filtered chunk's delta, got %q", content)
+	}
+	var filterErr *params.ContentFilterError
+	if !errors.As(final.Error, &filterErr) {
+		t.Errorf("expected a content filter error, got %v", final.Error)
+	}
+}
+
+func TestValidateSettingsRejectsSafety(t *testing.T) {
+	c := NewOpenAIClient(ClientConfig{APIKey: "test"})
+	err := c.ValidateSettings(params.Settings{
+		ModelName: "gpt-4o",
+		Safety:    &params.SafetySettings{Default: params.SafetyThresholdBlockLowAndAbove},
+	})
+	if err == nil {
+		t.Error("expected safety settings to be rejected")
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		kind   params.ErrorKind
+		code   string
+	}{
+		{"invalid api key", 401,
+			`{"error":{"message":"Incorrect API key provided.","type":"invalid_request_error","param":null,"code":"invalid_api_key"}}`,
+			params.ErrorKindAuthentication, "invalid_api_key"},
+		{"unknown model", 404,
+			`{"error":{"message":"The model gpt-9 does not exist.","type":"invalid_request_error","param":null,"code":"model_not_found"}}`,
+			params.ErrorKindNotFound, "model_not_found"},
+		{"rate limit", 429,
+			`{"error":{"message":"Rate limit reached.","type":"requests","param":null,"code":"rate_limit_exceeded"}}`,
+			params.ErrorKindRateLimit, "rate_limit_exceeded"},
+		{"server error without code", 500,
+			`{"error":{"message":"The server had an error.","type":"server_error","param":null,"code":null}}`,
+			params.ErrorKindServer, "server_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Keep the SDK from retrying rate limits and server errors
+				w.Header().Set("X-Should-Retry", "false")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			t.Cleanup(server.Close)
+
+			c := NewOpenAIClient(ClientConfig{APIKey: "test", BaseURL: server.URL})
+
+			_, err := c.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "gpt-4o"})
+			var providerErr *params.ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("expected a provider error, got %v", err)
+			}
+			if providerErr.Provider != providerName || providerErr.StatusCode != tt.status || providerErr.Kind != tt.kind || providerErr.Code != tt.code {
+				t.Errorf("expected %s status %d code %s, got %+v", tt.kind, tt.status, tt.code, providerErr)
+			}
+			var apiErr *openai.Error
+			if !errors.As(err, &apiErr) {
+				t.Error("expected the SDK error to be kept")
+			}
+
+			chunks, err := c.StreamCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "gpt-4o"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, final := collect(t, chunks); !errors.As(final.Error, &providerErr) {
+				t.Errorf("expected a provider error from the stream, got %v", final.Error)
+			}
+		})
+	}
+}
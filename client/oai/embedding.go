@@ -0,0 +1,70 @@
+package oai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// OpenAI accepts at most 2048 inputs per embeddings request
+const maxEmbeddingBatchSize = 2048
+
+// CreateEmbeddings embeds the texts in batches. TaskType is not supported by OpenAI and is ignored.
+func (c *Client) CreateEmbeddings(ctx context.Context, texts []string, settings params.EmbeddingSettings) (*params.EmbeddingResponse, error) {
+	batchSize := maxEmbeddingBatchSize
+	if settings.BatchSize > 0 && settings.BatchSize < batchSize {
+		batchSize = settings.BatchSize
+	}
+
+	response := &params.EmbeddingResponse{
+		Embeddings: make([][]float32, 0, len(texts)),
+	}
+
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch := texts[start:end]
+
+		embeddingParams := openai.EmbeddingNewParams{
+			Model: openai.EmbeddingModel(settings.ModelName),
+			Input: openai.EmbeddingNewParamsInputUnion{
+				OfArrayOfStrings: batch,
+			},
+		}
+		if settings.Dimensions > 0 {
+			embeddingParams.Dimensions = openai.Int(int64(settings.Dimensions))
+		}
+
+		result, err := c.internalClient.Embeddings.New(ctx, embeddingParams, c.requestOptions(settings.ModelName)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create embeddings: %w", c.providerError(err))
+		}
+		if len(result.Data) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(result.Data))
+		}
+
+		// Data is not guaranteed to be in input order, so place each embedding by its index
+		embeddings := make([][]float32, len(batch))
+		for _, data := range result.Data {
+			if data.Index < 0 || int(data.Index) >= len(batch) {
+				return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+			}
+			embeddings[data.Index] = toFloat32(data.Embedding)
+		}
+
+		response.Embeddings = append(response.Embeddings, embeddings...)
+		response.TokenCount += int(result.Usage.PromptTokens)
+	}
+
+	return response, nil
+}
+
+func toFloat32(values []float64) []float32 {
+	result := make([]float32, len(values))
+	for i, v := range values {
+		result[i] = float32(v)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// serveEmbeddings embeds each input as its position in the request, answering in reverse order
+func serveEmbeddings(t *testing.T, batches *[][]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Error(err)
+		}
+		*batches = append(*batches, request.Input)
+
+		var data []string
+		for i := len(request.Input) - 1; i >= 0; i-- {
+			data = append(data, fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":[%d]}`, i, len(request.Input[i])))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"object":"list","model":"text-embedding-3-small","data":[%s],"usage":{"prompt_tokens":%d,"total_tokens":%d}}`,
+			strings.Join(data, ","), len(request.Input), len(request.Input))
+	}
+}
+
+func TestCreateEmbeddingsKeepsInputOrderAcrossBatches(t *testing.T) {
+	var batches [][]string
+	c := newTestClient(t, serveEmbeddings(t, &batches))
+
+	// Each input embeds as its length, so the order of the embeddings shows the order of the inputs
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	response, err := c.CreateEmbeddings(context.Background(), texts,
+		params.EmbeddingSettings{ModelName: "text-embedding-3-small", BatchSize: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(batches) != 3 {
+		t.Errorf("expected 3 batches of at most 2 inputs, got %q", batches)
+	}
+	if len(response.Embeddings) != len(texts) {
+		t.Fatalf("expected %d embeddings, got %d", len(texts), len(response.Embeddings))
+	}
+	for i, embedding := range response.Embeddings {
+		if len(embedding) != 1 || embedding[0] != float32(len(texts[i])) {
+			t.Errorf("expected embedding %d to be for %q, got %v", i, texts[i], embedding)
+		}
+	}
+	if response.TokenCount != len(texts) {
+		t.Errorf("expected the token counts of all batches, got %d", response.TokenCount)
+	}
+}
+
+func TestCreateEmbeddingsRejectsMissingEmbeddings(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[1]}],"usage":{}}`)
+	})
+
+	_, err := c.CreateEmbeddings(context.Background(), []string{"a", "b"}, params.EmbeddingSettings{ModelName: "text-embedding-3-small"})
+	if err == nil {
+		t.Error("expected an error when fewer embeddings than inputs are returned")
+	}
+}
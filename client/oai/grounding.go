@@ -0,0 +1,89 @@
+package oai
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/respjson"
+)
+
+// groundingBuilder collects citations from either API. OpenAI reports character offsets,
+// which are converted to byte offsets into the reply.
+type groundingBuilder struct {
+	grounding *params.Grounding
+	seen      map[string]bool
+}
+
+func (b *groundingBuilder) init() {
+	if b.grounding == nil {
+		b.grounding = &params.Grounding{}
+		b.seen = make(map[string]bool)
+	}
+}
+
+// addCitation adds a citation of text, which starts at byte offset in the whole reply
+func (b *groundingBuilder) addCitation(url, title string, startIndex, endIndex int, text string, offset int) {
+	b.init()
+	if !b.seen[url] {
+		b.seen[url] = true
+		b.grounding.Sources = append(b.grounding.Sources, params.Source{URL: url, Title: title})
+	}
+
+	start := byteOffset(text, startIndex)
+	end := byteOffset(text, endIndex)
+	if end < start {
+		end = start
+	}
+	b.grounding.Citations = append(b.grounding.Citations, params.Citation{
+		URL:        url,
+		Title:      title,
+		StartIndex: offset + start,
+		EndIndex:   offset + end,
+		Text:       text[start:end],
+	})
+}
+
+func (b *groundingBuilder) addSearchQuery(query string) {
+	b.init()
+	b.grounding.SearchQueries = append(b.grounding.SearchQueries, query)
+}
+
+// mapAnnotations converts URL citations into grounding, or returns nil if there are none
+func mapAnnotations(annotations []openai.ChatCompletionMessageAnnotation, content string) *params.Grounding {
+	var builder groundingBuilder
+	for _, annotation := range annotations {
+		if annotation.Type != "url_citation" {
+			continue
+		}
+		citation := annotation.URLCitation
+		builder.addCitation(citation.URL, citation.Title, int(citation.StartIndex), int(citation.EndIndex), content, 0)
+	}
+	return builder.grounding
+}
+
+// annotationsFromExtraFields reads annotations from a streamed delta, where the SDK doesn't declare them
+func annotationsFromExtraFields(fields map[string]respjson.Field) []openai.ChatCompletionMessageAnnotation {
+	raw, exists := extraField(fields, "annotations")
+	if !exists {
+		return nil
+	}
+
+	var annotations []openai.ChatCompletionMessageAnnotation
+	if err := json.Unmarshal([]byte(raw), &annotations); err != nil {
+		return nil
+	}
+	return annotations
+}
+
+// byteOffset returns the byte offset of the character at index, clamped to the length of s
+func byteOffset(s string, index int) int {
+	offset := 0
+	for i := 0; i < index && offset < len(s); i++ {
+		_, size := utf8.DecodeRuneInString(s[offset:])
+		offset += size
+	}
+	return offset
+}
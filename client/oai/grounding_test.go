@@ -0,0 +1,112 @@
+package oai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+)
+
+func urlCitation(url string, start int64, end int64) openai.ChatCompletionMessageAnnotation {
+	return openai.ChatCompletionMessageAnnotation{
+		Type: "url_citation",
+		URLCitation: openai.ChatCompletionMessageAnnotationURLCitation{
+			URL:        url,
+			Title:      url,
+			StartIndex: start,
+			EndIndex:   end,
+		},
+	}
+}
+
+func TestMapAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		annotations []openai.ChatCompletionMessageAnnotation
+		sources     int
+		expected    []string
+	}{
+		{name: "none", content: "Paris"},
+		{
+			name:        "ascii",
+			content:     "Paris is the capital of France.",
+			annotations: []openai.ChatCompletionMessageAnnotation{urlCitation("a", 0, 5), urlCitation("b", 24, 30)},
+			sources:     2,
+			expected:    []string{"Paris", "France"},
+		},
+		{
+			// Offsets count characters, so they differ from byte offsets after é and ü
+			name:        "non-ascii",
+			content:     "Le café de Zürich ouvre à 8h.",
+			annotations: []openai.ChatCompletionMessageAnnotation{urlCitation("a", 3, 7), urlCitation("a", 11, 17), urlCitation("b", 24, 28)},
+			sources:     2,
+			expected:    []string{"café", "Zürich", "à 8h"},
+		},
+		{
+			name:        "out of range",
+			content:     "Paris",
+			annotations: []openai.ChatCompletionMessageAnnotation{urlCitation("a", 2, 50), urlCitation("b", 4, 1)},
+			sources:     2,
+			expected:    []string{"ris", ""},
+		},
+		{
+			name:        "other annotation types",
+			content:     "Paris",
+			annotations: []openai.ChatCompletionMessageAnnotation{{Type: "file_citation"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grounding := mapAnnotations(test.annotations, test.content)
+			if test.expected == nil {
+				if grounding != nil {
+					t.Errorf("expected no grounding, got %+v", grounding)
+				}
+				return
+			}
+
+			if len(grounding.Sources) != test.sources {
+				t.Errorf("expected %d distinct sources, got %+v", test.sources, grounding.Sources)
+			}
+			var cited []string
+			for _, citation := range grounding.Citations {
+				if span := test.content[citation.StartIndex:citation.EndIndex]; span != citation.Text {
+					t.Errorf("expected the byte offsets to slice %q, got %q", citation.Text, span)
+				}
+				cited = append(cited, citation.Text)
+			}
+			if !reflect.DeepEqual(cited, test.expected) {
+				t.Errorf("expected citations %q, got %q", test.expected, cited)
+			}
+		})
+	}
+}
+
+func TestSendCompletionMessageReturnsGrounding(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o-search-preview",`+
+			`"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"Zürich has 440,000 people.",`+
+			`"annotations":[{"type":"url_citation","url_citation":{"url":"https://example.com","title":"Zürich",`+
+			`"start_index":0,"end_index":26}}]}}]}`)
+	})
+
+	response, err := c.SendCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Population of Zürich?"), params.Settings{ModelName: "gpt-4o-search-preview"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Grounding == nil || len(response.Grounding.Citations) != 1 {
+		t.Fatalf("expected one citation, got %+v", response.Grounding)
+	}
+	if citation := response.Grounding.Citations[0]; citation.Text != response.Content || citation.EndIndex != len(response.Content) {
+		t.Errorf("expected the whole reply to be cited, got %+v", citation)
+	}
+}
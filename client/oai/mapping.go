@@ -1,11 +1,12 @@
 package oai
 
 import (
+	"errors"
 	"reflect"
 
 	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
 
-	"github.com/invopop/jsonschema"
 	"github.com/openai/openai-go/v3"
 	"github.com/openai/openai-go/v3/shared"
 )
@@ -16,71 +17,141 @@ func mapPromptToMessages(prompt params.Prompt) []openai.ChatCompletionMessagePar
 		messages = append(messages, openai.SystemMessage(prompt.SystemMessage))
 	}
 
-	if prompt.Messages != nil {
-		for _, message := range prompt.Messages {
+	for _, message := range prompt.Messages {
+		switch message.Role {
+		case params.MessageRoleAssistant:
+			messages = append(messages, openai.AssistantMessage(message.Content))
+		default:
 			messages = append(messages, openai.UserMessage(message.Content))
 		}
 	}
 	return messages
 }
 
-func mapPromptToResponseFormat(prompt params.Prompt) *openai.ChatCompletionNewParamsResponseFormatUnion {
+func mapPromptToResponseFormat(prompt params.Prompt) (*openai.ChatCompletionNewParamsResponseFormatUnion, error) {
 	if prompt.ResponseFormat == nil {
-		return nil
+		return nil, nil
 	}
 
-	// Get the type name from the struct
-	t := reflect.TypeOf(prompt.ResponseFormat)
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+	responseType := reflect.TypeOf(prompt.ResponseFormat)
+	responseSchema, strict, err := schema.ForOpenAI(responseType)
+	if err != nil {
+		return nil, err
 	}
-	schemaName := t.Name()
 
 	schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
-		Name:   schemaName,
-		Schema: generateSchemaFromType(reflect.TypeOf(prompt.ResponseFormat)),
-		Strict: openai.Bool(true),
+		Name:   schema.Name(responseType),
+		Schema: responseSchema,
+		Strict: openai.Bool(strict),
 	}
 
 	return &openai.ChatCompletionNewParamsResponseFormatUnion{
 		OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
 			JSONSchema: schemaParam,
 		},
-	}
+	}, nil
 }
 
-func generateSchemaFromType(t reflect.Type) interface{} {
-	// Structured Outputs uses a subset of JSON schema
-	// These flags are necessary to comply with the subset
-	reflector := jsonschema.Reflector{
-		AllowAdditionalProperties: false,
-		DoNotReference:            true,
-	}
+const defaultMaxCompletionTokens = 16000
 
-	// If it's a pointer type, get the element type
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+// validateSettings rejects sampling parameters that the Chat Completions API cannot express
+func validateSettings(settings params.Settings) error {
+	if settings.TopK != nil {
+		return errors.New("top k is not supported by OpenAI")
 	}
-
-	// Create a zero value of the type
-	v := reflect.New(t).Elem().Interface()
-	schema := reflector.Reflect(v)
-	return schema
+	if settings.TopLogprobs != nil && !settings.Logprobs {
+		return errors.New("top logprobs requires logprobs to be enabled")
+	}
+	if settings.Safety != nil {
+		return errors.New("safety settings are not supported by OpenAI, content filters are configured on the account or deployment")
+	}
+	return validateSearchOptions(optionsFromSettings(settings))
 }
 
-func mapSettingsToParams(settings params.Settings) openai.ChatCompletionNewParams {
-	reasoningEffort := getReasoningEffortFromThinkingBudget(settings.ThinkingBudget)
+func mapSettingsToParams(settings params.Settings) (openai.ChatCompletionNewParams, error) {
+	if err := validateSettings(settings); err != nil {
+		return openai.ChatCompletionNewParams{}, err
+	}
+
 	params := openai.ChatCompletionNewParams{
 		Model:               shared.ChatModel(string(settings.ModelName)),
-		ReasoningEffort:     reasoningEffort,
-		MaxCompletionTokens: openai.Int(16000),
+		ReasoningEffort:     getReasoningEffort(settings),
+		MaxCompletionTokens: openai.Int(int64(getMaxOutputTokens(settings))),
 	}
 
 	if settings.Temperature != nil {
 		params.Temperature = openai.Float(*settings.Temperature)
 	}
+	if settings.TopP != nil {
+		params.TopP = openai.Float(*settings.TopP)
+	}
+	if settings.Seed != nil {
+		params.Seed = openai.Int(int64(*settings.Seed))
+	}
+	if len(settings.StopSequences) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: settings.StopSequences}
+	}
+	if settings.PresencePenalty != nil {
+		params.PresencePenalty = openai.Float(*settings.PresencePenalty)
+	}
+	if settings.FrequencyPenalty != nil {
+		params.FrequencyPenalty = openai.Float(*settings.FrequencyPenalty)
+	}
+	if settings.CandidateCount != nil {
+		params.N = openai.Int(int64(*settings.CandidateCount))
+	}
+	if len(settings.LogitBias) > 0 {
+		params.LogitBias = make(map[string]int64, len(settings.LogitBias))
+		for token, bias := range settings.LogitBias {
+			params.LogitBias[token] = int64(bias)
+		}
+	}
+	if settings.Logprobs {
+		params.Logprobs = openai.Bool(true)
+	}
+	if settings.TopLogprobs != nil {
+		params.TopLogprobs = openai.Int(int64(*settings.TopLogprobs))
+	}
+
+	applyOptions(&params, optionsFromSettings(settings))
+
+	return params, nil
+}
+
+func mapLogprobs(logprobs []openai.ChatCompletionTokenLogprob) []params.TokenLogprob {
+	if len(logprobs) == 0 {
+		return nil
+	}
 
-	return params
+	result := make([]params.TokenLogprob, 0, len(logprobs))
+	for _, logprob := range logprobs {
+		tokenLogprob := params.TokenLogprob{
+			Token:   logprob.Token,
+			Logprob: logprob.Logprob,
+		}
+		for _, top := range logprob.TopLogprobs {
+			tokenLogprob.TopLogprobs = append(tokenLogprob.TopLogprobs, params.TopLogprob{
+				Token:   top.Token,
+				Logprob: top.Logprob,
+			})
+		}
+		result = append(result, tokenLogprob)
+	}
+	return result
+}
+
+func getMaxOutputTokens(settings params.Settings) int {
+	if settings.MaxOutputTokens != nil {
+		return *settings.MaxOutputTokens
+	}
+	return defaultMaxCompletionTokens
+}
+
+func getReasoningEffort(settings params.Settings) shared.ReasoningEffort {
+	if settings.ThinkingTokens != nil {
+		return getReasoningEffortFromThinkingTokens(*settings.ThinkingTokens)
+	}
+	return getReasoningEffortFromThinkingBudget(settings.ThinkingBudget)
 }
 
 func getReasoningEffortFromThinkingBudget(thinkingBudget params.ThinkingBudget) shared.ReasoningEffort {
@@ -97,3 +168,21 @@ func getReasoningEffortFromThinkingBudget(thinkingBudget params.ThinkingBudget)
 		return ""
 	}
 }
+
+// getReasoningEffortFromThinkingTokens maps a numeric budget to the closest effort, using the same
+// thresholds as the Vertex thinking budgets. Reasoning can't be turned off, so 0 is the minimal effort.
+// Dynamic budgets use the model's default effort.
+func getReasoningEffortFromThinkingTokens(thinkingTokens int) shared.ReasoningEffort {
+	switch {
+	case thinkingTokens < 0:
+		return ""
+	case thinkingTokens <= 512:
+		return shared.ReasoningEffortMinimal
+	case thinkingTokens <= 1024:
+		return shared.ReasoningEffortLow
+	case thinkingTokens <= 2048:
+		return shared.ReasoningEffortMedium
+	default:
+		return shared.ReasoningEffortHigh
+	}
+}
@@ -0,0 +1,111 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// serveCompletion answers with a chat completion of content and records the request body
+func serveCompletion(content string, body *map[string]any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"gpt-4o",`+
+			`"choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, content)
+	}
+}
+
+type city struct {
+	Name       string `json:"name"`
+	Population int    `json:"population"`
+}
+
+func TestStructuredOutputModes(t *testing.T) {
+	tests := []struct {
+		mode                params.StructuredOutputMode
+		responseFormat      string
+		instructionInPrompt bool
+	}{
+		{mode: params.StructuredOutputNative, responseFormat: "json_schema"},
+		{mode: params.StructuredOutputJSONObject, responseFormat: "json_object", instructionInPrompt: true},
+		{mode: params.StructuredOutputNone, instructionInPrompt: true},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.mode), func(t *testing.T) {
+			var body map[string]any
+			c := newTestClient(t, serveCompletion(`{"name":"Paris","population":2100000}`, &body))
+			c.structuredOutputMode = test.mode
+
+			var result city
+			prompt := params.NewSimplePrompt("Be brief.", "Largest city in France?")
+			prompt.ResponseFormat = &result
+			if _, err := c.SendCompletionMessage(context.Background(), prompt, params.Settings{ModelName: "gpt-4o"}); err != nil {
+				t.Fatal(err)
+			}
+			if result.Name != "Paris" {
+				t.Errorf("expected the parsed reply, got %+v", result)
+			}
+
+			responseFormat, _ := body["response_format"].(map[string]any)
+			if got, _ := responseFormat["type"].(string); got != test.responseFormat {
+				t.Errorf("expected response format %q, got %q", test.responseFormat, got)
+			}
+
+			system := body["messages"].([]any)[0].(map[string]any)["content"].(string)
+			if got := strings.Contains(system, `"population"`); got != test.instructionInPrompt {
+				t.Errorf("expected the schema in the system message to be %v, got %q", test.instructionInPrompt, system)
+			}
+		})
+	}
+}
+
+func TestStructuredOutputModesValidateReply(t *testing.T) {
+	for _, mode := range []params.StructuredOutputMode{params.StructuredOutputJSONObject, params.StructuredOutputNone} {
+		t.Run(string(mode), func(t *testing.T) {
+			var body map[string]any
+			c := newTestClient(t, serveCompletion(`Sure! {"name":"Paris"}`, &body))
+			c.structuredOutputMode = mode
+
+			prompt := params.NewSimplePrompt("", "Largest city in France?")
+			prompt.ResponseFormat = &city{}
+			_, err := c.SendCompletionMessage(context.Background(), prompt, params.Settings{ModelName: "gpt-4o"})
+
+			var outputErr *params.StructuredOutputError
+			if !errors.As(err, &outputErr) || len(outputErr.Violations) == 0 {
+				t.Errorf("expected the missing population to fail validation, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetReasoningEffortFromThinkingTokens(t *testing.T) {
+	tests := []struct {
+		thinkingTokens int
+		want           shared.ReasoningEffort
+	}{
+		{thinkingTokens: params.DynamicThinkingTokens, want: ""},
+		{thinkingTokens: 0, want: shared.ReasoningEffortMinimal},
+		{thinkingTokens: 512, want: shared.ReasoningEffortMinimal},
+		{thinkingTokens: 1024, want: shared.ReasoningEffortLow},
+		{thinkingTokens: 2048, want: shared.ReasoningEffortMedium},
+		{thinkingTokens: 8192, want: shared.ReasoningEffortHigh},
+	}
+
+	for _, tt := range tests {
+		if got := getReasoningEffortFromThinkingTokens(tt.thinkingTokens); got != tt.want {
+			t.Errorf("thinking tokens %d: expected effort %q, got %q", tt.thinkingTokens, tt.want, got)
+		}
+	}
+}
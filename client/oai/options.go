@@ -0,0 +1,103 @@
+package oai
+
+import (
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+)
+
+const providerName = "openai"
+
+// azureProviderName names Azure OpenAI in provider errors
+const azureProviderName = "azure_openai"
+
+// Options are OpenAI specific settings. Add them to params.Settings.ProviderOptions.
+type Options struct {
+	ServiceTier openai.ChatCompletionNewParamsServiceTier
+	Store       *bool
+	Metadata    map[string]string
+	// Prediction is the expected content of the reply, which speeds up regenerating mostly unchanged text
+	Prediction string
+	// ExtraBody is merged into the request body, for fields that OpenAI compatible vendors add
+	ExtraBody map[string]any
+	// SearchContextSize is "low", "medium" or "high". Used when Settings.IsSearchEnabled is set.
+	SearchContextSize string
+	// SearchUserLocation refines search results. Used when Settings.IsSearchEnabled is set.
+	SearchUserLocation *UserLocation
+}
+
+// UserLocation is the approximate location of the user. Country is a two-letter ISO code
+// and Timezone an IANA timezone, e.g. "Asia/Singapore".
+type UserLocation struct {
+	Country  string
+	Region   string
+	City     string
+	Timezone string
+}
+
+func (o Options) ProviderName() string {
+	return providerName
+}
+
+// optionsFromSettings returns the OpenAI options of the settings, ignoring other providers' options.
+// If several are given, later options override earlier ones field by field.
+func optionsFromSettings(settings params.Settings) Options {
+	var result Options
+	for _, providerOptions := range settings.ProviderOptions {
+		var opts Options
+		switch o := providerOptions.(type) {
+		case Options:
+			opts = o
+		case *Options:
+			if o == nil {
+				continue
+			}
+			opts = *o
+		default:
+			continue
+		}
+
+		if opts.ServiceTier != "" {
+			result.ServiceTier = opts.ServiceTier
+		}
+		if opts.Store != nil {
+			result.Store = opts.Store
+		}
+		if opts.Metadata != nil {
+			result.Metadata = opts.Metadata
+		}
+		if opts.Prediction != "" {
+			result.Prediction = opts.Prediction
+		}
+		if opts.ExtraBody != nil {
+			result.ExtraBody = opts.ExtraBody
+		}
+		if opts.SearchContextSize != "" {
+			result.SearchContextSize = opts.SearchContextSize
+		}
+		if opts.SearchUserLocation != nil {
+			result.SearchUserLocation = opts.SearchUserLocation
+		}
+	}
+	return result
+}
+
+func applyOptions(chatParams *openai.ChatCompletionNewParams, opts Options) {
+	chatParams.ServiceTier = opts.ServiceTier
+	if opts.Store != nil {
+		chatParams.Store = openai.Bool(*opts.Store)
+	}
+	if opts.Metadata != nil {
+		chatParams.Metadata = opts.Metadata
+	}
+	if opts.Prediction != "" {
+		chatParams.Prediction = openai.ChatCompletionPredictionContentParam{
+			Content: openai.ChatCompletionPredictionContentContentUnionParam{
+				OfString: openai.String(opts.Prediction),
+			},
+		}
+	}
+	if len(opts.ExtraBody) > 0 {
+		chatParams.SetExtraFields(opts.ExtraBody)
+	}
+}
@@ -0,0 +1,125 @@
+package oai
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+)
+
+// otherOptions belong to another provider and must be ignored
+type otherOptions struct {
+	ServiceTier string
+}
+
+func (o otherOptions) ProviderName() string {
+	return "other"
+}
+
+func TestOptionsFromSettings(t *testing.T) {
+	store := true
+	noStore := false
+
+	tests := []struct {
+		name     string
+		options  []params.ProviderOptions
+		expected Options
+	}{
+		{name: "none"},
+		{
+			name:     "value",
+			options:  []params.ProviderOptions{Options{ServiceTier: openai.ChatCompletionNewParamsServiceTierFlex, Store: &store}},
+			expected: Options{ServiceTier: openai.ChatCompletionNewParamsServiceTierFlex, Store: &store},
+		},
+		{
+			name:     "pointer",
+			options:  []params.ProviderOptions{&Options{Prediction: "draft"}},
+			expected: Options{Prediction: "draft"},
+		},
+		{
+			name:    "nil pointer",
+			options: []params.ProviderOptions{(*Options)(nil)},
+		},
+		{
+			name:     "other provider",
+			options:  []params.ProviderOptions{otherOptions{ServiceTier: "flex"}, Options{Metadata: map[string]string{"team": "search"}}},
+			expected: Options{Metadata: map[string]string{"team": "search"}},
+		},
+		{
+			name: "later options override field by field",
+			options: []params.ProviderOptions{
+				Options{ServiceTier: openai.ChatCompletionNewParamsServiceTierFlex, Store: &store},
+				&Options{Store: &noStore, ExtraBody: map[string]any{"separate_reasoning": true}},
+			},
+			expected: Options{
+				ServiceTier: openai.ChatCompletionNewParamsServiceTierFlex,
+				Store:       &noStore,
+				ExtraBody:   map[string]any{"separate_reasoning": true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := optionsFromSettings(params.Settings{ProviderOptions: test.options})
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestOptionsAreSent(t *testing.T) {
+	store := true
+	tests := []struct {
+		name     string
+		options  params.ProviderOptions
+		expected map[string]any
+	}{
+		{
+			name:     "service tier and store",
+			options:  Options{ServiceTier: openai.ChatCompletionNewParamsServiceTierFlex, Store: &store},
+			expected: map[string]any{"service_tier": "flex", "store": true},
+		},
+		{
+			name:     "metadata",
+			options:  Options{Metadata: map[string]string{"team": "search"}},
+			expected: map[string]any{"metadata": map[string]any{"team": "search"}},
+		},
+		{
+			name:     "prediction",
+			options:  Options{Prediction: "draft"},
+			expected: map[string]any{"prediction": map[string]any{"type": "content", "content": "draft"}},
+		},
+		{
+			name:     "extra body",
+			options:  &Options{ExtraBody: map[string]any{"separate_reasoning": true}},
+			expected: map[string]any{"separate_reasoning": true},
+		},
+		{
+			name:     "other provider",
+			options:  otherOptions{ServiceTier: "flex"},
+			expected: map[string]any{"service_tier": nil, "store": nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var body map[string]any
+			c := newTestClient(t, serveCompletion("Hello", &body))
+
+			settings := params.Settings{ModelName: "gpt-4o", ProviderOptions: []params.ProviderOptions{test.options}}
+			if _, err := c.SendCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hi"), settings); err != nil {
+				t.Fatal(err)
+			}
+			for field, expected := range test.expected {
+				if got := body[field]; !reflect.DeepEqual(got, expected) {
+					t.Errorf("expected %s to be %v, got %v", field, expected, got)
+				}
+			}
+		})
+	}
+}
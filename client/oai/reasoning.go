@@ -0,0 +1,36 @@
+package oai
+
+import (
+	"encoding/json"
+
+	"github.com/openai/openai-go/v3/packages/respjson"
+)
+
+// OpenAI compatible backends return reasoning in fields that aren't part of the OpenAI API.
+// DeepSeek uses reasoning_content, while OpenRouter and others use reasoning.
+var reasoningFields = []string{"reasoning_content", "reasoning"}
+
+func reasoningFromExtraFields(fields map[string]respjson.Field) string {
+	for _, name := range reasoningFields {
+		raw, exists := extraField(fields, name)
+		if !exists {
+			continue
+		}
+
+		var reasoning string
+		if err := json.Unmarshal([]byte(raw), &reasoning); err == nil && reasoning != "" {
+			return reasoning
+		}
+	}
+	return ""
+}
+
+// extraField returns the raw JSON of a field that the SDK doesn't declare. The SDK marks such fields
+// as invalid rather than valid, so only their raw value tells whether they were present.
+func extraField(fields map[string]respjson.Field, name string) (string, bool) {
+	raw := fields[name].Raw()
+	if raw == respjson.Omitted || raw == respjson.Null {
+		return "", false
+	}
+	return raw, true
+}
@@ -0,0 +1,110 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+)
+
+func TestReasoningFromExtraFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected string
+	}{
+		{name: "deepseek", message: `{"role":"assistant","content":"4","reasoning_content":"2+2=4"}`, expected: "2+2=4"},
+		{name: "openrouter", message: `{"role":"assistant","content":"4","reasoning":"2+2=4"}`, expected: "2+2=4"},
+		{name: "first non-empty field", message: `{"role":"assistant","content":"4","reasoning_content":"","reasoning":"2+2=4"}`, expected: "2+2=4"},
+		{name: "null", message: `{"role":"assistant","content":"4","reasoning_content":null}`},
+		{name: "not a string", message: `{"role":"assistant","content":"4","reasoning":{"effort":"low"}}`},
+		{name: "missing", message: `{"role":"assistant","content":"4"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var message openai.ChatCompletionMessage
+			if err := json.Unmarshal([]byte(test.message), &message); err != nil {
+				t.Fatal(err)
+			}
+			if got := reasoningFromExtraFields(message.JSON.ExtraFields); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestSendCompletionMessageIncludesReasoning(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"deepseek-reasoner",`+
+			`"choices":[{"index":0,"message":{"role":"assistant","content":"4","reasoning_content":"2+2=4"},"finish_reason":"stop"}]}`)
+	})
+
+	prompt := params.NewSimplePrompt("", "What is 2+2?")
+	response, err := c.SendCompletionMessage(context.Background(), prompt, params.Settings{ModelName: "deepseek-reasoner", IncludeReasoning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "4" || response.Reasoning != "2+2=4" {
+		t.Errorf("expected the answer and reasoning separately, got %+v", response)
+	}
+
+	response, err = c.SendCompletionMessage(context.Background(), prompt, params.Settings{ModelName: "deepseek-reasoner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Reasoning != "" {
+		t.Errorf("expected no reasoning unless requested, got %q", response.Reasoning)
+	}
+}
+
+func TestStreamCompletionMessageIncludesReasoning(t *testing.T) {
+	delta := func(field string, text string) string {
+		return fmt.Sprintf(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1,"model":"deepseek-reasoner",`+
+			`"choices":[{"index":0,"delta":{%q:%q}}]}`, field, text)
+	}
+	events := []string{
+		delta("reasoning_content", "2+2"),
+		delta("reasoning_content", "=4"),
+		delta("content", "4"),
+	}
+
+	for _, includeReasoning := range []bool{true, false} {
+		t.Run(fmt.Sprint(includeReasoning), func(t *testing.T) {
+			c := newTestClient(t, serveEvents(events...))
+			chunks, err := c.StreamCompletionMessage(context.Background(),
+				params.NewSimplePrompt("", "What is 2+2?"),
+				params.Settings{ModelName: "deepseek-reasoner", IncludeReasoning: includeReasoning})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var content, reasoning strings.Builder
+			for chunk := range chunks {
+				if chunk.Error != nil {
+					t.Fatal(chunk.Error)
+				}
+				if chunk.Kind == params.StreamChunkKindReasoning {
+					reasoning.WriteString(chunk.Content)
+				} else {
+					content.WriteString(chunk.Content)
+				}
+			}
+
+			expectedReasoning := ""
+			if includeReasoning {
+				expectedReasoning = "2+2=4"
+			}
+			if content.String() != "4" || reasoning.String() != expectedReasoning {
+				t.Errorf("expected answer 4 and reasoning %q, got %q and %q", expectedReasoning, content.String(), reasoning.String())
+			}
+		})
+	}
+}
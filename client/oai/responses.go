@@ -0,0 +1,350 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+	"github.com/jamesleeht/llm-gopher/structured"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/responses"
+	"github.com/openai/openai-go/v3/shared"
+)
+
+// validateResponsesSettings rejects sampling parameters that the Responses API doesn't have
+func validateResponsesSettings(settings params.Settings) error {
+	switch {
+	case settings.Seed != nil:
+		return errors.New("seed is not supported by the Responses API")
+	case len(settings.StopSequences) > 0:
+		return errors.New("stop sequences are not supported by the Responses API")
+	case settings.PresencePenalty != nil, settings.FrequencyPenalty != nil:
+		return errors.New("presence and frequency penalties are not supported by the Responses API")
+	case settings.CandidateCount != nil && *settings.CandidateCount > 1:
+		return errors.New("multiple candidates are not supported by the Responses API")
+	case len(settings.LogitBias) > 0:
+		return errors.New("logit bias is not supported by the Responses API")
+	case optionsFromSettings(settings).Prediction != "":
+		return errors.New("predicted outputs are not supported by the Responses API")
+	}
+	return nil
+}
+
+func (c *Client) sendResponse(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	responseParams, err := c.mapPromptToResponseParams(prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.internalClient.Responses.New(ctx, responseParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create response: %w", c.providerError(err))
+	}
+	if err := responseError(resp); err != nil {
+		return nil, err
+	}
+
+	response := mapResponse(resp, settings)
+
+	// If response format is specified, validate the reply and unmarshal into that type
+	if prompt.ResponseFormat != nil {
+		if err := structured.Parse(response.Content, prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+
+	return response, nil
+}
+
+func (c *Client) streamResponse(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
+	responseParams, err := c.mapPromptToResponseParams(prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+
+	stream := c.internalClient.Responses.NewStreaming(ctx, responseParams)
+
+	chunks := make(chan params.StreamChunk)
+
+	go func() {
+		defer close(chunks)
+
+		var final *params.Response
+		for stream.Next() {
+			event := stream.Current()
+
+			switch event.Type {
+			case "response.reasoning_summary_text.delta":
+				if settings.IncludeReasoning && event.Delta != "" {
+					chunks <- params.StreamChunk{
+						Kind:    params.StreamChunkKindReasoning,
+						Content: event.Delta,
+						Done:    false,
+						Error:   nil,
+					}
+				}
+			case "response.output_text.delta":
+				if event.Delta != "" {
+					chunks <- params.StreamChunk{
+						Content: event.Delta,
+						Done:    false,
+						Error:   nil,
+					}
+				}
+			case "response.completed", "response.incomplete", "response.failed":
+				if err := responseError(&event.Response); err != nil {
+					chunks <- params.StreamChunk{
+						Content: "",
+						Done:    true,
+						Error:   err,
+					}
+					return
+				}
+				final = mapResponse(&event.Response, settings)
+			case "error":
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Errorf("streaming error: %s", event.Message),
+				}
+				return
+			}
+		}
+
+		if err := stream.Err(); err != nil {
+			chunks <- params.StreamChunk{
+				Content: "",
+				Done:    true,
+				Error:   fmt.Errorf("streaming error: %w", c.providerError(err)),
+			}
+			return
+		}
+
+		// Send final chunk to indicate completion
+		finalChunk := params.StreamChunk{
+			Content: "",
+			Done:    true,
+			Error:   nil,
+		}
+		if final != nil {
+			finalChunk.Grounding = final.Grounding
+			finalChunk.ResponseID = final.ResponseID
+		}
+		chunks <- finalChunk
+	}()
+
+	return chunks, nil
+}
+
+func (c *Client) mapPromptToResponseParams(prompt params.Prompt, settings params.Settings) (responses.ResponseNewParams, error) {
+	if err := validateSettings(settings); err != nil {
+		return responses.ResponseNewParams{}, err
+	}
+	if err := validateResponsesSettings(settings); err != nil {
+		return responses.ResponseNewParams{}, err
+	}
+	if len(prompt.Documents) > 0 {
+		return responses.ResponseNewParams{}, errors.New("documents are not supported by OpenAI")
+	}
+
+	responseParams := responses.ResponseNewParams{
+		Model:           shared.ResponsesModel(settings.ModelName),
+		MaxOutputTokens: openai.Int(int64(getMaxOutputTokens(settings))),
+	}
+	responseParams.Reasoning.Effort = getReasoningEffort(settings)
+	if settings.IncludeReasoning {
+		// OpenAI doesn't return raw reasoning, only a summary of it
+		responseParams.Reasoning.Summary = shared.ReasoningSummaryAuto
+	}
+	if settings.Temperature != nil {
+		responseParams.Temperature = openai.Float(*settings.Temperature)
+	}
+	if settings.TopP != nil {
+		responseParams.TopP = openai.Float(*settings.TopP)
+	}
+	if settings.Logprobs {
+		responseParams.Include = append(responseParams.Include, responses.ResponseIncludableMessageOutputTextLogprobs)
+	}
+	if settings.TopLogprobs != nil {
+		responseParams.TopLogprobs = openai.Int(int64(*settings.TopLogprobs))
+	}
+
+	opts := optionsFromSettings(settings)
+	responseParams.ServiceTier = responses.ResponseNewParamsServiceTier(opts.ServiceTier)
+	if opts.Store != nil {
+		responseParams.Store = openai.Bool(*opts.Store)
+	}
+	if opts.Metadata != nil {
+		responseParams.Metadata = opts.Metadata
+	}
+	if len(opts.ExtraBody) > 0 {
+		responseParams.SetExtraFields(opts.ExtraBody)
+	}
+	if settings.IsSearchEnabled {
+		responseParams.Tools = append(responseParams.Tools, mapWebSearchTool(opts))
+	}
+
+	switch c.structuredOutputMode {
+	case params.StructuredOutputJSONObject, params.StructuredOutputNone:
+		// Describe the schema in the instructions instead, the reply is still validated against it
+		if prompt.ResponseFormat != nil {
+			var err error
+			if prompt, err = structured.WithSchemaInstruction(prompt); err != nil {
+				return responses.ResponseNewParams{}, err
+			}
+			if c.structuredOutputMode == params.StructuredOutputJSONObject {
+				responseParams.Text.Format = responses.ResponseFormatTextConfigUnionParam{
+					OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+				}
+			}
+		}
+	default:
+		format, err := mapPromptToResponseTextFormat(prompt)
+		if err != nil {
+			return responses.ResponseNewParams{}, err
+		}
+		if format != nil {
+			responseParams.Text.Format = *format
+		}
+	}
+
+	responseParams.Instructions = optString(prompt.SystemMessage)
+	responseParams.PreviousResponseID = optString(prompt.PreviousResponseID)
+	responseParams.Input = responses.ResponseNewParamsInputUnion{
+		OfInputItemList: mapPromptToInputItems(prompt),
+	}
+	return responseParams, nil
+}
+
+func mapPromptToInputItems(prompt params.Prompt) responses.ResponseInputParam {
+	items := responses.ResponseInputParam{}
+	for _, message := range prompt.Messages {
+		role := responses.EasyInputMessageRoleUser
+		if message.Role == params.MessageRoleAssistant {
+			role = responses.EasyInputMessageRoleAssistant
+		}
+		items = append(items, responses.ResponseInputItemParamOfMessage(message.Content, role))
+	}
+	return items
+}
+
+func mapPromptToResponseTextFormat(prompt params.Prompt) (*responses.ResponseFormatTextConfigUnionParam, error) {
+	if prompt.ResponseFormat == nil {
+		return nil, nil
+	}
+
+	responseType := reflect.TypeOf(prompt.ResponseFormat)
+	responseSchema, strict, err := schema.ForOpenAI(responseType)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Responses API takes the schema as a plain map
+	schemaJSON, err := json.Marshal(responseSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal response schema: %w", err)
+	}
+	var schemaMap map[string]any
+	if err := json.Unmarshal(schemaJSON, &schemaMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response schema: %w", err)
+	}
+
+	return &responses.ResponseFormatTextConfigUnionParam{
+		OfJSONSchema: &responses.ResponseFormatTextJSONSchemaConfigParam{
+			Name:   schema.Name(responseType),
+			Schema: schemaMap,
+			Strict: openai.Bool(strict),
+		},
+	}, nil
+}
+
+// responseError returns an error if the response failed or was cut off by the content filter
+func responseError(resp *responses.Response) error {
+	if resp.IncompleteDetails.Reason == contentFilterFinishReason {
+		return &params.ContentFilterError{Reason: contentFilterFinishReason}
+	}
+	if resp.Error.Message != "" {
+		return fmt.Errorf("response failed: %s: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return nil
+}
+
+// mapResponse collects the reply text, reasoning summary, citations, search queries and logprobs
+// from the output items
+func mapResponse(resp *responses.Response, settings params.Settings) *params.Response {
+	var content strings.Builder
+	var reasoning strings.Builder
+	var grounding groundingBuilder
+	var logprobs []params.TokenLogprob
+
+	for _, item := range resp.Output {
+		switch item.Type {
+		case "reasoning":
+			for _, summary := range item.Summary {
+				if reasoning.Len() > 0 {
+					reasoning.WriteString("\n\n")
+				}
+				reasoning.WriteString(summary.Text)
+			}
+		case "web_search_call":
+			if item.Action.Query != "" {
+				grounding.addSearchQuery(item.Action.Query)
+			}
+		case "message":
+			for _, part := range item.Content {
+				if part.Type != "output_text" {
+					continue
+				}
+
+				offset := content.Len()
+				content.WriteString(part.Text)
+				for _, annotation := range part.Annotations {
+					if annotation.Type == "url_citation" {
+						grounding.addCitation(annotation.URL, annotation.Title,
+							int(annotation.StartIndex), int(annotation.EndIndex), part.Text, offset)
+					}
+				}
+				if settings.Logprobs {
+					logprobs = append(logprobs, mapOutputTextLogprobs(part.Logprobs)...)
+				}
+			}
+		}
+	}
+
+	response := &params.Response{
+		ResponseID: resp.ID,
+		Content:    content.String(),
+		Parsed:     nil,
+		Logprobs:   logprobs,
+		Grounding:  grounding.grounding,
+	}
+	if settings.IncludeReasoning {
+		response.Reasoning = reasoning.String()
+	}
+	return response
+}
+
+func mapOutputTextLogprobs(logprobs []responses.ResponseOutputTextLogprob) []params.TokenLogprob {
+	result := make([]params.TokenLogprob, 0, len(logprobs))
+	for _, logprob := range logprobs {
+		tokenLogprob := params.TokenLogprob{
+			Token:   logprob.Token,
+			Logprob: logprob.Logprob,
+		}
+		for _, top := range logprob.TopLogprobs {
+			tokenLogprob.TopLogprobs = append(tokenLogprob.TopLogprobs, params.TopLogprob{
+				Token:   top.Token,
+				Logprob: top.Logprob,
+			})
+		}
+		result = append(result, tokenLogprob)
+	}
+	return result
+}
@@ -0,0 +1,189 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// newTestResponsesClient returns a Responses API client for a local server that answers every request with handler
+func newTestResponsesClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewOpenAIClient(ClientConfig{APIKey: "test", BaseURL: server.URL, UseResponsesAPI: true})
+}
+
+// searchResponse has a reasoning summary, a web search and a reply with a citation of "Zürich"
+const searchResponse = `{"id":"resp_2","object":"response","created_at":1,"model":"gpt-4o","status":"completed",` +
+	`"output":[` +
+	`{"type":"reasoning","id":"rs_1","summary":[{"type":"summary_text","text":"Looking it up."}]},` +
+	`{"type":"web_search_call","id":"ws_1","status":"completed","action":{"type":"search","query":"zürich population"}},` +
+	`{"type":"message","id":"msg_1","role":"assistant","status":"completed","content":[` +
+	`{"type":"output_text","text":"{\"name\":\"Zürich\",\"population\":440000}","annotations":[` +
+	`{"type":"url_citation","url":"https://example.com/zurich","title":"Zürich","start_index":9,"end_index":15}]}]}]}`
+
+func TestSendResponse(t *testing.T) {
+	var path string
+	var body map[string]any
+	c := newTestResponsesClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, searchResponse)
+	})
+
+	prompt := params.NewSimplePrompt("Answer in JSON.", "How many people live in Zürich?")
+	prompt.PreviousResponseID = "resp_1"
+	prompt.ResponseFormat = &city{}
+	temperature := 0.2
+	response, err := c.SendCompletionMessage(context.Background(), prompt, params.Settings{
+		ModelName:        "gpt-4o",
+		Temperature:      &temperature,
+		IncludeReasoning: true,
+		IsSearchEnabled:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "/responses" {
+		t.Errorf("expected the Responses API to be called, got %s", path)
+	}
+	if body["model"] != "gpt-4o" || body["instructions"] != "Answer in JSON." || body["previous_response_id"] != "resp_1" {
+		t.Errorf("expected the model, instructions and previous response id to be sent, got %v", body)
+	}
+	if body["temperature"] != 0.2 {
+		t.Errorf("expected the temperature to be sent, got %v", body["temperature"])
+	}
+	input, _ := body["input"].([]any)
+	if len(input) != 1 || input[0].(map[string]any)["content"] != "How many people live in Zürich?" {
+		t.Errorf("expected the user message as the only input item, got %v", body["input"])
+	}
+	if reasoning, _ := body["reasoning"].(map[string]any); reasoning["summary"] != "auto" {
+		t.Errorf("expected a reasoning summary to be requested, got %v", body["reasoning"])
+	}
+	if tools, _ := body["tools"].([]any); len(tools) != 1 || tools[0].(map[string]any)["type"] != "web_search" {
+		t.Errorf("expected the web_search tool, got %v", body["tools"])
+	}
+	text, _ := body["text"].(map[string]any)
+	if format, _ := text["format"].(map[string]any); format["type"] != "json_schema" || format["schema"] == nil {
+		t.Errorf("expected a JSON schema text format, got %v", body["text"])
+	}
+
+	if response.ResponseID != "resp_2" {
+		t.Errorf("expected the response id, got %q", response.ResponseID)
+	}
+	if response.Reasoning != "Looking it up." {
+		t.Errorf("expected the reasoning summary, got %q", response.Reasoning)
+	}
+	if parsed, ok := response.Parsed.(*city); !ok || parsed.Name != "Zürich" || parsed.Population != 440000 {
+		t.Errorf("expected the reply to be parsed, got %+v", response.Parsed)
+	}
+
+	grounding := response.Grounding
+	if grounding == nil || len(grounding.SearchQueries) != 1 || len(grounding.Citations) != 1 {
+		t.Fatalf("expected a search query and a citation, got %+v", grounding)
+	}
+	if citation := grounding.Citations[0]; citation.Text != "Zürich" || response.Content[citation.StartIndex:citation.EndIndex] != "Zürich" {
+		t.Errorf("expected the citation to cover Zürich, got %+v", citation)
+	}
+}
+
+func TestStreamResponse(t *testing.T) {
+	c := newTestResponsesClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"type":"response.reasoning_summary_text.delta","item_id":"rs_1","output_index":0,"summary_index":0,"sequence_number":1,"delta":"Looking it up."}`,
+			`{"type":"response.output_text.delta","item_id":"msg_1","output_index":2,"content_index":0,"sequence_number":2,"delta":"{\"name\":\"Zürich\","}`,
+			`{"type":"response.output_text.delta","item_id":"msg_1","output_index":2,"content_index":0,"sequence_number":3,"delta":"\"population\":440000}"}`,
+			`{"type":"response.completed","sequence_number":4,"response":` + searchResponse + `}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+	})
+
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "How many people live in Zürich?"),
+		params.Settings{ModelName: "gpt-4o", IncludeReasoning: true, IsSearchEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reasoning string
+	var content string
+	var final params.StreamChunk
+	for chunk := range chunks {
+		switch {
+		case chunk.Done:
+			final = chunk
+		case chunk.Kind == params.StreamChunkKindReasoning:
+			reasoning += chunk.Content
+		default:
+			content += chunk.Content
+		}
+	}
+
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if reasoning != "Looking it up." || content != `{"name":"Zürich","population":440000}` {
+		t.Errorf("expected the reasoning and reply deltas, got %q and %q", reasoning, content)
+	}
+	if final.ResponseID != "resp_2" || final.Grounding == nil || len(final.Grounding.Citations) != 1 {
+		t.Errorf("expected the final chunk to carry the response id and grounding, got %+v", final)
+	}
+}
+
+func TestStreamResponseReturnsContentFilterError(t *testing.T) {
+	c := newTestResponsesClient(t, serveEvents(
+		`{"type":"response.output_text.delta","item_id":"msg_1","output_index":0,"content_index":0,"sequence_number":1,"delta":"Sure"}`,
+		`{"type":"response.incomplete","sequence_number":2,"response":{"id":"resp_1","object":"response","created_at":1,`+
+			`"model":"gpt-4o","status":"incomplete","incomplete_details":{"reason":"content_filter"},"output":[]}}`,
+	))
+
+	chunks, err := c.StreamCompletionMessage(context.Background(), params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, final := collect(t, chunks)
+	var filterErr *params.ContentFilterError
+	if !errors.As(final.Error, &filterErr) {
+		t.Errorf("expected a content filter error, got %v", final.Error)
+	}
+}
+
+func TestValidateResponsesSettings(t *testing.T) {
+	seed := 1
+	candidates := 2
+	tests := map[string]params.Settings{
+		"seed":       {Seed: &seed},
+		"stop":       {StopSequences: []string{"\n"}},
+		"candidates": {CandidateCount: &candidates},
+		"logit bias": {LogitBias: map[string]int{"50256": -100}},
+		"prediction": {ProviderOptions: []params.ProviderOptions{Options{Prediction: "func main() {}"}}},
+	}
+
+	c := NewOpenAIClient(ClientConfig{APIKey: "test", UseResponsesAPI: true})
+	for name, settings := range tests {
+		settings.ModelName = "gpt-4o"
+		if err := c.ValidateSettings(settings); err == nil {
+			t.Errorf("%s: expected the Responses API client to reject it", name)
+		}
+	}
+
+	// Chat Completions takes all of them
+	chat := NewOpenAIClient(ClientConfig{APIKey: "test"})
+	if err := chat.ValidateSettings(tests["prediction"]); err != nil {
+		t.Errorf("expected Chat Completions to accept a prediction, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package oai
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"github.com/openai/openai-go/v3"
+	"github.com/openai/openai-go/v3/packages/param"
+	"github.com/openai/openai-go/v3/responses"
+)
+
+type searchMode int
+
+const (
+	searchModeNone searchMode = iota
+	// searchModeChat uses web_search_options, which only the search models accept
+	searchModeChat
+	// searchModeResponses sends the request through the Responses API with the web_search tool
+	searchModeResponses
+)
+
+// webSearchToolModels are the model families that can use the Responses API web_search tool
+var webSearchToolModels = []string{"gpt-4o", "gpt-4.1", "gpt-5", "o3", "o4-mini"}
+
+// webSearchToolExcludedModels are members of those families that can't
+var webSearchToolExcludedModels = []string{"gpt-4.1-nano"}
+
+var searchContextSizes = map[string]bool{"low": true, "medium": true, "high": true}
+
+func isChatSearchModel(modelName string) bool {
+	return strings.Contains(modelName, "search-preview") || strings.Contains(modelName, "search-api")
+}
+
+func supportsWebSearchTool(modelName string) bool {
+	for _, prefix := range webSearchToolExcludedModels {
+		if strings.HasPrefix(modelName, prefix) {
+			return false
+		}
+	}
+	for _, prefix := range webSearchToolModels {
+		if strings.HasPrefix(modelName, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// searchMode decides how a search request is sent. Only OpenAI itself has the Responses API,
+// so other OpenAI compatible backends can only search with their own search models.
+func (c *Client) searchMode(settings params.Settings) (searchMode, error) {
+	if !settings.IsSearchEnabled {
+		return searchModeNone, nil
+	}
+
+	if isChatSearchModel(settings.ModelName) && !c.useResponsesAPI {
+		return searchModeChat, nil
+	}
+	if (c.isOpenAI || c.useResponsesAPI) && supportsWebSearchTool(settings.ModelName) {
+		return searchModeResponses, nil
+	}
+	return searchModeNone, fmt.Errorf("model %s does not support web search", settings.ModelName)
+}
+
+func validateSearchOptions(opts Options) error {
+	if opts.SearchContextSize != "" && !searchContextSizes[opts.SearchContextSize] {
+		return fmt.Errorf("unknown search context size %q, expected low, medium or high", opts.SearchContextSize)
+	}
+	return nil
+}
+
+func mapSearchOptions(opts Options) openai.ChatCompletionNewParamsWebSearchOptions {
+	webSearchOptions := openai.ChatCompletionNewParamsWebSearchOptions{
+		SearchContextSize: opts.SearchContextSize,
+	}
+	if location := opts.SearchUserLocation; location != nil {
+		webSearchOptions.UserLocation = openai.ChatCompletionNewParamsWebSearchOptionsUserLocation{
+			Approximate: openai.ChatCompletionNewParamsWebSearchOptionsUserLocationApproximate{
+				Country:  optString(location.Country),
+				Region:   optString(location.Region),
+				City:     optString(location.City),
+				Timezone: optString(location.Timezone),
+			},
+		}
+	}
+	return webSearchOptions
+}
+
+func mapWebSearchTool(opts Options) responses.ToolUnionParam {
+	tool := &responses.WebSearchToolParam{
+		Type:              responses.WebSearchToolTypeWebSearch,
+		SearchContextSize: responses.WebSearchToolSearchContextSize(opts.SearchContextSize),
+	}
+	if location := opts.SearchUserLocation; location != nil {
+		tool.UserLocation = responses.WebSearchToolUserLocationParam{
+			Type:     "approximate",
+			Country:  optString(location.Country),
+			Region:   optString(location.Region),
+			City:     optString(location.City),
+			Timezone: optString(location.Timezone),
+		}
+	}
+	return responses.ToolUnionParam{OfWebSearch: tool}
+}
+
+// optString leaves empty strings out of the request
+func optString(s string) param.Opt[string] {
+	if s == "" {
+		return param.Opt[string]{}
+	}
+	return openai.String(s)
+}
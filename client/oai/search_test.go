@@ -0,0 +1,102 @@
+package oai
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+func TestSearchMode(t *testing.T) {
+	tests := []struct {
+		model           string
+		isOpenAI        bool
+		useResponsesAPI bool
+		expected        searchMode
+		wantErr         bool
+	}{
+		{model: "gpt-4o-search-preview", isOpenAI: true, expected: searchModeChat},
+		{model: "gpt-4o-search-preview", isOpenAI: false, expected: searchModeChat},
+		{model: "gpt-5-search-api", isOpenAI: true, expected: searchModeChat},
+		{model: "gpt-4o", isOpenAI: true, expected: searchModeResponses},
+		{model: "gpt-4.1-mini", isOpenAI: true, expected: searchModeResponses},
+		{model: "o4-mini", isOpenAI: true, expected: searchModeResponses},
+		{model: "gpt-4o", isOpenAI: false, wantErr: true},
+		{model: "gpt-4.1-nano", isOpenAI: true, wantErr: true},
+		{model: "gpt-3.5-turbo", isOpenAI: true, wantErr: true},
+		{model: "llama-3.1-70b", isOpenAI: false, wantErr: true},
+		// A Responses API client always searches with the web_search tool, even on another base URL
+		{model: "gpt-4o", isOpenAI: true, useResponsesAPI: true, expected: searchModeResponses},
+		{model: "gpt-4o", isOpenAI: false, useResponsesAPI: true, expected: searchModeResponses},
+		{model: "gpt-4o-search-preview", isOpenAI: true, useResponsesAPI: true, expected: searchModeResponses},
+		{model: "gpt-5-search-api", isOpenAI: true, useResponsesAPI: true, expected: searchModeResponses},
+		{model: "gpt-4.1-nano", isOpenAI: true, useResponsesAPI: true, wantErr: true},
+		{model: "llama-3.1-70b", isOpenAI: false, useResponsesAPI: true, wantErr: true},
+	}
+
+	for _, test := range tests {
+		c := &Client{isOpenAI: test.isOpenAI, useResponsesAPI: test.useResponsesAPI}
+		name := fmt.Sprintf("%s (openai %v, responses %v)", test.model, test.isOpenAI, test.useResponsesAPI)
+
+		mode, err := c.searchMode(params.Settings{ModelName: test.model, IsSearchEnabled: true})
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got mode %d", name, mode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if mode != test.expected {
+			t.Errorf("%s: expected mode %d, got %d", name, test.expected, mode)
+		}
+
+		// Without search every model is sent as a plain request
+		if mode, err := c.searchMode(params.Settings{ModelName: test.model}); err != nil || mode != searchModeNone {
+			t.Errorf("%s: expected no search mode, got %d and %v", name, mode, err)
+		}
+	}
+}
+
+func TestMapSearchOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{name: "empty", expected: `{}`},
+		{name: "context size", opts: Options{SearchContextSize: "high"}, expected: `{"search_context_size":"high"}`},
+		{
+			name: "location",
+			opts: Options{SearchUserLocation: &UserLocation{Country: "SG", City: "Singapore", Timezone: "Asia/Singapore"}},
+			expected: `{"user_location":{"approximate":{"city":"Singapore","country":"SG","timezone":"Asia/Singapore"},` +
+				`"type":"approximate"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(mapSearchOptions(test.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, data)
+			}
+		})
+	}
+}
+
+func TestValidateSearchOptions(t *testing.T) {
+	for _, size := range []string{"", "low", "medium", "high"} {
+		if err := validateSearchOptions(Options{SearchContextSize: size}); err != nil {
+			t.Errorf("expected %q to be accepted, got %v", size, err)
+		}
+	}
+	if err := validateSearchOptions(Options{SearchContextSize: "huge"}); err == nil {
+		t.Error("expected an unknown context size to be rejected")
+	}
+}
@@ -0,0 +1,59 @@
+package oai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+	"github.com/jamesleeht/llm-gopher/tokenizer"
+)
+
+// Every message is wrapped in a few formatting tokens, and the reply is primed with a few more
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+)
+
+// CountTokens estimates the prompt size locally with the model's BPE encoding.
+// Models that aren't from OpenAI are approximated with cl100k_base.
+func (c *Client) CountTokens(ctx context.Context, prompt params.Prompt, settings params.Settings) (int, error) {
+	texts := []string{}
+	if prompt.SystemMessage != "" {
+		texts = append(texts, string(params.MessageRoleSystem), prompt.SystemMessage)
+	}
+	for _, message := range prompt.Messages {
+		texts = append(texts, string(message.Role), message.Content)
+	}
+
+	if prompt.ResponseFormat != nil {
+		responseSchema, _, err := schema.ForOpenAI(reflect.TypeOf(prompt.ResponseFormat))
+		if err != nil {
+			return 0, err
+		}
+		data, err := json.Marshal(responseSchema)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal response format schema: %w", err)
+		}
+		texts = append(texts, string(data))
+	}
+
+	total := tokensPerReply
+	for _, text := range texts {
+		count, err := tokenizer.Count(settings.ModelName, text)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		total += count
+	}
+
+	messageCount := len(prompt.Messages)
+	if prompt.SystemMessage != "" {
+		messageCount++
+	}
+	total += messageCount * tokensPerMessage
+
+	return total, nil
+}
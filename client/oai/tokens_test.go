@@ -0,0 +1,44 @@
+package oai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+func TestCountTokens(t *testing.T) {
+	c := NewOpenAIClient(ClientConfig{APIKey: "test"})
+
+	// "system", "Be brief." (3), "user" and "Hello, world!" (4), plus formatting for two messages and the reply
+	count, err := c.CountTokens(context.Background(), params.NewSimplePrompt("Be brief.", "Hello, world!"), params.Settings{ModelName: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := 1 + 3 + 1 + 4 + 2*tokensPerMessage + tokensPerReply; count != expected {
+		t.Errorf("expected %d tokens, got %d", expected, count)
+	}
+
+	// The response format's schema is sent with the prompt, so it is counted too
+	prompt := params.NewSimplePrompt("Be brief.", "Hello, world!")
+	prompt.ResponseFormat = &struct {
+		Greeting string `json:"greeting"`
+	}{}
+	withSchema, err := c.CountTokens(context.Background(), prompt, params.Settings{ModelName: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withSchema <= count {
+		t.Errorf("expected the schema to add tokens, got %d and %d", withSchema, count)
+	}
+}
+
+func TestCountTokensReturnsSchemaError(t *testing.T) {
+	c := NewOpenAIClient(ClientConfig{APIKey: "test"})
+
+	prompt := params.NewSimplePrompt("Be brief.", "List three colors.")
+	prompt.ResponseFormat = &[]string{}
+	if _, err := c.CountTokens(context.Background(), prompt, params.Settings{ModelName: "gpt-4o"}); err == nil {
+		t.Error("expected an error for a response format OpenAI can't use")
+	}
+}
@@ -0,0 +1,222 @@
+package ollama
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+const defaultBaseURL = "http://localhost:11434"
+
+type ClientConfig struct {
+	// BaseURL defaults to http://localhost:11434
+	BaseURL string `json:"base_url,omitempty"`
+	// StructuredOutputMode defaults to native JSON schema support through the format field
+	StructuredOutputMode params.StructuredOutputMode `json:"structured_output_mode,omitempty"`
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client `json:"-"`
+}
+
+// Client talks to Ollama's native API, which exposes more settings than its OpenAI compatible one
+type Client struct {
+	baseURL              string
+	httpClient           *http.Client
+	structuredOutputMode params.StructuredOutputMode
+}
+
+func NewOllamaClient(config ClientConfig) *Client {
+	baseURL := strings.TrimSuffix(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:              baseURL,
+		httpClient:           httpClient,
+		structuredOutputMode: config.StructuredOutputMode,
+	}
+}
+
+// ValidateSettings reports settings that this client cannot send, so presets can be rejected up front
+func (c *Client) ValidateSettings(settings params.Settings) error {
+	return validateSettings(settings)
+}
+
+func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	request, err := c.mapPromptToRequest(prompt, settings, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/api/chat", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var chat chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("failed to decode chat response: %w", err)
+	}
+
+	response := &params.Response{
+		Content: chat.Message.Content,
+		Parsed:  nil,
+	}
+	if settings.IncludeReasoning {
+		response.Reasoning = chat.Message.Thinking
+	}
+
+	// If response format is specified, validate the reply and unmarshal into that type
+	if prompt.ResponseFormat != nil {
+		if err := structured.Parse(response.Content, prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+
+	return response, nil
+}
+
+func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
+	request, err := c.mapPromptToRequest(prompt, settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post(ctx, "/api/chat", request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send chat message: %w", err)
+	}
+
+	chunks := make(chan params.StreamChunk)
+
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		// The reply is streamed as one JSON object per line
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+
+			var chat chatResponse
+			if err := json.Unmarshal(line, &chat); err != nil {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Errorf("failed to decode stream line: %w", err),
+				}
+				return
+			}
+			if chat.Error != "" {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   fmt.Errorf("streaming error: %s", chat.Error),
+				}
+				return
+			}
+
+			if settings.IncludeReasoning && chat.Message.Thinking != "" {
+				chunks <- params.StreamChunk{
+					Kind:    params.StreamChunkKindReasoning,
+					Content: chat.Message.Thinking,
+					Done:    false,
+					Error:   nil,
+				}
+			}
+			if chat.Message.Content != "" {
+				chunks <- params.StreamChunk{
+					Content: chat.Message.Content,
+					Done:    false,
+					Error:   nil,
+				}
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			chunks <- params.StreamChunk{
+				Content: "",
+				Done:    true,
+				Error:   fmt.Errorf("streaming error: %w", err),
+			}
+			return
+		}
+
+		// Send final chunk to indicate completion
+		chunks <- params.StreamChunk{
+			Content: "",
+			Done:    true,
+			Error:   nil,
+		}
+	}()
+
+	return chunks, nil
+}
+
+// post sends body as JSON and returns the response if it succeeded. The caller closes the body.
+func (c *Client) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.do(req)
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, providerError(resp)
+	}
+	return resp, nil
+}
+
+// providerError normalizes an error reply, which carries an error string
+func providerError(resp *http.Response) *params.ProviderError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	providerErr := &params.ProviderError{
+		Provider:   providerName,
+		Kind:       params.ErrorKindFromStatus(resp.StatusCode),
+		StatusCode: resp.StatusCode,
+		Message:    strings.TrimSpace(string(body)),
+	}
+
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		providerErr.Message = apiErr.Error
+	}
+	return providerErr
+}
@@ -0,0 +1,215 @@
+package ollama
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// newTestClient returns a client for a local server that answers every request with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewOllamaClient(ClientConfig{BaseURL: server.URL})
+}
+
+// serveLines answers with one JSON object per line, flushing after each like Ollama does
+func serveLines(lines ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func TestValidateSettingsRejectsSafety(t *testing.T) {
+	c := NewOllamaClient(ClientConfig{})
+	err := c.ValidateSettings(params.Settings{
+		ModelName: "qwen3:8b",
+		Safety:    &params.SafetySettings{Default: params.SafetyThresholdBlockLowAndAbove},
+	})
+	if err == nil {
+		t.Error("expected safety settings to be rejected")
+	}
+}
+
+func TestStreamCompletionMessage(t *testing.T) {
+	var request chatRequest
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+		serveLines(
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":"","thinking":"Hmm"},"done":false}`,
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":"Hello"},"done":false}`,
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":" world"},"done":false}`,
+			`{"model":"qwen3:8b","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`,
+		)(w, r)
+	})
+
+	thinkingTokens := 1024
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("Be brief.", "Greet me"),
+		params.Settings{ModelName: "qwen3:8b", IncludeReasoning: true, ThinkingTokens: &thinkingTokens})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reasoning strings.Builder
+	var content strings.Builder
+	var final params.StreamChunk
+	for chunk := range chunks {
+		switch {
+		case chunk.Done:
+			final = chunk
+		case chunk.Kind == params.StreamChunkKindReasoning:
+			reasoning.WriteString(chunk.Content)
+		default:
+			content.WriteString(chunk.Content)
+		}
+	}
+
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content.String() != "Hello world" || reasoning.String() != "Hmm" {
+		t.Errorf("expected content and reasoning, got %q and %q", content.String(), reasoning.String())
+	}
+	if !request.Stream || request.Think == nil || !*request.Think || len(request.Messages) != 2 {
+		t.Errorf("unexpected request %+v", request)
+	}
+}
+
+func TestStreamCompletionMessageError(t *testing.T) {
+	c := newTestClient(t, serveLines(`{"error":"model ran out of memory"}`))
+
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "qwen3:8b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var final params.StreamChunk
+	for chunk := range chunks {
+		final = chunk
+	}
+	if final.Error == nil || !strings.Contains(final.Error.Error(), "out of memory") {
+		t.Errorf("expected the stream error, got %v", final.Error)
+	}
+}
+
+func TestProviderError(t *testing.T) {
+	tests := []struct {
+		status  int
+		body    string
+		kind    params.ErrorKind
+		message string
+	}{
+		{400, `{"error":"invalid options"}`, params.ErrorKindInvalidRequest, "invalid options"},
+		{404, `{"error":"model \"qwen9\" not found, try pulling it first"}`, params.ErrorKindNotFound, `model "qwen9" not found, try pulling it first`},
+		{500, `{"error":"llama runner process has terminated"}`, params.ErrorKindServer, "llama runner process has terminated"},
+		{502, `Bad Gateway`, params.ErrorKindServer, "Bad Gateway"},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := c.SendCompletionMessage(context.Background(),
+				params.NewSimplePrompt("", "Hi"), params.Settings{ModelName: "qwen9"})
+			var providerErr *params.ProviderError
+			if !errors.As(err, &providerErr) {
+				t.Fatalf("expected a provider error, got %v", err)
+			}
+			if providerErr.Provider != providerName || providerErr.StatusCode != tt.status || providerErr.Kind != tt.kind {
+				t.Errorf("expected %s status %d, got %+v", tt.kind, tt.status, providerErr)
+			}
+			if providerErr.Message != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, providerErr.Message)
+			}
+
+			if _, err := c.ListModels(context.Background()); !errors.As(err, &providerErr) {
+				t.Errorf("expected a provider error from ListModels, got %v", err)
+			}
+		})
+	}
+}
+
+func TestListModels(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/tags" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"models":[{"name":"qwen3:8b","size":5225376047,"digest":"abc",`+
+			`"modified_at":"2025-05-01T10:00:00Z","details":{"family":"qwen3","parameter_size":"8.2B"}}]}`)
+	})
+
+	models, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Name != "qwen3:8b" || models[0].Details.ParameterSize != "8.2B" {
+		t.Errorf("unexpected models %+v", models)
+	}
+}
+
+func TestPullModelProgress(t *testing.T) {
+	// Status lines can exceed the scanner's default 64KB buffer
+	longStatus := strings.Repeat("x", 100*1024)
+
+	var request map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pull" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Fatal(err)
+		}
+		serveLines(
+			`{"status":"pulling manifest"}`,
+			`{"status":"pulling abc","digest":"sha256:abc","total":100,"completed":40}`,
+			`{"status":"pulling abc","digest":"sha256:abc","total":100,"completed":100}`,
+			fmt.Sprintf(`{"status":%q}`, longStatus),
+			`{"status":"success"}`,
+		)(w, r)
+	})
+
+	var updates []PullProgress
+	err := c.PullModel(context.Background(), "qwen3:8b", func(progress PullProgress) {
+		updates = append(updates, progress)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request["model"] != "qwen3:8b" || request["stream"] != true {
+		t.Errorf("unexpected request %v", request)
+	}
+	if len(updates) != 5 || updates[2].Completed != 100 || updates[4].Status != "success" {
+		t.Errorf("unexpected progress %+v", updates)
+	}
+}
+
+func TestPullModelError(t *testing.T) {
+	c := newTestClient(t, serveLines(`{"status":"pulling manifest"}`, `{"error":"pull model manifest: file does not exist"}`))
+
+	err := c.PullModel(context.Background(), "missing", nil)
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("expected the pull error, got %v", err)
+	}
+}
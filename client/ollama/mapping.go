@@ -0,0 +1,173 @@
+package ollama
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+	"github.com/jamesleeht/llm-gopher/structured"
+)
+
+type chatRequest struct {
+	Model     string          `json:"model"`
+	Messages  []chatMessage   `json:"messages"`
+	Stream    bool            `json:"stream"`
+	Format    json.RawMessage `json:"format,omitempty"`
+	Options   map[string]any  `json:"options,omitempty"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	Think     *bool           `json:"think,omitempty"`
+}
+
+type chatMessage struct {
+	Role     string `json:"role"`
+	Content  string `json:"content"`
+	Thinking string `json:"thinking,omitempty"`
+}
+
+// chatResponse is the reply of /api/chat, and also each line of a streamed reply
+type chatResponse struct {
+	Model      string      `json:"model"`
+	Message    chatMessage `json:"message"`
+	Done       bool        `json:"done"`
+	DoneReason string      `json:"done_reason"`
+	Error      string      `json:"error"`
+}
+
+// validateSettings rejects settings that Ollama has no equivalent for
+func validateSettings(settings params.Settings) error {
+	switch {
+	case len(settings.LogitBias) > 0:
+		return errors.New("logit bias is not supported by Ollama")
+	case settings.CandidateCount != nil && *settings.CandidateCount > 1:
+		return errors.New("multiple candidates are not supported by Ollama")
+	case settings.Logprobs, settings.TopLogprobs != nil:
+		return errors.New("logprobs are not supported by Ollama")
+	case settings.IsSearchEnabled:
+		return errors.New("search is not supported by Ollama")
+	case settings.Safety != nil:
+		return errors.New("safety settings are not supported by Ollama")
+	}
+	return nil
+}
+
+func (c *Client) mapPromptToRequest(prompt params.Prompt, settings params.Settings, stream bool) (*chatRequest, error) {
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+	if len(prompt.Documents) > 0 {
+		return nil, errors.New("documents are not supported by Ollama")
+	}
+
+	request := &chatRequest{
+		Model:   settings.ModelName,
+		Stream:  stream,
+		Options: mapSettingsToOptions(settings),
+		Think:   mapThinking(settings),
+	}
+
+	opts := optionsFromSettings(settings)
+	request.KeepAlive = opts.KeepAlive
+
+	switch c.structuredOutputMode {
+	case params.StructuredOutputJSONObject, params.StructuredOutputNone:
+		// Describe the schema in the system message instead, the reply is still validated against it
+		if prompt.ResponseFormat != nil {
+			var err error
+			if prompt, err = structured.WithSchemaInstruction(prompt); err != nil {
+				return nil, err
+			}
+			if c.structuredOutputMode == params.StructuredOutputJSONObject {
+				request.Format = json.RawMessage(`"json"`)
+			}
+		}
+	default:
+		if prompt.ResponseFormat != nil {
+			format, err := json.Marshal(schema.Generate(reflect.TypeOf(prompt.ResponseFormat)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal response schema: %w", err)
+			}
+			request.Format = format
+		}
+	}
+
+	request.Messages = mapPromptToMessages(prompt)
+	return request, nil
+}
+
+func mapPromptToMessages(prompt params.Prompt) []chatMessage {
+	messages := []chatMessage{}
+	if prompt.SystemMessage != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: prompt.SystemMessage})
+	}
+
+	for _, message := range prompt.Messages {
+		role := "user"
+		if message.Role == params.MessageRoleAssistant {
+			role = "assistant"
+		}
+		messages = append(messages, chatMessage{Role: role, Content: message.Content})
+	}
+	return messages
+}
+
+// mapSettingsToOptions maps the sampling settings to Ollama's model options
+func mapSettingsToOptions(settings params.Settings) map[string]any {
+	options := map[string]any{}
+	if settings.Temperature != nil {
+		options["temperature"] = *settings.Temperature
+	}
+	if settings.TopP != nil {
+		options["top_p"] = *settings.TopP
+	}
+	if settings.TopK != nil {
+		options["top_k"] = *settings.TopK
+	}
+	if settings.Seed != nil {
+		options["seed"] = *settings.Seed
+	}
+	if len(settings.StopSequences) > 0 {
+		options["stop"] = settings.StopSequences
+	}
+	if settings.PresencePenalty != nil {
+		options["presence_penalty"] = *settings.PresencePenalty
+	}
+	if settings.FrequencyPenalty != nil {
+		options["frequency_penalty"] = *settings.FrequencyPenalty
+	}
+	if settings.MaxOutputTokens != nil {
+		options["num_predict"] = *settings.MaxOutputTokens
+	}
+
+	opts := optionsFromSettings(settings)
+	if opts.NumCtx != nil {
+		options["num_ctx"] = *opts.NumCtx
+	}
+	for key, value := range opts.ModelOptions {
+		options[key] = value
+	}
+
+	if len(options) == 0 {
+		return nil
+	}
+	return options
+}
+
+// mapThinking turns thinking on or off for models that support it. Ollama has no budget,
+// so any budget enables thinking. If nothing is set, the model's default is used.
+func mapThinking(settings params.Settings) *bool {
+	var think bool
+	switch {
+	case settings.ThinkingTokens != nil:
+		think = *settings.ThinkingTokens != 0
+	case settings.ThinkingBudget != "":
+		think = settings.ThinkingBudget != params.NoThinkingBudget
+	case settings.IncludeReasoning:
+		think = true
+	default:
+		return nil
+	}
+	return &think
+}
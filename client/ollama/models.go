@@ -0,0 +1,107 @@
+package ollama
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Model is a model that has been pulled to the Ollama server
+type Model struct {
+	Name       string       `json:"name"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Details    ModelDetails `json:"details"`
+}
+
+type ModelDetails struct {
+	Format            string `json:"format"`
+	Family            string `json:"family"`
+	ParameterSize     string `json:"parameter_size"`
+	QuantizationLevel string `json:"quantization_level"`
+}
+
+// PullProgress is reported while a model is downloaded. Total and Completed are in bytes
+// and are only set while a layer is downloading.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// ListModels returns the models available locally
+func (c *Client) ListModels(ctx context.Context) ([]Model, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tags struct {
+		Models []Model `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode models: %w", err)
+	}
+	return tags.Models, nil
+}
+
+// PullModel downloads a model, calling progress with each status update if it isn't nil.
+// It returns once the pull has finished.
+func (c *Client) PullModel(ctx context.Context, name string, progress func(PullProgress)) error {
+	resp, err := c.post(ctx, "/api/pull", map[string]any{"model": name, "stream": true})
+	if err != nil {
+		return fmt.Errorf("failed to pull model %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var update struct {
+			PullProgress
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &update); err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+		if update.Error != "" {
+			return fmt.Errorf("failed to pull model %s: %s", name, update.Error)
+		}
+		if progress != nil {
+			progress(update.PullProgress)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read pull progress: %w", err)
+	}
+	return nil
+}
+
+// EnsureModel pulls the model if it isn't available locally yet
+func (c *Client) EnsureModel(ctx context.Context, name string, progress func(PullProgress)) error {
+	models, err := c.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+	for _, model := range models {
+		if model.Name == name || model.Name == name+":latest" {
+			return nil
+		}
+	}
+	return c.PullModel(ctx, name, progress)
+}
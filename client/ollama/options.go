@@ -0,0 +1,50 @@
+package ollama
+
+import "github.com/jamesleeht/llm-gopher/params"
+
+const providerName = "ollama"
+
+// Options are Ollama specific settings. Add them to params.Settings.ProviderOptions.
+type Options struct {
+	// KeepAlive is how long the model stays loaded after the request, e.g. "10m", or "-1" to keep it loaded
+	KeepAlive string
+	// NumCtx is the context window to load the model with. Ollama defaults to a small window.
+	NumCtx *int
+	// ModelOptions are merged into the request options, for runner settings such as num_gpu or mirostat
+	ModelOptions map[string]any
+}
+
+func (o Options) ProviderName() string {
+	return providerName
+}
+
+// optionsFromSettings returns the Ollama options of the settings, ignoring other providers' options.
+// If several are given, later options override earlier ones field by field.
+func optionsFromSettings(settings params.Settings) Options {
+	var result Options
+	for _, providerOptions := range settings.ProviderOptions {
+		var opts Options
+		switch o := providerOptions.(type) {
+		case Options:
+			opts = o
+		case *Options:
+			if o == nil {
+				continue
+			}
+			opts = *o
+		default:
+			continue
+		}
+
+		if opts.KeepAlive != "" {
+			result.KeepAlive = opts.KeepAlive
+		}
+		if opts.NumCtx != nil {
+			result.NumCtx = opts.NumCtx
+		}
+		if opts.ModelOptions != nil {
+			result.ModelOptions = opts.ModelOptions
+		}
+	}
+	return result
+}
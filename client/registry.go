@@ -0,0 +1,104 @@
+package client
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ProviderFactory creates a provider client from a config. NewClient passes the config through as is,
+// so each factory decides which config types it accepts.
+type ProviderFactory func(config any) (ProviderClient, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[ClientType]ProviderFactory{}
+)
+
+// Register makes a provider available to NewClient under a client type. The config passed to NewClient
+// must be a C or a *C. It panics if the client type is already registered, so call it from an init function.
+//
+//	func init() {
+//		client.Register("my_server", func(config myserver.Config) (client.ProviderClient, error) {
+//			return myserver.New(config)
+//		})
+//	}
+func Register[C any](clientType ClientType, factory func(config C) (ProviderClient, error)) {
+	RegisterFactory(clientType, typedFactory(clientType, factory))
+}
+
+// RegisterFactory registers a factory that accepts any config. Most providers should use Register instead.
+func RegisterFactory(clientType ClientType, factory ProviderFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("client: factory of client type %s is nil", clientType))
+	}
+	if _, exists := registry[clientType]; exists {
+		panic(fmt.Sprintf("client: client type %s is already registered", clientType))
+	}
+	registry[clientType] = factory
+}
+
+// RegisteredTypes returns the registered client types in alphabetical order
+func RegisteredTypes() []ClientType {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	types := make([]ClientType, 0, len(registry))
+	for clientType := range registry {
+		types = append(types, clientType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+	return types
+}
+
+func lookupFactory(clientType ClientType) (ProviderFactory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	factory, ok := registry[clientType]
+	return factory, ok
+}
+
+// typedFactory adapts a factory of a config type to accept the config as a value or a pointer
+func typedFactory[C any](clientType ClientType, factory func(config C) (ProviderClient, error)) ProviderFactory {
+	return func(config any) (ProviderClient, error) {
+		switch c := config.(type) {
+		case C:
+			return nilOnError(factory(c))
+		case *C:
+			if c != nil {
+				return nilOnError(factory(*c))
+			}
+		}
+
+		var want C
+		return nil, fmt.Errorf("client type %s expects a %T config, got %T", clientType, want, config)
+	}
+}
+
+// nilOnError returns a nil interface rather than an interface holding a nil pointer, which a factory
+// returns when it passes on the result of a constructor that failed or returned no client
+func nilOnError[P ProviderClient](provider P, err error) (ProviderClient, error) {
+	if err != nil || isNil(provider) {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func isNil(provider ProviderClient) bool {
+	if provider == nil {
+		return true
+	}
+	value := reflect.ValueOf(provider)
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return value.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+type testConfig struct {
+	Name string
+}
+
+type testProvider struct {
+	name string
+}
+
+func (p *testProvider) SendCompletionMessage(context.Context, params.Prompt, params.Settings) (*params.Response, error) {
+	return &params.Response{Content: p.name}, nil
+}
+
+func (p *testProvider) StreamCompletionMessage(context.Context, params.Prompt, params.Settings) (<-chan params.StreamChunk, error) {
+	return nil, nil
+}
+
+// newTestProvider returns a nil pointer for an empty name, like a constructor that has nothing to create
+func newTestProvider(config testConfig) *testProvider {
+	if config.Name == "" {
+		return nil
+	}
+	return &testProvider{name: config.Name}
+}
+
+func init() {
+	Register("test_registry", func(config testConfig) (ProviderClient, error) {
+		return newTestProvider(config), nil
+	})
+	RegisterFactory("test_registry_untyped", func(config any) (ProviderClient, error) {
+		return newTestProvider(config.(testConfig)), nil
+	})
+}
+
+func TestNewClientAcceptsValueAndPointerConfigs(t *testing.T) {
+	configs := map[string]any{"value": testConfig{Name: "value"}, "pointer": &testConfig{Name: "pointer"}}
+	for name, config := range configs {
+		c, err := NewClient(config, "test_registry")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.ClientType != "test_registry" || c.Provider.(*testProvider).name != name {
+			t.Errorf("expected a test_registry client named %s, got %+v", name, c)
+		}
+	}
+
+	if _, err := NewClient((*testConfig)(nil), "test_registry"); err == nil {
+		t.Error("expected an error for a nil config")
+	}
+}
+
+func TestNewClientRejectsTypedNilProvider(t *testing.T) {
+	for _, clientType := range []ClientType{"test_registry", "test_registry_untyped"} {
+		c, err := NewClient(testConfig{}, clientType)
+		if err == nil || !strings.Contains(err.Error(), "created no provider") {
+			t.Errorf("%s: expected an error for a nil provider, got %v and %+v", clientType, err, c)
+		}
+	}
+}
+
+func TestNewClientRejectsUnknownTypes(t *testing.T) {
+	if _, err := NewClient(testConfig{Name: "x"}, "test_missing"); err == nil {
+		t.Error("expected an error for an unregistered client type")
+	}
+	_, err := NewClient(struct{}{}, "test_registry")
+	if err == nil || !strings.Contains(err.Error(), "expects a client.testConfig config") {
+		t.Errorf("expected an error for a config of the wrong type, got %v", err)
+	}
+}
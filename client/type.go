@@ -1,8 +1,23 @@
 package client
 
+// ClientType names a provider registered with Register. The built-in types are registered by this package.
 type ClientType string
 
 const (
 	ClientTypeOpenAI ClientType = "openai"
-	ClientTypeVertex ClientType = "vertex"
+	// ClientTypeOpenAIResponses uses OpenAI's Responses API instead of Chat Completions
+	ClientTypeOpenAIResponses ClientType = "openai_responses"
+	ClientTypeVertex          ClientType = "vertex"
+	// ClientTypeGemini uses the Gemini Developer API with an API key
+	ClientTypeGemini ClientType = "gemini"
+	// ClientTypeOllama uses Ollama's native API
+	ClientTypeOllama ClientType = "ollama"
+	// ClientTypeAzureOpenAI uses Azure OpenAI deployments
+	ClientTypeAzureOpenAI ClientType = "azure_openai"
+	// ClientTypeBedrock uses the AWS Bedrock Converse API
+	ClientTypeBedrock ClientType = "bedrock"
+	// ClientTypeMistral uses Mistral's native API
+	ClientTypeMistral ClientType = "mistral"
+	// ClientTypeCohere uses Cohere's v2 Chat API
+	ClientTypeCohere ClientType = "cohere"
 )
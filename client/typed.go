@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// SendTyped sends the prompt with T as the response format and returns the parsed value.
+// A fresh T is allocated for every call, so the same prompt can be reused concurrently.
+// Any ResponseFormat already set on the prompt is replaced.
+func SendTyped[T any](ctx context.Context,
+	c *Client,
+	prompt params.Prompt,
+	settings params.Settings) (T, *params.Response, error) {
+	var zero T
+
+	typedPrompt, value, err := WithTypedResponseFormat[T](prompt)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	response, err := c.SendMessage(ctx, typedPrompt, settings)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	return *value, response, nil
+}
+
+// WithTypedResponseFormat returns a copy of the prompt whose response format is a newly allocated T,
+// along with the pointer the response will be unmarshalled into. T must be a struct type,
+// since structured output schemas require an object at the root.
+func WithTypedResponseFormat[T any](prompt params.Prompt) (params.Prompt, *T, error) {
+	t := reflect.TypeFor[T]()
+	if t.Kind() != reflect.Struct {
+		return params.Prompt{}, nil, fmt.Errorf("typed response format must be a struct type, got %v", t)
+	}
+
+	value := new(T)
+	prompt.ResponseFormat = value
+	return prompt, value, nil
+}
@@ -0,0 +1,80 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+type weather struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature"`
+}
+
+// jsonProvider replies with the same JSON and unmarshals it into the response format like the provider clients do
+type jsonProvider struct {
+	reply   string
+	prompts []params.Prompt
+}
+
+func (p *jsonProvider) SendCompletionMessage(_ context.Context, prompt params.Prompt, _ params.Settings) (*params.Response, error) {
+	p.prompts = append(p.prompts, prompt)
+	response := &params.Response{Content: p.reply}
+	if prompt.ResponseFormat != nil {
+		if err := json.Unmarshal([]byte(p.reply), prompt.ResponseFormat); err != nil {
+			return nil, err
+		}
+		response.Parsed = prompt.ResponseFormat
+	}
+	return response, nil
+}
+
+func (p *jsonProvider) StreamCompletionMessage(context.Context, params.Prompt, params.Settings) (<-chan params.StreamChunk, error) {
+	return nil, errors.New("streaming is not supported")
+}
+
+func TestSendTypedReturnsParsedValue(t *testing.T) {
+	provider := &jsonProvider{reply: `{"city":"Paris","temperature":21.5}`}
+	c := &client.Client{Provider: provider, ClientType: client.ClientTypeOpenAI}
+
+	prompt := params.NewSimplePrompt("", "Weather in Paris?")
+	value, response, err := client.SendTyped[weather](context.Background(), c, prompt, params.Settings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != (weather{City: "Paris", Temperature: 21.5}) {
+		t.Errorf("expected the parsed weather, got %+v", value)
+	}
+	if response.Content != provider.reply {
+		t.Errorf("expected the raw reply in the response, got %q", response.Content)
+	}
+	if _, ok := provider.prompts[0].ResponseFormat.(*weather); !ok {
+		t.Errorf("expected a *weather response format, got %T", provider.prompts[0].ResponseFormat)
+	}
+	if prompt.ResponseFormat != nil {
+		t.Error("expected the caller's prompt to be left unchanged")
+	}
+}
+
+func TestSendTypedRejectsNonStructTypes(t *testing.T) {
+	provider := &jsonProvider{reply: `{}`}
+	c := &client.Client{Provider: provider, ClientType: client.ClientTypeOpenAI}
+	prompt := params.NewSimplePrompt("", "Weather in Paris?")
+
+	if _, _, err := client.SendTyped[*weather](context.Background(), c, prompt, params.Settings{}); err == nil {
+		t.Error("expected an error for a pointer type")
+	}
+	if _, _, err := client.SendTyped[map[string]any](context.Background(), c, prompt, params.Settings{}); err == nil {
+		t.Error("expected an error for a map type")
+	}
+	if _, _, err := client.SendTyped[[]string](context.Background(), c, prompt, params.Settings{}); err == nil {
+		t.Error("expected an error for a slice type")
+	}
+	if len(provider.prompts) != 0 {
+		t.Errorf("expected no requests for invalid types, got %d", len(provider.prompts))
+	}
+}
@@ -3,24 +3,36 @@ package vertex
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
-	"reflect"
+	"slices"
 
 	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/structured"
 
 	"cloud.google.com/go/auth"
 	"google.golang.org/genai"
 )
 
 type Client struct {
-	internalClient *genai.Client
+	internalClient       *genai.Client
+	structuredOutputMode params.StructuredOutputMode
+	backend              genai.Backend
 }
 
 type ClientConfig struct {
 	ProjectID string
 	Location  string
 
+	// APIKey authenticates with the Gemini Developer API. Only used by NewGeminiClient.
+	APIKey string
+	// BaseURL overrides the API endpoint, e.g. to point at a local stand-in server
+	BaseURL string
+
+	// StructuredOutputMode defaults to native JSON schema support
+	StructuredOutputMode params.StructuredOutputMode
+
 	// path to a service account JSON file or JSON string. Used in development.
 	CredentialsPath       string
 	CredentialsJSONString string
@@ -42,6 +54,7 @@ func NewVertexAIClient(config ClientConfig) (*Client, error) {
 		Location:    config.Location,
 		Credentials: creds,
 		Backend:     genai.BackendVertexAI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: config.BaseURL},
 	})
 
 	if err != nil {
@@ -49,16 +62,63 @@ func NewVertexAIClient(config ClientConfig) (*Client, error) {
 	}
 
 	return &Client{
-		internalClient: client,
+		internalClient:       client,
+		structuredOutputMode: config.StructuredOutputMode,
+		backend:              genai.BackendVertexAI,
 	}, nil
 }
 
-func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
-	config, err := mapSettingsToVertexSettings(prompt, settings)
+// NewGeminiClient creates a client for the Gemini Developer API, which authenticates with an API key
+// instead of a Google Cloud project. It shares the Vertex mapping, see the README for the differences.
+func NewGeminiClient(config ClientConfig) (*Client, error) {
+	client, err := genai.NewClient(context.Background(), &genai.ClientConfig{
+		APIKey:      config.APIKey,
+		Backend:     genai.BackendGeminiAPI,
+		HTTPOptions: genai.HTTPOptions{BaseURL: config.BaseURL},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create genai client: %w", err)
+	}
+
+	return &Client{
+		internalClient:       client,
+		structuredOutputMode: config.StructuredOutputMode,
+		backend:              genai.BackendGeminiAPI,
+	}, nil
+}
+
+// ValidateSettings reports settings that this client cannot send, so presets can be rejected up front
+func (c *Client) ValidateSettings(settings params.Settings) error {
+	if err := validateSettings(settings); err != nil {
+		return err
+	}
+	if c.backend == genai.BackendGeminiAPI && optionsFromSettings(settings).Labels != nil {
+		return fmt.Errorf("gemini - labels are not supported by the Gemini Developer API")
+	}
+	return nil
+}
+
+// generateConfig maps the settings and drops what the Gemini Developer API doesn't accept
+func (c *Client) generateConfig(prompt params.Prompt, settings params.Settings) (*genai.GenerateContentConfig, error) {
+	config, err := mapSettingsToVertexSettings(prompt, settings, c.structuredOutputMode)
 	if err != nil {
 		return nil, fmt.Errorf("failed to map settings to vertex settings: %w", err)
 	}
 
+	if c.backend == genai.BackendGeminiAPI {
+		config.SafetySettings = slices.DeleteFunc(config.SafetySettings, func(setting *genai.SafetySetting) bool {
+			return setting.Category == genai.HarmCategoryUnspecified
+		})
+	}
+	return config, nil
+}
+
+func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	config, err := c.generateConfig(prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+
 	messages := mapPromptToMessages(prompt)
 	resp, err := c.internalClient.Models.GenerateContent(ctx,
 		string(settings.ModelName),
@@ -66,40 +126,47 @@ func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt
 		config,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate content: %w", err)
+		return nil, fmt.Errorf("failed to generate content: %w", c.providerError(err))
+	}
+	if err := contentFilterError(resp); err != nil {
+		return nil, err
 	}
 
-	// candidate := resp.Candidates[0]
-	// groundingMetadata := candidate.GroundingMetadata
-	// if groundingMetadata != nil {
-	// 	fmt.Println("Grounding metadata:")
-	// 	fmt.Println(groundingMetadata)
-	// 	searchEntryPoint := groundingMetadata.SearchEntryPoint
-	// 	if searchEntryPoint != nil {
-	// 		fmt.Println("Search entry point:")
-	// 		fmt.Println(searchEntryPoint.RenderedContent)
-	// 	}
-	// }
+	// resp.Text logs a warning when there are several candidates, so read the first one directly
+	candidates := mapCandidates(resp)
+	var content string
+	if candidates != nil {
+		content = candidates[0]
+	} else {
+		content = resp.Text()
+	}
 
-	content := resp.Text()
+	reasoning, signature := mapThoughts(resp)
 
 	response := &params.Response{
-		Content: content,
-		Parsed:  nil,
+		ResponseID:       resp.ResponseID,
+		Content:          content,
+		Parsed:           nil,
+		ThoughtSignature: signature,
+	}
+	if settings.IncludeReasoning {
+		response.Reasoning = reasoning
+	}
+	if settings.Logprobs {
+		response.Logprobs = mapLogprobs(resp)
+	}
+	response.Candidates = candidates
+	if len(resp.Candidates) > 0 {
+		candidate := resp.Candidates[0]
+		response.SafetyRatings = mapSafetyRatings(candidate.SafetyRatings)
+		response.Grounding = mapGrounding(candidate.GroundingMetadata, answerPartOffsets(candidate.Content))
 	}
 
-	// If response format is specified, unmarshal into that type
+	// If response format is specified, validate the reply and unmarshal into that type
 	if prompt.ResponseFormat != nil {
-		// ResponseFormat must be a pointer to unmarshal into
-		responseType := reflect.TypeOf(prompt.ResponseFormat)
-
-		if responseType.Kind() != reflect.Ptr {
-			return nil, fmt.Errorf("response format must be a pointer, got %v", responseType.Kind())
-		}
-
 		// Unmarshal directly into the pointer provided by the user
-		if err := json.Unmarshal([]byte(content), prompt.ResponseFormat); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response into specified format: %w", err)
+		if err := structured.Parse(content, prompt.ResponseFormat); err != nil {
+			return nil, err
 		}
 
 		// Set the parsed field to the populated pointer
@@ -110,15 +177,9 @@ func (c *Client) SendCompletionMessage(ctx context.Context, prompt params.Prompt
 }
 
 func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
-	config, err := mapSettingsToVertexSettings(prompt, settings)
+	config, err := c.generateConfig(prompt, settings)
 	if err != nil {
-		return nil, fmt.Errorf("failed to map settings to vertex settings: %w", err)
-	}
-
-	// Note: Response format (structured outputs) may have limitations in streaming mode for Vertex
-	// We'll still attempt to stream, but the formatting may not work as expected
-	if prompt.ResponseFormat != nil {
-		fmt.Println("Warning: Response format may not be fully supported in streaming mode")
+		return nil, err
 	}
 
 	messages := mapPromptToMessages(prompt)
@@ -133,6 +194,9 @@ func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prom
 	go func() {
 		defer close(chunks)
 
+		var signature []byte
+		var grounding *params.Grounding
+
 		// Use the iterator with a range loop (Go 1.23 iter.Seq2)
 		for resp, err := range stream {
 			if err != nil {
@@ -140,11 +204,38 @@ func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prom
 				chunks <- params.StreamChunk{
 					Content: "",
 					Done:    true,
-					Error:   fmt.Errorf("streaming error: %w", err),
+					Error:   fmt.Errorf("streaming error: %w", c.providerError(err)),
+				}
+				return
+			}
+			// With several candidates, chunks of every candidate are interleaved. Only the first one is streamed.
+			resp = firstCandidate(resp)
+			if err := contentFilterError(resp); err != nil {
+				chunks <- params.StreamChunk{
+					Content: "",
+					Done:    true,
+					Error:   err,
 				}
 				return
 			}
 
+			reasoning, chunkSignature := mapThoughts(resp)
+			if chunkSignature != nil {
+				signature = chunkSignature
+			}
+			// Grounding metadata covers the whole reply, so keep the latest one
+			if len(resp.Candidates) > 0 && resp.Candidates[0].GroundingMetadata != nil {
+				grounding = mapGrounding(resp.Candidates[0].GroundingMetadata, nil)
+			}
+			if settings.IncludeReasoning && reasoning != "" {
+				chunks <- params.StreamChunk{
+					Kind:    params.StreamChunkKindReasoning,
+					Content: reasoning,
+					Done:    false,
+					Error:   nil,
+				}
+			}
+
 			// Extract text from the response
 			if text := resp.Text(); text != "" {
 				chunks <- params.StreamChunk{
@@ -157,15 +248,40 @@ func (c *Client) StreamCompletionMessage(ctx context.Context, prompt params.Prom
 
 		// Send final chunk to indicate completion
 		chunks <- params.StreamChunk{
-			Content: "",
-			Done:    true,
-			Error:   nil,
+			Content:          "",
+			Done:             true,
+			Error:            nil,
+			ThoughtSignature: signature,
+			Grounding:        grounding,
 		}
 	}()
 
 	return chunks, nil
 }
 
+// providerError converts an API error of the SDK to a ProviderError, which keeps it as Err.
+// Other errors, e.g. network errors, are returned as they are.
+func (c *Client) providerError(err error) error {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	provider := providerName
+	if c.backend == genai.BackendGeminiAPI {
+		provider = geminiProviderName
+	}
+	return &params.ProviderError{
+		Provider:   provider,
+		Kind:       params.ErrorKindFromStatus(apiErr.Code),
+		StatusCode: apiErr.Code,
+		// Status is the gRPC status name, e.g. RESOURCE_EXHAUSTED
+		Code:    apiErr.Status,
+		Message: apiErr.Message,
+		Err:     err,
+	}
+}
+
 func getCredentials(config ClientConfig) (*auth.Credentials, error) {
 	if config.CredentialsPath != "" {
 		return getCredsFromCredentialsPath(config.CredentialsPath)
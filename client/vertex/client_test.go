@@ -0,0 +1,447 @@
+package vertex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"google.golang.org/genai"
+)
+
+// newTestClient returns a Gemini client for a local server that answers every request with handler
+func newTestClient(t *testing.T, handler http.HandlerFunc, structuredOutputMode params.StructuredOutputMode) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewGeminiClient(ClientConfig{APIKey: "test", BaseURL: server.URL, StructuredOutputMode: structuredOutputMode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// serveContent answers with a candidate of text and records the request body
+func serveContent(text string, body *map[string]any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"candidates":[{"content":{"role":"model","parts":[{"text":%q}]}}]}`, text)
+	}
+}
+
+type city struct {
+	Name       string `json:"name"`
+	Population int    `json:"population"`
+}
+
+func TestStructuredOutputModes(t *testing.T) {
+	tests := []struct {
+		mode                params.StructuredOutputMode
+		mimeType            string
+		nativeSchema        bool
+		instructionInPrompt bool
+	}{
+		{mode: params.StructuredOutputNative, mimeType: "application/json", nativeSchema: true},
+		{mode: params.StructuredOutputJSONObject, mimeType: "application/json", instructionInPrompt: true},
+		{mode: params.StructuredOutputNone, instructionInPrompt: true},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.mode), func(t *testing.T) {
+			var body map[string]any
+			c := newTestClient(t, serveContent(`{"name":"Paris","population":2100000}`, &body), test.mode)
+
+			var result city
+			prompt := params.NewSimplePrompt("Be brief.", "Largest city in France?")
+			prompt.ResponseFormat = &result
+			if _, err := c.SendCompletionMessage(context.Background(), prompt, params.Settings{ModelName: "gemini-2.5-flash"}); err != nil {
+				t.Fatal(err)
+			}
+			if result.Name != "Paris" {
+				t.Errorf("expected the parsed reply, got %+v", result)
+			}
+
+			config, _ := body["generationConfig"].(map[string]any)
+			if got, _ := config["responseMimeType"].(string); got != test.mimeType {
+				t.Errorf("expected MIME type %q, got %q", test.mimeType, got)
+			}
+			if _, got := config["responseJsonSchema"]; got != test.nativeSchema {
+				t.Errorf("expected a native schema to be %v, got %v", test.nativeSchema, config["responseJsonSchema"])
+			}
+
+			data, _ := json.Marshal(body["systemInstruction"])
+			if got := strings.Contains(string(data), `population`); got != test.instructionInPrompt {
+				t.Errorf("expected the schema in the system instruction to be %v, got %s", test.instructionInPrompt, data)
+			}
+		})
+	}
+}
+
+func TestStructuredOutputModesValidateReply(t *testing.T) {
+	var body map[string]any
+	c := newTestClient(t, serveContent(`{"name":"Paris"}`, &body), params.StructuredOutputNone)
+
+	prompt := params.NewSimplePrompt("", "Largest city in France?")
+	prompt.ResponseFormat = &city{}
+	_, err := c.SendCompletionMessage(context.Background(), prompt, params.Settings{ModelName: "gemini-2.5-flash"})
+
+	var outputErr *params.StructuredOutputError
+	if !errors.As(err, &outputErr) || len(outputErr.Violations) == 0 {
+		t.Errorf("expected the missing population to fail validation, got %v", err)
+	}
+}
+
+// serveEvents answers with a server-sent event stream of the given JSON responses
+func serveEvents(events ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\r\n\r\n", event)
+		}
+	}
+}
+
+const thinkingResponse = `{"candidates":[{"content":{"role":"model","parts":[` +
+	`{"text":"2+2 is 4.","thought":true},{"text":"4","thoughtSignature":"c2lnbmF0dXJl"}]}}]}`
+
+func TestThoughtSignatureRoundTrip(t *testing.T) {
+	var requests []map[string]any
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		requests = append(requests, body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, thinkingResponse)
+	}, params.StructuredOutputNative)
+
+	ctx := context.Background()
+	settings := params.Settings{ModelName: "gemini-2.5-flash", IncludeReasoning: true}
+	prompt := params.NewSimplePrompt("", "What is 2+2?")
+
+	response, err := c.SendCompletionMessage(ctx, prompt, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "4" || response.Reasoning != "2+2 is 4." {
+		t.Errorf("expected the answer and the thoughts separately, got %+v", response)
+	}
+	if string(response.ThoughtSignature) != "signature" {
+		t.Fatalf("expected the thought signature, got %q", response.ThoughtSignature)
+	}
+
+	// The signature is sent back with the assistant message on the next turn
+	prompt.Messages = append(prompt.Messages,
+		params.Message{Role: params.MessageRoleAssistant, Content: response.Content, ThoughtSignature: response.ThoughtSignature},
+		params.Message{Role: params.MessageRoleUser, Content: "And 3+3?"},
+	)
+	if _, err := c.SendCompletionMessage(ctx, prompt, settings); err != nil {
+		t.Fatal(err)
+	}
+
+	contents := requests[1]["contents"].([]any)
+	part := contents[1].(map[string]any)["parts"].([]any)[0].(map[string]any)
+	if part["thoughtSignature"] != "c2lnbmF0dXJl" || part["text"] != "4" {
+		t.Errorf("expected the signature on the assistant message, got %v", part)
+	}
+
+	thinkingConfig := requests[0]["generationConfig"].(map[string]any)["thinkingConfig"].(map[string]any)
+	if thinkingConfig["includeThoughts"] != true {
+		t.Errorf("expected thoughts to be requested, got %v", thinkingConfig)
+	}
+}
+
+func TestStreamCompletionMessageIncludesReasoning(t *testing.T) {
+	c := newTestClient(t, serveEvents(
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"2+2 is 4.","thought":true}]}}]}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"4","thoughtSignature":"c2lnbmF0dXJl"}]}}]}`,
+	), params.StructuredOutputNative)
+
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "What is 2+2?"),
+		params.Settings{ModelName: "gemini-2.5-flash", IncludeReasoning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content, reasoning strings.Builder
+	var final params.StreamChunk
+	for chunk := range chunks {
+		switch {
+		case chunk.Error != nil:
+			t.Fatal(chunk.Error)
+		case chunk.Done:
+			final = chunk
+		case chunk.Kind == params.StreamChunkKindReasoning:
+			reasoning.WriteString(chunk.Content)
+		default:
+			content.WriteString(chunk.Content)
+		}
+	}
+
+	if content.String() != "4" || reasoning.String() != "2+2 is 4." {
+		t.Errorf("expected the answer and the thoughts separately, got %q and %q", content.String(), reasoning.String())
+	}
+	if string(final.ThoughtSignature) != "signature" {
+		t.Errorf("expected the signature on the final chunk, got %q", final.ThoughtSignature)
+	}
+}
+
+func candidateEvent(index int, text string) string {
+	return fmt.Sprintf(`{"candidates":[{"index":%d,"content":{"role":"model","parts":[{"text":%q}]}}],"responseId":"resp-1"}`,
+		index, text)
+}
+
+// collect reads a stream, returning the text content and the final chunk
+func collect(t *testing.T, chunks <-chan params.StreamChunk) (string, params.StreamChunk) {
+	t.Helper()
+	var content strings.Builder
+	var final params.StreamChunk
+	for chunk := range chunks {
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		if chunk.Kind == params.StreamChunkKindText {
+			content.WriteString(chunk.Content)
+		}
+	}
+	return content.String(), final
+}
+
+func TestStreamOnlyFirstCandidate(t *testing.T) {
+	c := newTestClient(t, serveEvents(
+		candidateEvent(0, "Hello"),
+		candidateEvent(1, "Bonjour"),
+		candidateEvent(0, " world"),
+		candidateEvent(1, " le monde"),
+	), params.StructuredOutputNative)
+
+	candidateCount := 2
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Greet me"),
+		params.Settings{ModelName: "gemini-2.5-flash", CandidateCount: &candidateCount})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, final := collect(t, chunks)
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content != "Hello world" {
+		t.Errorf("expected only the first candidate, got %q", content)
+	}
+}
+
+// geminiServer routes the Gemini Developer API methods used by the client to handlers
+func geminiServer(t *testing.T, handlers map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get("x-goog-api-key"); key != "test" {
+			t.Errorf("expected the API key header, got %q", key)
+		}
+		_, method, _ := strings.Cut(r.URL.Path, ":")
+		handler, exists := handlers[method]
+		if !exists {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+type capitalAnswer struct {
+	City string `json:"city"`
+}
+
+func TestGeminiSendCompletionMessage(t *testing.T) {
+	var request map[string]any
+	c := newTestClient(t, geminiServer(t, map[string]http.HandlerFunc{
+		"generateContent": func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, "/models/gemini-2.5-flash:generateContent") {
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprint(w, `{"candidates":[{"content":{"role":"model","parts":[`+
+				`{"text":"Looking it up","thought":true},{"text":"{\"city\":\"Paris\"}"}]},"finishReason":"STOP"}],`+
+				`"responseId":"resp-1"}`)
+		},
+	}), params.StructuredOutputNative)
+
+	var answer capitalAnswer
+	prompt := params.NewSimplePrompt("Answer in JSON.", "Capital of France?")
+	prompt.ResponseFormat = &answer
+	response, err := c.SendCompletionMessage(context.Background(), prompt,
+		params.Settings{ModelName: "gemini-2.5-flash", IncludeReasoning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if answer.City != "Paris" || response.ResponseID != "resp-1" || response.Reasoning != "Looking it up" {
+		t.Errorf("unexpected response %+v with answer %+v", response, answer)
+	}
+	config, _ := request["generationConfig"].(map[string]any)
+	if config["responseMimeType"] != "application/json" || config["responseJsonSchema"] == nil {
+		t.Errorf("expected a JSON schema response format, got %v", config)
+	}
+	if request["systemInstruction"] == nil {
+		t.Error("expected the system message as system instruction")
+	}
+}
+
+func TestGeminiStreamCompletionMessage(t *testing.T) {
+	c := newTestClient(t, geminiServer(t, map[string]http.HandlerFunc{
+		"streamGenerateContent": func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("alt") != "sse" {
+				t.Errorf("expected a server-sent event stream, got %s", r.URL.RawQuery)
+			}
+			serveEvents(
+				`{"candidates":[{"content":{"role":"model","parts":[{"text":"Thinking","thought":true}]}}]}`,
+				candidateEvent(0, "Hello"),
+				`{"candidates":[{"content":{"role":"model","parts":[{"text":" world","thoughtSignature":"c2ln"}]},"finishReason":"STOP"}]}`,
+			)(w, r)
+		},
+	}), params.StructuredOutputNative)
+
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Greet me"),
+		params.Settings{ModelName: "gemini-2.5-flash", IncludeReasoning: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reasoning strings.Builder
+	var content strings.Builder
+	var final params.StreamChunk
+	for chunk := range chunks {
+		switch {
+		case chunk.Done:
+			final = chunk
+		case chunk.Kind == params.StreamChunkKindReasoning:
+			reasoning.WriteString(chunk.Content)
+		default:
+			content.WriteString(chunk.Content)
+		}
+	}
+
+	if final.Error != nil {
+		t.Fatal(final.Error)
+	}
+	if content.String() != "Hello world" || reasoning.String() != "Thinking" {
+		t.Errorf("expected content and reasoning, got %q and %q", content.String(), reasoning.String())
+	}
+	if string(final.ThoughtSignature) != "sig" {
+		t.Errorf("expected the thought signature on the final chunk, got %q", final.ThoughtSignature)
+	}
+}
+
+func TestGeminiCreateEmbeddings(t *testing.T) {
+	var batches []int
+	c := newTestClient(t, geminiServer(t, map[string]http.HandlerFunc{
+		"batchEmbedContents": func(w http.ResponseWriter, r *http.Request) {
+			var request struct {
+				Requests []struct {
+					TaskType string `json:"taskType"`
+				} `json:"requests"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Fatal(err)
+			}
+			batches = append(batches, len(request.Requests))
+
+			embeddings := make([]string, len(request.Requests))
+			for i, embedRequest := range request.Requests {
+				if embedRequest.TaskType != "RETRIEVAL_DOCUMENT" {
+					t.Errorf("expected the task type, got %q", embedRequest.TaskType)
+				}
+				embeddings[i] = `{"values":[0.1,0.2]}`
+			}
+			fmt.Fprintf(w, `{"embeddings":[%s]}`, strings.Join(embeddings, ","))
+		},
+	}), params.StructuredOutputNative)
+
+	texts := make([]string, 150)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("text %d", i)
+	}
+	response, err := c.CreateEmbeddings(context.Background(), texts, params.EmbeddingSettings{
+		ModelName: "gemini-embedding-001",
+		TaskType:  params.EmbeddingTaskTypeRetrievalDocument,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(response.Embeddings) != 150 || len(response.Embeddings[0]) != 2 {
+		t.Errorf("expected 150 embeddings of 2 dimensions, got %d", len(response.Embeddings))
+	}
+	if len(batches) != 2 || batches[0] != 100 || batches[1] != 50 {
+		t.Errorf("expected batches of 100 and 50, got %v", batches)
+	}
+}
+
+func TestGeminiProviderError(t *testing.T) {
+	rateLimited := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"code":429,"message":"Resource has been exhausted (e.g. check quota).","status":"RESOURCE_EXHAUSTED"}}`)
+	}
+	c := newTestClient(t, geminiServer(t, map[string]http.HandlerFunc{
+		"generateContent":       rateLimited,
+		"streamGenerateContent": rateLimited,
+		"batchEmbedContents":    rateLimited,
+		"countTokens":           rateLimited,
+	}), params.StructuredOutputNative)
+
+	checkError := func(t *testing.T, err error) {
+		t.Helper()
+		var providerErr *params.ProviderError
+		if !errors.As(err, &providerErr) {
+			t.Fatalf("expected a provider error, got %v", err)
+		}
+		if providerErr.Provider != geminiProviderName || providerErr.Kind != params.ErrorKindRateLimit ||
+			providerErr.StatusCode != 429 || providerErr.Code != "RESOURCE_EXHAUSTED" {
+			t.Errorf("unexpected provider error %+v", providerErr)
+		}
+		if !providerErr.Retryable() {
+			t.Error("expected a rate limit to be retryable")
+		}
+		var apiErr genai.APIError
+		if !errors.As(err, &apiErr) {
+			t.Error("expected the SDK error to be kept")
+		}
+	}
+
+	ctx := context.Background()
+	settings := params.Settings{ModelName: "gemini-2.5-flash"}
+	prompt := params.NewSimplePrompt("", "Hi")
+
+	_, err := c.SendCompletionMessage(ctx, prompt, settings)
+	checkError(t, err)
+
+	chunks, err := c.StreamCompletionMessage(ctx, prompt, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, final := collect(t, chunks)
+	checkError(t, final.Error)
+
+	_, err = c.CreateEmbeddings(ctx, []string{"Hi"}, params.EmbeddingSettings{ModelName: "text-embedding-004"})
+	checkError(t, err)
+
+	_, err = c.CountTokens(ctx, prompt, settings)
+	checkError(t, err)
+}
@@ -0,0 +1,78 @@
+package vertex
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"google.golang.org/genai"
+)
+
+// Vertex text embedding models accept at most 250 inputs per request
+const maxEmbeddingBatchSize = 250
+
+// Gemini embedding models on Vertex AI, such as gemini-embedding-001, accept one input per request
+const maxGeminiModelEmbeddingBatchSize = 1
+
+// The Gemini Developer API accepts at most 100 inputs per batch
+const maxGeminiEmbeddingBatchSize = 100
+
+func (c *Client) CreateEmbeddings(ctx context.Context, texts []string, settings params.EmbeddingSettings) (*params.EmbeddingResponse, error) {
+	batchSize := c.maxEmbeddingBatchSize(settings.ModelName)
+	if settings.BatchSize > 0 && settings.BatchSize < batchSize {
+		batchSize = settings.BatchSize
+	}
+
+	config := &genai.EmbedContentConfig{
+		TaskType: string(settings.TaskType),
+	}
+	if settings.Dimensions > 0 {
+		dimensions := int32(settings.Dimensions)
+		config.OutputDimensionality = &dimensions
+	}
+
+	response := &params.EmbeddingResponse{
+		Embeddings: make([][]float32, 0, len(texts)),
+	}
+
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch := texts[start:end]
+
+		contents := make([]*genai.Content, len(batch))
+		for i, text := range batch {
+			contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+		}
+
+		result, err := c.internalClient.Models.EmbedContent(ctx, settings.ModelName, contents, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed content: %w", c.providerError(err))
+		}
+		if len(result.Embeddings) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(result.Embeddings))
+		}
+
+		for _, embedding := range result.Embeddings {
+			response.Embeddings = append(response.Embeddings, embedding.Values)
+			if embedding.Statistics != nil {
+				response.TokenCount += int(embedding.Statistics.TokenCount)
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// maxEmbeddingBatchSize returns how many inputs the backend accepts per request for the model
+func (c *Client) maxEmbeddingBatchSize(modelName string) int {
+	switch {
+	case c.backend == genai.BackendGeminiAPI:
+		return maxGeminiEmbeddingBatchSize
+	case strings.Contains(modelName, "gemini-embedding"):
+		return maxGeminiModelEmbeddingBatchSize
+	default:
+		return maxEmbeddingBatchSize
+	}
+}
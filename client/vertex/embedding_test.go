@@ -0,0 +1,30 @@
+package vertex
+
+import (
+	"testing"
+
+	"google.golang.org/genai"
+)
+
+func TestMaxEmbeddingBatchSize(t *testing.T) {
+	tests := []struct {
+		name      string
+		backend   genai.Backend
+		modelName string
+		want      int
+	}{
+		{name: "vertex text embedding", backend: genai.BackendVertexAI, modelName: "text-embedding-005", want: 250},
+		{name: "vertex gemini embedding", backend: genai.BackendVertexAI, modelName: "gemini-embedding-001", want: 1},
+		{name: "vertex gemini embedding resource", backend: genai.BackendVertexAI, modelName: "publishers/google/models/gemini-embedding-001", want: 1},
+		{name: "gemini api", backend: genai.BackendGeminiAPI, modelName: "gemini-embedding-001", want: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{backend: tt.backend}
+			if got := c.maxEmbeddingBatchSize(tt.modelName); got != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package vertex
+
+import (
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"google.golang.org/genai"
+)
+
+// mapGrounding converts the grounding metadata of the first candidate. Segment offsets are relative
+// to their part, so partOffsets gives the position of each part in the reply; nil keeps them as is.
+func mapGrounding(metadata *genai.GroundingMetadata, partOffsets []int) *params.Grounding {
+	if metadata == nil {
+		return nil
+	}
+
+	grounding := &params.Grounding{
+		SearchQueries: metadata.WebSearchQueries,
+	}
+	if metadata.SearchEntryPoint != nil {
+		grounding.SearchEntryPoint = metadata.SearchEntryPoint.RenderedContent
+	}
+
+	for _, chunk := range metadata.GroundingChunks {
+		grounding.Sources = append(grounding.Sources, mapGroundingChunk(chunk))
+	}
+
+	for _, support := range metadata.GroundingSupports {
+		if support == nil || support.Segment == nil {
+			continue
+		}
+
+		offset := 0
+		if partIndex := int(support.Segment.PartIndex); partIndex < len(partOffsets) {
+			offset = partOffsets[partIndex]
+		}
+
+		for _, chunkIndex := range support.GroundingChunkIndices {
+			if int(chunkIndex) >= len(grounding.Sources) {
+				continue
+			}
+			source := grounding.Sources[chunkIndex]
+			grounding.Citations = append(grounding.Citations, params.Citation{
+				URL:        source.URL,
+				Title:      source.Title,
+				StartIndex: offset + int(support.Segment.StartIndex),
+				EndIndex:   offset + int(support.Segment.EndIndex),
+				Text:       support.Segment.Text,
+			})
+		}
+	}
+
+	return grounding
+}
+
+func mapGroundingChunk(chunk *genai.GroundingChunk) params.Source {
+	switch {
+	case chunk == nil:
+		return params.Source{}
+	case chunk.Web != nil:
+		return params.Source{URL: chunk.Web.URI, Title: chunk.Web.Title}
+	case chunk.RetrievedContext != nil:
+		return params.Source{URL: chunk.RetrievedContext.URI, Title: chunk.RetrievedContext.Title}
+	default:
+		return params.Source{}
+	}
+}
+
+// answerPartOffsets returns the byte offset of every part in the answer text, which skips thoughts
+func answerPartOffsets(content *genai.Content) []int {
+	if content == nil {
+		return nil
+	}
+
+	offsets := make([]int, len(content.Parts))
+	position := 0
+	for i, part := range content.Parts {
+		offsets[i] = position
+		if !part.Thought {
+			position += len(part.Text)
+		}
+	}
+	return offsets
+}
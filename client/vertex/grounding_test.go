@@ -0,0 +1,65 @@
+package vertex
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// groundedResponse has a thought part and two answer parts with non-ASCII text. Segment offsets are
+// bytes within their part, so the second citation only lines up if the first part's bytes are counted.
+const groundedResponse = `{"candidates":[{"content":{"role":"model","parts":[` +
+	`{"text":"Looking up the café.","thought":true},{"text":"Le café ouvre à 8h. "},{"text":"Zürich est loin."}]},` +
+	`"groundingMetadata":{"webSearchQueries":["café zürich"],` +
+	`"groundingChunks":[{"web":{"uri":"https://example.com/cafe","title":"Café"}},{"web":{"uri":"https://example.com/zurich","title":"Zürich"}}],` +
+	`"groundingSupports":[` +
+	`{"segment":{"partIndex":1,"startIndex":3,"endIndex":8,"text":"café"},"groundingChunkIndices":[0]},` +
+	`{"segment":{"partIndex":2,"startIndex":0,"endIndex":7,"text":"Zürich"},"groundingChunkIndices":[1,5]}]}}]}`
+
+func TestSendCompletionMessageReturnsGrounding(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, groundedResponse)
+	}, params.StructuredOutputNative)
+
+	response, err := c.SendCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Is the café in Zürich open?"), params.Settings{ModelName: "gemini-2.5-flash", IsSearchEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grounding := response.Grounding
+	if grounding == nil || len(grounding.Sources) != 2 || len(grounding.SearchQueries) != 1 {
+		t.Fatalf("expected two sources and a search query, got %+v", grounding)
+	}
+	// The out-of-range chunk index is skipped
+	if len(grounding.Citations) != 2 {
+		t.Fatalf("expected two citations, got %+v", grounding.Citations)
+	}
+	for _, citation := range grounding.Citations {
+		if span := response.Content[citation.StartIndex:citation.EndIndex]; span != citation.Text {
+			t.Errorf("expected the offsets to slice %q from the reply, got %q", citation.Text, span)
+		}
+	}
+	if url := grounding.Citations[1].URL; url != "https://example.com/zurich" {
+		t.Errorf("expected the second citation to point at its chunk, got %q", url)
+	}
+}
+
+func TestStreamCompletionMessageReturnsGrounding(t *testing.T) {
+	c := newTestClient(t, serveEvents(groundedResponse), params.StructuredOutputNative)
+
+	chunks, err := c.StreamCompletionMessage(context.Background(),
+		params.NewSimplePrompt("", "Is the café in Zürich open?"), params.Settings{ModelName: "gemini-2.5-flash", IsSearchEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, final := collect(t, chunks)
+	if final.Grounding == nil || len(final.Grounding.Citations) != 2 {
+		t.Fatalf("expected the final chunk to carry two citations, got %+v", final.Grounding)
+	}
+}
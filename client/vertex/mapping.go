@@ -3,10 +3,12 @@ package vertex
 import (
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+	"github.com/jamesleeht/llm-gopher/structured"
 
-	"github.com/invopop/jsonschema"
 	"google.golang.org/genai"
 )
 
@@ -20,37 +22,141 @@ func mapPromptToMessages(prompt params.Prompt) []*genai.Content {
 		case params.MessageRoleUser:
 			role = genai.RoleUser
 		}
-		messages = append(messages, genai.NewContentFromText(message.Content, role))
+		content := genai.NewContentFromText(message.Content, role)
+		// Gemini needs the signature back on the part it was returned with to continue its reasoning
+		if message.ThoughtSignature != nil {
+			content.Parts[0].ThoughtSignature = message.ThoughtSignature
+		}
+		messages = append(messages, content)
 	}
 	return messages
 }
 
-func mapSettingsToVertexSettings(prompt params.Prompt, settings params.Settings) (*genai.GenerateContentConfig, error) {
-	if settings.IsSearchEnabled && prompt.ResponseFormat != nil {
-		return nil, fmt.Errorf("gemini - response format is not supported when search is enabled")
+// mapThoughts returns the thought summaries and the last thought signature of the first candidate
+func mapThoughts(resp *genai.GenerateContentResponse) (string, []byte) {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return "", nil
+	}
+
+	var reasoning strings.Builder
+	var signature []byte
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if part.Thought && part.Text != "" {
+			reasoning.WriteString(part.Text)
+		}
+		if part.ThoughtSignature != nil {
+			signature = part.ThoughtSignature
+		}
+	}
+	return reasoning.String(), signature
+}
+
+// firstCandidate returns a copy of a streamed response with only the candidate at index 0,
+// or with none if the chunk only holds other candidates
+func firstCandidate(resp *genai.GenerateContentResponse) *genai.GenerateContentResponse {
+	if len(resp.Candidates) == 1 && resp.Candidates[0].Index == 0 {
+		return resp
+	}
+
+	filtered := *resp
+	filtered.Candidates = nil
+	for _, candidate := range resp.Candidates {
+		if candidate.Index == 0 {
+			filtered.Candidates = []*genai.Candidate{candidate}
+			break
+		}
 	}
+	return &filtered
+}
 
-	safetySettings := []*genai.SafetySetting{
-		{
-			Category:  genai.HarmCategoryUnspecified,
-			Threshold: genai.HarmBlockThresholdOff,
-		},
-		{
-			Category:  genai.HarmCategorySexuallyExplicit,
-			Threshold: genai.HarmBlockThresholdOff,
-		},
-		{
-			Category:  genai.HarmCategoryHateSpeech,
-			Threshold: genai.HarmBlockThresholdOff,
-		},
-		{
-			Category:  genai.HarmCategoryHarassment,
-			Threshold: genai.HarmBlockThresholdOff,
-		},
-		{
-			Category:  genai.HarmCategoryDangerousContent,
-			Threshold: genai.HarmBlockThresholdOff,
-		},
+// mapCandidates returns the text of every candidate, or nil if there is only one
+func mapCandidates(resp *genai.GenerateContentResponse) []string {
+	if len(resp.Candidates) < 2 {
+		return nil
+	}
+
+	candidates := make([]string, 0, len(resp.Candidates))
+	for _, candidate := range resp.Candidates {
+		var text strings.Builder
+		if candidate.Content != nil {
+			for _, part := range candidate.Content.Parts {
+				if !part.Thought {
+					text.WriteString(part.Text)
+				}
+			}
+		}
+		candidates = append(candidates, text.String())
+	}
+	return candidates
+}
+
+func mapLogprobs(resp *genai.GenerateContentResponse) []params.TokenLogprob {
+	if len(resp.Candidates) == 0 || resp.Candidates[0].LogprobsResult == nil {
+		return nil
+	}
+
+	logprobsResult := resp.Candidates[0].LogprobsResult
+	result := make([]params.TokenLogprob, 0, len(logprobsResult.ChosenCandidates))
+	for i, chosen := range logprobsResult.ChosenCandidates {
+		tokenLogprob := params.TokenLogprob{
+			Token:   chosen.Token,
+			Logprob: float64(chosen.LogProbability),
+		}
+		// Top candidates are listed per decoding step, in the same order as the chosen tokens
+		if i < len(logprobsResult.TopCandidates) && logprobsResult.TopCandidates[i] != nil {
+			for _, top := range logprobsResult.TopCandidates[i].Candidates {
+				tokenLogprob.TopLogprobs = append(tokenLogprob.TopLogprobs, params.TopLogprob{
+					Token:   top.Token,
+					Logprob: float64(top.LogProbability),
+				})
+			}
+		}
+		result = append(result, tokenLogprob)
+	}
+	return result
+}
+
+// validateSettings rejects sampling parameters that Gemini cannot express
+func validateSettings(settings params.Settings) error {
+	if len(settings.LogitBias) > 0 {
+		return fmt.Errorf("gemini - logit bias is not supported")
+	}
+	if settings.TopLogprobs != nil && !settings.Logprobs {
+		return fmt.Errorf("gemini - top logprobs requires logprobs to be enabled")
+	}
+	return validateSafetySettings(settings.Safety)
+}
+
+func float32Ptr(v *float64) *float32 {
+	if v == nil {
+		return nil
+	}
+	f := float32(*v)
+	return &f
+}
+
+func int32Ptr(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	i := int32(*v)
+	return &i
+}
+
+func mapSettingsToVertexSettings(prompt params.Prompt,
+	settings params.Settings,
+	structuredOutputMode params.StructuredOutputMode) (*genai.GenerateContentConfig, error) {
+	if settings.IsSearchEnabled && prompt.ResponseFormat != nil && structuredOutputMode != params.StructuredOutputNone {
+		return nil, fmt.Errorf("gemini - response format is not supported when search is enabled")
+	}
+	if err := validateSettings(settings); err != nil {
+		return nil, err
+	}
+	if prompt.PreviousResponseID != "" {
+		return nil, fmt.Errorf("gemini - previous response id is not supported")
+	}
+	if len(prompt.Documents) > 0 {
+		return nil, fmt.Errorf("gemini - documents are not supported")
 	}
 
 	var tools []*genai.Tool
@@ -61,25 +167,58 @@ func mapSettingsToVertexSettings(prompt params.Prompt, settings params.Settings)
 		}
 	}
 
-	respFormat := mapResponseFormatToVertexResponseFormat(prompt)
+	var respFormat any
 	respMimeType := ""
-	if respFormat != nil {
-		respMimeType = "application/json"
+	switch structuredOutputMode {
+	case params.StructuredOutputJSONObject, params.StructuredOutputNone:
+		// Describe the schema in the system instruction instead, the reply is still validated against it
+		if prompt.ResponseFormat != nil {
+			var err error
+			if prompt, err = structured.WithSchemaInstruction(prompt); err != nil {
+				return nil, err
+			}
+			if structuredOutputMode == params.StructuredOutputJSONObject {
+				respMimeType = "application/json"
+			}
+		}
+	default:
+		respFormat = mapResponseFormatToVertexResponseFormat(prompt)
+		if respFormat != nil {
+			respMimeType = "application/json"
+		}
 	}
 
 	var genaiThinkingConfig *genai.ThinkingConfig
 	thinkingBudget := getThinkingBudget(settings.ThinkingBudget)
-	if thinkingBudget > 0 {
+	hasBudget := thinkingBudget > 0
+	if settings.ThinkingTokens != nil {
+		// An explicit budget is always sent, so 0 disables thinking and -1 enables dynamic thinking
+		thinkingBudget = int32(*settings.ThinkingTokens)
+		hasBudget = true
+	}
+	if hasBudget || settings.IncludeReasoning {
 		genaiThinkingConfig = &genai.ThinkingConfig{
-			IncludeThoughts: false,
-			ThinkingBudget:  &thinkingBudget,
+			IncludeThoughts: settings.IncludeReasoning,
 		}
+		if hasBudget {
+			genaiThinkingConfig.ThinkingBudget = &thinkingBudget
+		}
+	}
+
+	var maxOutputTokens int32
+	if settings.MaxOutputTokens != nil {
+		maxOutputTokens = int32(*settings.MaxOutputTokens)
+	}
+
+	var topK *float32
+	if settings.TopK != nil {
+		v := float32(*settings.TopK)
+		topK = &v
 	}
 
-	var temperature *float32
-	if settings.Temperature != nil {
-		v := float32(*settings.Temperature)
-		temperature = &v
+	var candidateCount int32
+	if settings.CandidateCount != nil {
+		candidateCount = int32(*settings.CandidateCount)
 	}
 
 	var systemInstruction *genai.Content
@@ -87,15 +226,28 @@ func mapSettingsToVertexSettings(prompt params.Prompt, settings params.Settings)
 		systemInstruction = &genai.Content{Parts: []*genai.Part{{Text: prompt.SystemMessage}}}
 	}
 
-	return &genai.GenerateContentConfig{
+	config := &genai.GenerateContentConfig{
 		SystemInstruction:  systemInstruction,
-		Temperature:        temperature,
-		SafetySettings:     safetySettings,
+		Temperature:        float32Ptr(settings.Temperature),
+		TopP:               float32Ptr(settings.TopP),
+		TopK:               topK,
+		Seed:               int32Ptr(settings.Seed),
+		StopSequences:      settings.StopSequences,
+		PresencePenalty:    float32Ptr(settings.PresencePenalty),
+		FrequencyPenalty:   float32Ptr(settings.FrequencyPenalty),
+		CandidateCount:     candidateCount,
+		ResponseLogprobs:   settings.Logprobs,
+		Logprobs:           int32Ptr(settings.TopLogprobs),
+		MaxOutputTokens:    maxOutputTokens,
+		SafetySettings:     mapSafetySettings(settings.Safety),
 		ThinkingConfig:     genaiThinkingConfig,
 		Tools:              tools,
 		ResponseJsonSchema: respFormat,
 		ResponseMIMEType:   respMimeType,
-	}, nil
+	}
+	applyOptions(config, optionsFromSettings(settings))
+
+	return config, nil
 }
 
 func mapResponseFormatToVertexResponseFormat(prompt params.Prompt) any {
@@ -103,27 +255,7 @@ func mapResponseFormatToVertexResponseFormat(prompt params.Prompt) any {
 	if prompt.ResponseFormat == nil {
 		return nil
 	}
-	// Create a zero value of the type and generate schema
-	return generateSchemaFromType(reflect.TypeOf(prompt.ResponseFormat))
-}
-
-func generateSchemaFromType(t reflect.Type) interface{} {
-	// Structured Outputs uses a subset of JSON schema
-	// These flags are necessary to comply with the subset
-	reflector := jsonschema.Reflector{
-		AllowAdditionalProperties: false,
-		DoNotReference:            true,
-	}
-
-	// If it's a pointer type, get the element type
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-
-	// Create a zero value of the type
-	v := reflect.New(t).Elem().Interface()
-	schema := reflector.Reflect(v)
-	return schema
+	return schema.ForGemini(reflect.TypeOf(prompt.ResponseFormat))
 }
 
 func getThinkingBudget(thinkingBudget params.ThinkingBudget) int32 {
@@ -0,0 +1,61 @@
+package vertex
+
+import (
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"google.golang.org/genai"
+)
+
+const providerName = "vertex"
+
+// geminiProviderName names the Gemini Developer API in provider errors
+const geminiProviderName = "gemini"
+
+// Options are Gemini specific settings. Add them to params.Settings.ProviderOptions.
+type Options struct {
+	Labels          map[string]string
+	MediaResolution genai.MediaResolution
+	// CachedContent is the resource name of a context cache to use as a prefix of the prompt
+	CachedContent string
+}
+
+func (o Options) ProviderName() string {
+	return providerName
+}
+
+// optionsFromSettings returns the Gemini options of the settings, ignoring other providers' options.
+// If several are given, later options override earlier ones field by field.
+func optionsFromSettings(settings params.Settings) Options {
+	var result Options
+	for _, providerOptions := range settings.ProviderOptions {
+		var opts Options
+		switch o := providerOptions.(type) {
+		case Options:
+			opts = o
+		case *Options:
+			if o == nil {
+				continue
+			}
+			opts = *o
+		default:
+			continue
+		}
+
+		if opts.Labels != nil {
+			result.Labels = opts.Labels
+		}
+		if opts.MediaResolution != "" {
+			result.MediaResolution = opts.MediaResolution
+		}
+		if opts.CachedContent != "" {
+			result.CachedContent = opts.CachedContent
+		}
+	}
+	return result
+}
+
+func applyOptions(config *genai.GenerateContentConfig, opts Options) {
+	config.Labels = opts.Labels
+	config.MediaResolution = opts.MediaResolution
+	config.CachedContent = opts.CachedContent
+}
@@ -0,0 +1,87 @@
+package vertex
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"google.golang.org/genai"
+)
+
+// otherOptions belong to another provider and must be ignored
+type otherOptions struct {
+	Labels map[string]string
+}
+
+func (o otherOptions) ProviderName() string {
+	return "other"
+}
+
+func TestOptionsFromSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []params.ProviderOptions
+		expected Options
+	}{
+		{name: "none"},
+		{
+			name:     "value",
+			options:  []params.ProviderOptions{Options{Labels: map[string]string{"team": "search"}}},
+			expected: Options{Labels: map[string]string{"team": "search"}},
+		},
+		{
+			name:     "pointer",
+			options:  []params.ProviderOptions{&Options{CachedContent: "cachedContents/1"}},
+			expected: Options{CachedContent: "cachedContents/1"},
+		},
+		{
+			name:    "nil pointer",
+			options: []params.ProviderOptions{(*Options)(nil)},
+		},
+		{
+			name:    "other provider",
+			options: []params.ProviderOptions{otherOptions{Labels: map[string]string{"team": "search"}}},
+		},
+		{
+			name: "later options override field by field",
+			options: []params.ProviderOptions{
+				Options{Labels: map[string]string{"team": "search"}, MediaResolution: genai.MediaResolutionLow},
+				Options{MediaResolution: genai.MediaResolutionHigh},
+			},
+			expected: Options{Labels: map[string]string{"team": "search"}, MediaResolution: genai.MediaResolutionHigh},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := optionsFromSettings(params.Settings{ProviderOptions: test.options})
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestOptionsAreApplied(t *testing.T) {
+	settings := params.Settings{
+		ModelName: "gemini-2.5-flash",
+		ProviderOptions: []params.ProviderOptions{
+			otherOptions{Labels: map[string]string{"team": "other"}},
+			&Options{
+				Labels:          map[string]string{"team": "search"},
+				MediaResolution: genai.MediaResolutionLow,
+				CachedContent:   "cachedContents/1",
+			},
+		},
+	}
+
+	config, err := mapSettingsToVertexSettings(params.NewSimplePrompt("", "Hi"), settings, params.StructuredOutputNative)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Labels["team"] != "search" || config.MediaResolution != genai.MediaResolutionLow || config.CachedContent != "cachedContents/1" {
+		t.Errorf("expected the Gemini options in the config, got labels %v, resolution %q and cache %q",
+			config.Labels, config.MediaResolution, config.CachedContent)
+	}
+}
@@ -0,0 +1,135 @@
+package vertex
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"google.golang.org/genai"
+)
+
+var harmCategories = map[params.HarmCategory]genai.HarmCategory{
+	params.HarmCategoryHarassment:       genai.HarmCategoryHarassment,
+	params.HarmCategoryHateSpeech:       genai.HarmCategoryHateSpeech,
+	params.HarmCategorySexuallyExplicit: genai.HarmCategorySexuallyExplicit,
+	params.HarmCategoryDangerousContent: genai.HarmCategoryDangerousContent,
+	params.HarmCategoryCivicIntegrity:   genai.HarmCategoryCivicIntegrity,
+}
+
+var safetyThresholds = map[params.SafetyThreshold]genai.HarmBlockThreshold{
+	params.SafetyThresholdOff:                 genai.HarmBlockThresholdOff,
+	params.SafetyThresholdBlockNone:           genai.HarmBlockThresholdBlockNone,
+	params.SafetyThresholdBlockOnlyHigh:       genai.HarmBlockThresholdBlockOnlyHigh,
+	params.SafetyThresholdBlockMediumAndAbove: genai.HarmBlockThresholdBlockMediumAndAbove,
+	params.SafetyThresholdBlockLowAndAbove:    genai.HarmBlockThresholdBlockLowAndAbove,
+}
+
+// blockedFinishReasons are the finish reasons that mean the reply was cut off by a filter
+var blockedFinishReasons = map[genai.FinishReason]bool{
+	genai.FinishReasonSafety:                 true,
+	genai.FinishReasonBlocklist:              true,
+	genai.FinishReasonProhibitedContent:      true,
+	genai.FinishReasonSPII:                   true,
+	genai.FinishReasonImageSafety:            true,
+	genai.FinishReasonImageProhibitedContent: true,
+}
+
+func validateSafetySettings(safety *params.SafetySettings) error {
+	if safety == nil {
+		return nil
+	}
+	if _, ok := safetyThresholds[safety.Default]; safety.Default != "" && !ok {
+		return fmt.Errorf("gemini - unknown safety threshold %q", safety.Default)
+	}
+	for category, threshold := range safety.Categories {
+		if _, ok := harmCategories[category]; !ok {
+			return fmt.Errorf("gemini - unknown harm category %q", category)
+		}
+		if _, ok := safetyThresholds[threshold]; !ok {
+			return fmt.Errorf("gemini - unknown safety threshold %q for %s", threshold, category)
+		}
+	}
+	return nil
+}
+
+// mapSafetySettings turns every filter off when no safety settings are given,
+// and otherwise sends the configured threshold of every category that has one
+func mapSafetySettings(safety *params.SafetySettings) []*genai.SafetySetting {
+	if safety == nil {
+		safetySettings := []*genai.SafetySetting{
+			{
+				Category:  genai.HarmCategoryUnspecified,
+				Threshold: genai.HarmBlockThresholdOff,
+			},
+		}
+		for _, category := range []params.HarmCategory{
+			params.HarmCategorySexuallyExplicit,
+			params.HarmCategoryHateSpeech,
+			params.HarmCategoryHarassment,
+			params.HarmCategoryDangerousContent,
+		} {
+			safetySettings = append(safetySettings, &genai.SafetySetting{
+				Category:  harmCategories[category],
+				Threshold: genai.HarmBlockThresholdOff,
+			})
+		}
+		return safetySettings
+	}
+
+	var safetySettings []*genai.SafetySetting
+	for _, category := range []params.HarmCategory{
+		params.HarmCategoryHarassment,
+		params.HarmCategoryHateSpeech,
+		params.HarmCategorySexuallyExplicit,
+		params.HarmCategoryDangerousContent,
+		params.HarmCategoryCivicIntegrity,
+	} {
+		threshold := safety.Threshold(category)
+		if threshold == "" {
+			continue
+		}
+		safetySettings = append(safetySettings, &genai.SafetySetting{
+			Category:  harmCategories[category],
+			Threshold: safetyThresholds[threshold],
+		})
+	}
+	return safetySettings
+}
+
+func mapSafetyRatings(ratings []*genai.SafetyRating) []params.SafetyRating {
+	var result []params.SafetyRating
+	for _, rating := range ratings {
+		if rating == nil {
+			continue
+		}
+		result = append(result, params.SafetyRating{
+			Category:    params.HarmCategory(strings.ToLower(strings.TrimPrefix(string(rating.Category), "HARM_CATEGORY_"))),
+			Probability: string(rating.Probability),
+			Blocked:     rating.Blocked,
+		})
+	}
+	return result
+}
+
+// contentFilterError returns a *params.ContentFilterError if the prompt or the reply was blocked
+func contentFilterError(resp *genai.GenerateContentResponse) error {
+	if feedback := resp.PromptFeedback; feedback != nil && feedback.BlockReason != "" {
+		return &params.ContentFilterError{
+			PromptBlocked: true,
+			Reason:        string(feedback.BlockReason),
+			Message:       feedback.BlockReasonMessage,
+			SafetyRatings: mapSafetyRatings(feedback.SafetyRatings),
+		}
+	}
+
+	if len(resp.Candidates) > 0 && blockedFinishReasons[resp.Candidates[0].FinishReason] {
+		candidate := resp.Candidates[0]
+		return &params.ContentFilterError{
+			Reason:        string(candidate.FinishReason),
+			Message:       candidate.FinishMessage,
+			SafetyRatings: mapSafetyRatings(candidate.SafetyRatings),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package vertex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamesleeht/llm-gopher/params"
+
+	"google.golang.org/genai"
+)
+
+// CountTokens counts the prompt size with the Vertex CountTokens endpoint
+func (c *Client) CountTokens(ctx context.Context, prompt params.Prompt, settings params.Settings) (int, error) {
+	config := &genai.CountTokensConfig{}
+	messages := mapPromptToMessages(prompt)
+
+	if prompt.SystemMessage != "" {
+		if c.backend == genai.BackendGeminiAPI {
+			// The Gemini Developer API doesn't take a system instruction here, so count it as a message
+			messages = append([]*genai.Content{genai.NewContentFromText(prompt.SystemMessage, genai.RoleUser)}, messages...)
+		} else {
+			config.SystemInstruction = &genai.Content{Parts: []*genai.Part{{Text: prompt.SystemMessage}}}
+		}
+	}
+
+	resp, err := c.internalClient.Models.CountTokens(ctx, settings.ModelName, messages, config)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", c.providerError(err))
+	}
+
+	return int(resp.TotalTokens), nil
+}
@@ -0,0 +1,280 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+type Strategy string
+
+const (
+	// StrategyDropOldest removes the oldest turns until the prompt fits
+	StrategyDropOldest Strategy = "drop_oldest"
+	// StrategyKeepFirstLast keeps the first KeepFirst and the last KeepLast turns
+	StrategyKeepFirstLast Strategy = "keep_first_last"
+	// StrategySummarize replaces everything except the last KeepLast turns with a summary
+	// generated by SummaryPreset. Turns are dropped as well if the summarized prompt still doesn't fit.
+	StrategySummarize Strategy = "summarize"
+)
+
+const defaultSummaryInstruction = "Summarize the following conversation between a user and an assistant. " +
+	"Keep every fact, decision and open question that later turns may depend on. Reply with the summary only."
+
+const summaryPrefix = "Summary of the earlier conversation:\n"
+
+type HistoryConfig struct {
+	// Router counts tokens with the clients of the preset's model, which must implement client.TokenCounter.
+	// Every strategy fails for clients that can't count tokens.
+	Router   *router.Router
+	Strategy Strategy
+
+	// KeepFirst and KeepLast are numbers of turns, where a turn starts at a user message.
+	// KeepLast is also the number of recent turns that StrategySummarize keeps verbatim.
+	KeepFirst int
+	KeepLast  int
+
+	// SummaryPreset is the router preset used by StrategySummarize, usually a cheap model
+	SummaryPreset      string
+	SummaryInstruction string
+}
+
+// HistoryManager trims the messages of a prompt to fit a token budget.
+// The system message and pinned messages are always kept.
+type HistoryManager struct {
+	config HistoryConfig
+}
+
+func NewHistoryManager(config HistoryConfig) (*HistoryManager, error) {
+	if config.Router == nil {
+		return nil, fmt.Errorf("history manager requires a router")
+	}
+	if config.KeepFirst < 0 || config.KeepLast < 0 {
+		return nil, fmt.Errorf("turns to keep cannot be negative")
+	}
+
+	switch config.Strategy {
+	case StrategyDropOldest:
+	case StrategyKeepFirstLast:
+		if config.KeepFirst == 0 && config.KeepLast == 0 {
+			return nil, fmt.Errorf("keep first/last strategy requires KeepFirst or KeepLast")
+		}
+	case StrategySummarize:
+		if config.SummaryPreset == "" {
+			return nil, fmt.Errorf("summarize strategy requires a summary preset")
+		}
+		if config.SummaryInstruction == "" {
+			config.SummaryInstruction = defaultSummaryInstruction
+		}
+	default:
+		return nil, fmt.Errorf("unknown truncation strategy: %s", config.Strategy)
+	}
+
+	return &HistoryManager{config: config}, nil
+}
+
+// Fit returns a copy of the prompt whose messages fit in tokenBudget input tokens for the model of the preset.
+// It returns a *params.ContextLengthError if the prompt can't be trimmed enough.
+func (m *HistoryManager) Fit(ctx context.Context,
+	prompt params.Prompt,
+	presetName string,
+	tokenBudget int) (params.Prompt, error) {
+	if tokenBudget <= 0 {
+		return params.Prompt{}, fmt.Errorf("token budget must be positive, got %d", tokenBudget)
+	}
+
+	preset, exists := m.config.Router.Preset(presetName)
+	if !exists {
+		return params.Prompt{}, fmt.Errorf("preset %s not found", presetName)
+	}
+	target := fitTarget{presetName: presetName, modelName: preset.ModelName, tokenBudget: tokenBudget}
+
+	fits, err := m.fits(ctx, prompt, target)
+	if err != nil || fits {
+		return prompt, err
+	}
+
+	switch m.config.Strategy {
+	case StrategyKeepFirstLast:
+		return m.keepFirstLast(ctx, prompt, target)
+	case StrategySummarize:
+		return m.summarize(ctx, prompt, target)
+	default:
+		return m.dropOldest(ctx, prompt, target)
+	}
+}
+
+// fitTarget is the preset a prompt is fitted for and its token budget
+type fitTarget struct {
+	presetName  string
+	modelName   string
+	tokenBudget int
+}
+
+func (m *HistoryManager) dropOldest(ctx context.Context,
+	prompt params.Prompt,
+	target fitTarget) (params.Prompt, error) {
+	turns := turnIndexes(prompt.Messages)
+	turnCount := countTurns(turns)
+
+	// Dropping more turns never makes the prompt larger, so binary search the fewest turns to drop.
+	// The last turn is always kept since it holds the message being answered.
+	var searchErr error
+	dropped := sort.Search(turnCount, func(drop int) bool {
+		if searchErr != nil || drop == turnCount-1 {
+			return true
+		}
+		fits, err := m.fits(ctx, keepTurns(prompt, turns, func(turn int) bool {
+			return turn >= drop
+		}), target)
+		if err != nil {
+			searchErr = err
+		}
+		return fits
+	})
+	if searchErr != nil {
+		return params.Prompt{}, searchErr
+	}
+
+	trimmed := keepTurns(prompt, turns, func(turn int) bool {
+		return turn >= dropped
+	})
+	return trimmed, m.ensureFits(ctx, trimmed, target)
+}
+
+func (m *HistoryManager) keepFirstLast(ctx context.Context,
+	prompt params.Prompt,
+	target fitTarget) (params.Prompt, error) {
+	turns := turnIndexes(prompt.Messages)
+	turnCount := countTurns(turns)
+
+	trimmed := keepTurns(prompt, turns, func(turn int) bool {
+		return turn < m.config.KeepFirst || turn >= turnCount-m.config.KeepLast
+	})
+	return trimmed, m.ensureFits(ctx, trimmed, target)
+}
+
+func (m *HistoryManager) summarize(ctx context.Context,
+	prompt params.Prompt,
+	target fitTarget) (params.Prompt, error) {
+	turns := turnIndexes(prompt.Messages)
+	firstKept := max(countTurns(turns)-m.config.KeepLast, 0)
+
+	var older []params.Message
+	var pinned []params.Message
+	var recent []params.Message
+	for i, message := range prompt.Messages {
+		switch {
+		case turns[i] >= firstKept:
+			recent = append(recent, message)
+		case message.Pinned:
+			pinned = append(pinned, message)
+		default:
+			older = append(older, message)
+		}
+	}
+
+	if len(older) == 0 {
+		return m.dropOldest(ctx, prompt, target)
+	}
+
+	summary, err := m.summarizeMessages(ctx, older)
+	if err != nil {
+		return params.Prompt{}, err
+	}
+
+	// The summary goes in the system message, since a message of its own could follow or precede
+	// another message of the same role, which Gemini and Bedrock reject
+	summarized := prompt
+	summarized.SystemMessage = summaryPrefix + summary
+	if prompt.SystemMessage != "" {
+		summarized.SystemMessage = prompt.SystemMessage + "\n\n" + summarized.SystemMessage
+	}
+	summarized.Messages = append(pinned, recent...)
+
+	fits, err := m.fits(ctx, summarized, target)
+	if err != nil || fits {
+		return summarized, err
+	}
+	return m.dropOldest(ctx, summarized, target)
+}
+
+func (m *HistoryManager) summarizeMessages(ctx context.Context, messages []params.Message) (string, error) {
+	var transcript strings.Builder
+	for _, message := range messages {
+		transcript.WriteString(string(message.Role))
+		transcript.WriteString(": ")
+		transcript.WriteString(message.Content)
+		transcript.WriteString("\n\n")
+	}
+
+	response, err := m.config.Router.SendPrompt(ctx,
+		m.config.SummaryPreset,
+		params.NewSimplePrompt(m.config.SummaryInstruction, transcript.String()))
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize conversation: %w", err)
+	}
+	return response.Content, nil
+}
+
+func (m *HistoryManager) fits(ctx context.Context, prompt params.Prompt, target fitTarget) (bool, error) {
+	tokens, err := m.config.Router.CountTokens(ctx, target.modelName, prompt)
+	if err != nil {
+		return false, err
+	}
+	return tokens <= target.tokenBudget, nil
+}
+
+func (m *HistoryManager) ensureFits(ctx context.Context, prompt params.Prompt, target fitTarget) error {
+	tokens, err := m.config.Router.CountTokens(ctx, target.modelName, prompt)
+	if err != nil {
+		return err
+	}
+	if tokens > target.tokenBudget {
+		return &params.ContextLengthError{
+			PresetName:    target.presetName,
+			ModelName:     target.modelName,
+			PromptTokens:  tokens,
+			ContextWindow: target.tokenBudget,
+		}
+	}
+	return nil
+}
+
+// turnIndexes returns the turn of every message. A new turn starts at every user message.
+func turnIndexes(messages []params.Message) []int {
+	turns := make([]int, len(messages))
+	turn := -1
+	for i, message := range messages {
+		if message.Role == params.MessageRoleUser || turn < 0 {
+			turn++
+		}
+		turns[i] = turn
+	}
+	return turns
+}
+
+func countTurns(turns []int) int {
+	if len(turns) == 0 {
+		return 0
+	}
+	return turns[len(turns)-1] + 1
+}
+
+// keepTurns copies the prompt with the messages of the turns that keep returns true for, plus pinned messages
+func keepTurns(prompt params.Prompt, turns []int, keep func(turn int) bool) params.Prompt {
+	messages := []params.Message{}
+	for i, message := range prompt.Messages {
+		if message.Pinned || keep(turns[i]) {
+			messages = append(messages, message)
+		}
+	}
+
+	trimmed := prompt
+	trimmed.Messages = messages
+	return trimmed
+}
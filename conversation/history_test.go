@@ -0,0 +1,222 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+// stubProvider answers every prompt with the same reply or error and records the prompts
+type stubProvider struct {
+	reply   string
+	err     error
+	prompts []params.Prompt
+}
+
+func (p *stubProvider) SendCompletionMessage(_ context.Context, prompt params.Prompt, _ params.Settings) (*params.Response, error) {
+	p.prompts = append(p.prompts, prompt)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &params.Response{Content: p.reply}, nil
+}
+
+func (p *stubProvider) StreamCompletionMessage(context.Context, params.Prompt, params.Settings) (<-chan params.StreamChunk, error) {
+	return nil, errors.New("streaming is not supported")
+}
+
+// countingProvider counts a token per byte of the system message and message content
+type countingProvider struct {
+	*stubProvider
+}
+
+func (p countingProvider) CountTokens(_ context.Context, prompt params.Prompt, _ params.Settings) (int, error) {
+	count := len(prompt.SystemMessage)
+	for _, message := range prompt.Messages {
+		count += len(message.Content)
+	}
+	return count, nil
+}
+
+func newTestRouter(t *testing.T, provider client.ProviderClient) *router.Router {
+	t.Helper()
+	r, err := router.NewRouter(
+		router.ClientMap{"model": {{Provider: provider, ClientType: client.ClientTypeOpenAI}}},
+		router.PresetMap{"chat": {ModelName: "model"}, "summary": {ModelName: "model"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func chat(contents ...string) params.Prompt {
+	prompt := params.Prompt{}
+	for i, content := range contents {
+		role := params.MessageRoleUser
+		if i%2 == 1 {
+			role = params.MessageRoleAssistant
+		}
+		prompt.Messages = append(prompt.Messages, params.Message{Role: role, Content: content})
+	}
+	return prompt
+}
+
+func contents(prompt params.Prompt) string {
+	var contents []string
+	for _, message := range prompt.Messages {
+		contents = append(contents, message.Content)
+	}
+	return strings.Join(contents, ",")
+}
+
+func TestFitDropsOldestTurns(t *testing.T) {
+	history, err := NewHistoryManager(HistoryConfig{
+		Router:   newTestRouter(t, countingProvider{&stubProvider{}}),
+		Strategy: StrategyDropOldest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fitted, err := history.Fit(context.Background(), chat("aaaa", "bbbb", "cccc", "dddd", "ee"), "chat", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(fitted); got != "cccc,dddd,ee" {
+		t.Errorf("expected the last two turns, got %s", got)
+	}
+}
+
+func TestFitReturnsContextLengthErrorWithPreset(t *testing.T) {
+	history, err := NewHistoryManager(HistoryConfig{
+		Router:   newTestRouter(t, countingProvider{&stubProvider{}}),
+		Strategy: StrategyDropOldest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = history.Fit(context.Background(), chat("aaaa", "bbbb", "a much too long question"), "chat", 10)
+	var contextErr *params.ContextLengthError
+	if !errors.As(err, &contextErr) {
+		t.Fatalf("expected a context length error, got %v", err)
+	}
+	if contextErr.PresetName != "chat" || contextErr.ModelName != "model" {
+		t.Errorf("expected preset chat and model model, got %+v", contextErr)
+	}
+}
+
+func TestFitRequiresTokenCounter(t *testing.T) {
+	history, err := NewHistoryManager(HistoryConfig{
+		Router:   newTestRouter(t, &stubProvider{}),
+		Strategy: StrategyDropOldest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := history.Fit(context.Background(), chat("hello"), "chat", 10); err == nil {
+		t.Error("expected an error for a client that can't count tokens")
+	}
+	if _, err := history.Fit(context.Background(), chat("hello"), "missing", 10); err == nil {
+		t.Error("expected an error for an unknown preset")
+	}
+}
+
+func TestFitKeepsFirstAndLastTurns(t *testing.T) {
+	history, err := NewHistoryManager(HistoryConfig{
+		Router:    newTestRouter(t, countingProvider{&stubProvider{}}),
+		Strategy:  StrategyKeepFirstLast,
+		KeepFirst: 1,
+		KeepLast:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := chat("aaaa", "bbbb", "cccc", "dddd", "ee", "ff", "gg")
+	// Pinned messages are kept even in a dropped turn
+	prompt.Messages[3].Pinned = true
+
+	fitted, err := history.Fit(context.Background(), prompt, "chat", 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(fitted); got != "aaaa,bbbb,dddd,gg" {
+		t.Errorf("expected the first and last turns and the pinned message, got %s", got)
+	}
+
+	_, err = history.Fit(context.Background(), prompt, "chat", 10)
+	if !errors.As(err, new(*params.ContextLengthError)) {
+		t.Errorf("expected a context length error when the kept turns don't fit, got %v", err)
+	}
+}
+
+func TestFitSummarizesOlderTurns(t *testing.T) {
+	provider := &stubProvider{reply: "The user ordered tea."}
+	history, err := NewHistoryManager(HistoryConfig{
+		Router:        newTestRouter(t, countingProvider{provider}),
+		Strategy:      StrategySummarize,
+		KeepLast:      1,
+		SummaryPreset: "summary",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	long := strings.Repeat("a", 100)
+	prompt := chat(long, "Your order number is 42.", long, long, "Where is my order?")
+	prompt.SystemMessage = "Be brief."
+	prompt.Messages[1].Pinned = true
+
+	fitted, err := history.Fit(context.Background(), prompt, "chat", 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedSystem := "Be brief.\n\n" + summaryPrefix + "The user ordered tea."
+	if fitted.SystemMessage != expectedSystem {
+		t.Errorf("expected the summary in the system message, got %q", fitted.SystemMessage)
+	}
+	if got := contents(fitted); got != "Your order number is 42.,Where is my order?" {
+		t.Errorf("expected the pinned message and the last turn, got %s", got)
+	}
+	for i := 1; i < len(fitted.Messages); i++ {
+		if fitted.Messages[i].Role == fitted.Messages[i-1].Role {
+			t.Errorf("expected alternating roles, got two %s messages in a row", fitted.Messages[i].Role)
+		}
+	}
+
+	// The pinned message is kept verbatim, so it isn't summarized
+	if len(provider.prompts) != 1 {
+		t.Fatalf("expected one summary request, got %d", len(provider.prompts))
+	}
+	transcript := provider.prompts[0].Messages[0].Content
+	if strings.Count(transcript, long) != 3 || strings.Contains(transcript, "order number") {
+		t.Errorf("expected only the unpinned older messages in the transcript, got %q", transcript)
+	}
+}
+
+func TestFitReturnsSummarizerError(t *testing.T) {
+	errUnavailable := errors.New("summary model unavailable")
+	history, err := NewHistoryManager(HistoryConfig{
+		Router:        newTestRouter(t, countingProvider{&stubProvider{err: errUnavailable}}),
+		Strategy:      StrategySummarize,
+		KeepLast:      1,
+		SummaryPreset: "summary",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = history.Fit(context.Background(), chat("aaaa", "bbbb", "cccc"), "chat", 5)
+	if !errors.Is(err, errUnavailable) {
+		t.Errorf("expected the summarizer's error, got %v", err)
+	}
+}
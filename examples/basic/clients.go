@@ -5,6 +5,7 @@ import (
 
 	"github.com/jamesleeht/llm-gopher/client"
 	"github.com/jamesleeht/llm-gopher/examples/basic/enums/modelname"
+	"github.com/jamesleeht/llm-gopher/params"
 )
 
 func getClientMap(env appConfig) map[string][]*client.Client {
@@ -17,8 +18,9 @@ func getClientMap(env appConfig) map[string][]*client.Client {
 	}
 
 	novitaClient, err := client.NewClient(client.ClientConfig{
-		APIKey:  env.novitaKey,
-		BaseURL: "https://api.novita.ai/v3/openai",
+		APIKey:               env.novitaKey,
+		BaseURL:              "https://api.novita.ai/v3/openai",
+		StructuredOutputMode: params.StructuredOutputJSONObject,
 	}, client.ClientTypeOpenAI)
 	if err != nil {
 		log.Fatalf("failed to create novita client: %v", err)
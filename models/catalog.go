@@ -0,0 +1,116 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// ModelInfo describes the token limits of a model
+type ModelInfo struct {
+	// ContextWindow is the maximum number of input and output tokens combined
+	ContextWindow int
+	// MaxOutputTokens is the maximum number of tokens the model can generate in one response
+	MaxOutputTokens int
+	// MinThinkingTokens and MaxThinkingTokens bound a numeric thinking budget.
+	// MaxThinkingTokens is 0 for models without a numeric thinking budget.
+	MinThinkingTokens int
+	MaxThinkingTokens int
+	// ThinkingRequired is set for models where thinking can't be disabled
+	ThinkingRequired bool
+}
+
+// Catalog maps model names to their limits. A model name also matches dated or
+// suffixed variants, e.g. "gpt-4o" matches "gpt-4o-2024-08-06".
+type Catalog map[string]ModelInfo
+
+// DefaultCatalog returns a copy of the limits for commonly used models
+func DefaultCatalog() Catalog {
+	catalog := make(Catalog, len(defaultCatalog))
+	for modelName, info := range defaultCatalog {
+		catalog[modelName] = info
+	}
+	return catalog
+}
+
+var defaultCatalog = Catalog{
+	"gpt-3.5-turbo":              {ContextWindow: 16385, MaxOutputTokens: 4096},
+	"gpt-4":                      {ContextWindow: 8192, MaxOutputTokens: 8192},
+	"gpt-4-turbo":                {ContextWindow: 128000, MaxOutputTokens: 4096},
+	"gpt-4o":                     {ContextWindow: 128000, MaxOutputTokens: 16384},
+	"gpt-4o-mini":                {ContextWindow: 128000, MaxOutputTokens: 16384},
+	"gpt-4o-search-preview":      {ContextWindow: 128000, MaxOutputTokens: 16384},
+	"gpt-4o-mini-search-preview": {ContextWindow: 128000, MaxOutputTokens: 16384},
+	"gpt-4.1":                    {ContextWindow: 1047576, MaxOutputTokens: 32768},
+	"gpt-5":                      {ContextWindow: 400000, MaxOutputTokens: 128000},
+	"o1":                         {ContextWindow: 200000, MaxOutputTokens: 100000},
+	"o3":                         {ContextWindow: 200000, MaxOutputTokens: 100000},
+	"o4-mini":                    {ContextWindow: 200000, MaxOutputTokens: 100000},
+	"gemini-2.0-flash":           {ContextWindow: 1048576, MaxOutputTokens: 8192},
+	"gemini-2.5-flash": {ContextWindow: 1048576, MaxOutputTokens: 65536,
+		MinThinkingTokens: 1, MaxThinkingTokens: 24576},
+	"gemini-2.5-flash-lite": {ContextWindow: 1048576, MaxOutputTokens: 65536,
+		MinThinkingTokens: 512, MaxThinkingTokens: 24576},
+	"gemini-2.5-pro": {ContextWindow: 1048576, MaxOutputTokens: 65536,
+		MinThinkingTokens: 128, MaxThinkingTokens: 32768, ThinkingRequired: true},
+	"deepseek/deepseek-v3-turbo": {ContextWindow: 64000, MaxOutputTokens: 16000},
+	"deepseek/deepseek-v3.1":     {ContextWindow: 131072, MaxOutputTokens: 32768},
+}
+
+// Lookup returns the limits for the model, matching the longest catalog entry
+// that is either the exact model name or a "-" separated prefix of it
+func (c Catalog) Lookup(modelName string) (ModelInfo, bool) {
+	if info, ok := c[modelName]; ok {
+		return info, true
+	}
+
+	var best string
+	for name := range c {
+		if strings.HasPrefix(modelName, name+"-") && len(name) > len(best) {
+			best = name
+		}
+	}
+	if best == "" {
+		return ModelInfo{}, false
+	}
+	return c[best], true
+}
+
+// Validate checks the token settings of a preset against the limits of its model.
+// Models missing from the catalog are only checked for values that are never valid.
+func (c Catalog) Validate(settings params.Settings) error {
+	if settings.MaxOutputTokens != nil && *settings.MaxOutputTokens <= 0 {
+		return fmt.Errorf("max output tokens must be positive, got %d", *settings.MaxOutputTokens)
+	}
+	if settings.ThinkingTokens != nil && *settings.ThinkingTokens < params.DynamicThinkingTokens {
+		return fmt.Errorf("thinking tokens must be %d (dynamic) or at least 0, got %d",
+			params.DynamicThinkingTokens, *settings.ThinkingTokens)
+	}
+
+	info, ok := c.Lookup(settings.ModelName)
+	if !ok {
+		return nil
+	}
+
+	if settings.MaxOutputTokens != nil && info.MaxOutputTokens > 0 && *settings.MaxOutputTokens > info.MaxOutputTokens {
+		return fmt.Errorf("max output tokens %d exceeds the limit of %d for model %s",
+			*settings.MaxOutputTokens, info.MaxOutputTokens, settings.ModelName)
+	}
+
+	if settings.ThinkingTokens != nil && info.MaxThinkingTokens > 0 {
+		thinkingTokens := *settings.ThinkingTokens
+		switch {
+		case thinkingTokens == params.DynamicThinkingTokens:
+		case thinkingTokens == 0:
+			if info.ThinkingRequired {
+				return fmt.Errorf("thinking cannot be disabled for model %s", settings.ModelName)
+			}
+		case thinkingTokens < info.MinThinkingTokens || thinkingTokens > info.MaxThinkingTokens:
+			return fmt.Errorf("thinking tokens %d must be between %d and %d for model %s",
+				thinkingTokens, info.MinThinkingTokens, info.MaxThinkingTokens, settings.ModelName)
+		}
+	}
+
+	return nil
+}
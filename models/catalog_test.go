@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestCatalogLookup(t *testing.T) {
+	catalog := Catalog{
+		"gpt-4o":      {ContextWindow: 128000},
+		"gpt-4o-mini": {ContextWindow: 64000},
+	}
+
+	tests := []struct {
+		modelName string
+		expected  int
+		ok        bool
+	}{
+		{"gpt-4o", 128000, true},
+		{"gpt-4o-2024-08-06", 128000, true},
+		// The longest matching entry wins
+		{"gpt-4o-mini-2024-07-18", 64000, true},
+		{"gpt-4", 0, false},
+		{"gpt-4o1", 0, false},
+	}
+	for _, tt := range tests {
+		info, ok := catalog.Lookup(tt.modelName)
+		if ok != tt.ok || info.ContextWindow != tt.expected {
+			t.Errorf("%s: expected %d (%v), got %d (%v)", tt.modelName, tt.expected, tt.ok, info.ContextWindow, ok)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package params
+
+// Document is a source the model can ground its reply in and cite. Only supported by Cohere.
+type Document struct {
+	// ID is returned in Citation.DocumentID. If empty, the provider assigns one.
+	ID    string
+	Title string
+	URL   string
+	Text  string
+	// Metadata holds any other fields of the document
+	Metadata map[string]string
+}
@@ -0,0 +1,32 @@
+package params
+
+// EmbeddingTaskType hints what the embedding will be used for. Only Vertex uses it.
+type EmbeddingTaskType string
+
+const (
+	EmbeddingTaskTypeUnspecified        EmbeddingTaskType = ""
+	EmbeddingTaskTypeRetrievalQuery     EmbeddingTaskType = "RETRIEVAL_QUERY"
+	EmbeddingTaskTypeRetrievalDocument  EmbeddingTaskType = "RETRIEVAL_DOCUMENT"
+	EmbeddingTaskTypeSemanticSimilarity EmbeddingTaskType = "SEMANTIC_SIMILARITY"
+	EmbeddingTaskTypeClassification     EmbeddingTaskType = "CLASSIFICATION"
+	EmbeddingTaskTypeClustering         EmbeddingTaskType = "CLUSTERING"
+	EmbeddingTaskTypeQuestionAnswering  EmbeddingTaskType = "QUESTION_ANSWERING"
+	EmbeddingTaskTypeFactVerification   EmbeddingTaskType = "FACT_VERIFICATION"
+	EmbeddingTaskTypeCodeRetrievalQuery EmbeddingTaskType = "CODE_RETRIEVAL_QUERY"
+)
+
+type EmbeddingSettings struct {
+	ModelName string
+	// Dimensions truncates the embeddings to this size. 0 uses the model default.
+	Dimensions int
+	TaskType   EmbeddingTaskType
+	// BatchSize overrides the maximum number of texts sent per request. 0 uses the provider maximum.
+	BatchSize int
+}
+
+// EmbeddingResponse contains one embedding per input text, in the same order as the input
+type EmbeddingResponse struct {
+	Embeddings [][]float32
+	// TokenCount is the number of input tokens reported by the provider, 0 if not reported
+	TokenCount int
+}
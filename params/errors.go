@@ -0,0 +1,138 @@
+package params
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContextLengthError is returned when a prompt doesn't fit in the context window of the model
+type ContextLengthError struct {
+	PresetName   string
+	ModelName    string
+	PromptTokens int
+	// OutputTokens is the room reserved for the reply, from Settings.MaxOutputTokens
+	OutputTokens  int
+	ContextWindow int
+}
+
+func (e *ContextLengthError) Error() string {
+	if e.OutputTokens > 0 {
+		return fmt.Sprintf("prompt uses %d tokens, which with %d output tokens exceeds the %d token context window of model %s (preset %s)",
+			e.PromptTokens, e.OutputTokens, e.ContextWindow, e.ModelName, e.PresetName)
+	}
+	return fmt.Sprintf("prompt uses %d tokens, which exceeds the %d token context window of model %s (preset %s)",
+		e.PromptTokens, e.ContextWindow, e.ModelName, e.PresetName)
+}
+
+// StructuredOutputError is returned when the model's reply can't be parsed into the
+// response format, or doesn't match its schema after all repair attempts
+type StructuredOutputError struct {
+	// Content is the raw reply of the last attempt
+	Content string
+	// Violations lists where the reply doesn't match the schema
+	Violations []string
+	// Attempts is the number of replies that were tried
+	Attempts int
+	// Err is the JSON parse error, if the reply wasn't valid JSON
+	Err error
+}
+
+func (e *StructuredOutputError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("structured output is not valid JSON after %d attempt(s): %v", e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("structured output does not match schema after %d attempt(s): %s",
+		e.Attempts, strings.Join(e.Violations, "; "))
+}
+
+func (e *StructuredOutputError) Unwrap() error {
+	return e.Err
+}
+
+// ContentFilterError is returned when the provider's content filter blocks the prompt or the reply
+type ContentFilterError struct {
+	// PromptBlocked is true if the prompt was blocked, and false if the reply was
+	PromptBlocked bool
+	// Reason is the provider's block or finish reason, e.g. "SAFETY" or "content_filter"
+	Reason        string
+	Message       string
+	SafetyRatings []SafetyRating
+}
+
+func (e *ContentFilterError) Error() string {
+	target := "reply"
+	if e.PromptBlocked {
+		target = "prompt"
+	}
+
+	msg := fmt.Sprintf("%s blocked by content filter: %s", target, e.Reason)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// ErrorKind classifies provider errors, so callers can handle them the same way for every provider
+type ErrorKind string
+
+const (
+	ErrorKindInvalidRequest ErrorKind = "invalid_request"
+	ErrorKindAuthentication ErrorKind = "authentication"
+	ErrorKindPermission     ErrorKind = "permission"
+	ErrorKindNotFound       ErrorKind = "not_found"
+	ErrorKindRateLimit      ErrorKind = "rate_limit"
+	ErrorKindServer         ErrorKind = "server"
+	ErrorKindUnknown        ErrorKind = "unknown"
+)
+
+// ErrorKindFromStatus returns the kind of error an HTTP status code stands for
+func ErrorKindFromStatus(statusCode int) ErrorKind {
+	switch {
+	case statusCode == 401:
+		return ErrorKindAuthentication
+	case statusCode == 403:
+		return ErrorKindPermission
+	case statusCode == 404:
+		return ErrorKindNotFound
+	case statusCode == 429:
+		return ErrorKindRateLimit
+	case statusCode >= 500:
+		return ErrorKindServer
+	case statusCode >= 400:
+		return ErrorKindInvalidRequest
+	default:
+		return ErrorKindUnknown
+	}
+}
+
+// ProviderError is an error returned by the provider's API
+type ProviderError struct {
+	Provider   string
+	Kind       ErrorKind
+	StatusCode int
+	// Code is the provider's error type or code, if it returns one
+	Code    string
+	Message string
+	// Err is the error of the provider's SDK, for clients that use one
+	Err error
+}
+
+func (e *ProviderError) Error() string {
+	msg := fmt.Sprintf("%s returned status %d", e.Provider, e.StatusCode)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether the request may succeed if it is sent again later
+func (e *ProviderError) Retryable() bool {
+	return e.Kind == ErrorKindRateLimit || e.Kind == ErrorKindServer
+}
@@ -0,0 +1,34 @@
+package params
+
+// Grounding holds the sources of a reply when search is enabled or documents are cited
+type Grounding struct {
+	// Sources are all the documents the provider consulted, cited or not
+	Sources []Source
+	// Citations link spans of the reply to the sources that support them
+	Citations []Citation
+	// SearchQueries are the queries the provider ran, if it reports them
+	SearchQueries []string
+	// SearchEntryPoint is the rendered HTML of Google's search suggestions,
+	// which must be displayed next to grounded Gemini replies
+	SearchEntryPoint string
+}
+
+type Source struct {
+	URL   string
+	Title string
+	// DocumentID is the ID of the cited Prompt.Documents entry
+	DocumentID string
+}
+
+// Citation links a span of Response.Content to a source.
+// StartIndex and EndIndex are byte offsets into the content.
+type Citation struct {
+	URL   string
+	Title string
+	// DocumentID is the ID of the cited Prompt.Documents entry
+	DocumentID string
+	StartIndex int
+	EndIndex   int
+	// Text is the cited span of the reply
+	Text string
+}
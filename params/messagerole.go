@@ -3,6 +3,7 @@ package params
 type MessageRole string
 
 const (
+	MessageRoleSystem    MessageRole = "system"
 	MessageRoleUser      MessageRole = "user"
 	MessageRoleAssistant MessageRole = "assistant"
 )
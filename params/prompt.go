@@ -3,12 +3,22 @@ package params
 type Message struct {
 	Role    MessageRole
 	Content string
+	// Pinned messages are never removed when a conversation is truncated to fit a context window
+	Pinned bool
+	// ThoughtSignature is the opaque signature Gemini returns with thinking, see Response.ThoughtSignature
+	ThoughtSignature []byte
 }
 
 type Prompt struct {
 	SystemMessage  string
 	Messages       []Message
 	ResponseFormat interface{} // Pointer to struct for JSON schema response format. It cannot be a nil pointer, or it will be ignored.
+	// PreviousResponseID continues a stored conversation from Response.ResponseID, so Messages only
+	// needs the new turn. Only supported by the OpenAI Responses API.
+	PreviousResponseID string
+	// Documents are sources the reply is grounded in. Citations of them are returned in Response.Grounding.
+	// Only supported by Cohere.
+	Documents []Document
 }
 
 func NewPrompt(
@@ -0,0 +1,8 @@
+package params
+
+// ProviderOptions holds settings that only one provider understands. Each client package defines
+// its own options struct, such as oai.Options or vertex.Options. Clients apply the options that
+// belong to them and ignore the rest, so one preset can be sent to several provider types.
+type ProviderOptions interface {
+	ProviderName() string
+}
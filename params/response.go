@@ -2,19 +2,68 @@ package params
 
 // Response contains both the raw string content and the unmarshalled struct
 type Response struct {
+	// ResponseID is the provider's ID of the reply, if it returns one
+	ResponseID string
 	// Content is the raw string response from the LLM
 	Content string
 	// Parsed is a pointer to the unmarshalled struct if ResponseFormat was specified, nil otherwise.
 	// Type assert as a pointer when using: myStruct := response.Parsed.(*MyStructType)
 	Parsed interface{}
+	// Reasoning is the model's thinking, if Settings.IncludeReasoning is set and the provider returns it
+	Reasoning string
+	// ThoughtSignature must be sent back on the assistant message so Gemini can continue its reasoning
+	// in the next turn. Copy it to Message.ThoughtSignature when appending the reply to a conversation.
+	ThoughtSignature []byte
+	// Candidates contains every reply if Settings.CandidateCount is more than 1. The first one is Content.
+	Candidates []string
+	// Logprobs contains the log probability of every output token if Settings.Logprobs is set
+	Logprobs []TokenLogprob
+	// SafetyRatings are the provider's content filter ratings of the reply, if it returns them
+	SafetyRatings []SafetyRating
+	// Grounding holds the sources of the reply when search is enabled
+	Grounding *Grounding
 }
 
+type TokenLogprob struct {
+	Token   string
+	Logprob float64
+	// TopLogprobs are the most likely tokens at this position, if Settings.TopLogprobs is set
+	TopLogprobs []TopLogprob
+}
+
+type TopLogprob struct {
+	Token   string
+	Logprob float64
+}
+
+type StreamChunkKind string
+
+const (
+	// StreamChunkKindText chunks contain the answer. This is the zero value.
+	StreamChunkKindText StreamChunkKind = ""
+	// StreamChunkKindReasoning chunks contain the model's thinking, if Settings.IncludeReasoning is set
+	StreamChunkKindReasoning StreamChunkKind = "reasoning"
+)
+
 // StreamChunk represents a single chunk of a streaming response
 type StreamChunk struct {
+	// Kind tells whether Content is part of the answer or the reasoning
+	Kind StreamChunkKind
 	// Content is the incremental text content in this chunk
 	Content string
 	// Done indicates if this is the final chunk in the stream
 	Done bool
 	// Error contains any error that occurred during streaming
 	Error error
+	// Partial is a new pointer of the ResponseFormat type, populated from the JSON received so far.
+	// It is nil if ResponseFormat was not specified or nothing could be parsed yet.
+	Partial interface{}
+	// Parsed is set on the final chunk to the validated ResponseFormat pointer, like Response.Parsed
+	Parsed interface{}
+	// ThoughtSignature is set on the final chunk, like Response.ThoughtSignature
+	ThoughtSignature []byte
+	// Grounding is set on the final chunk, like Response.Grounding
+	Grounding *Grounding
+	// ResponseID is set on the final chunk, like Response.ResponseID
+	ResponseID string
 }
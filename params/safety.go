@@ -0,0 +1,49 @@
+package params
+
+type HarmCategory string
+
+const (
+	HarmCategoryHarassment       HarmCategory = "harassment"
+	HarmCategoryHateSpeech       HarmCategory = "hate_speech"
+	HarmCategorySexuallyExplicit HarmCategory = "sexually_explicit"
+	HarmCategoryDangerousContent HarmCategory = "dangerous_content"
+	HarmCategoryCivicIntegrity   HarmCategory = "civic_integrity"
+	// HarmCategoryViolence and HarmCategorySelfHarm are only reported by Azure OpenAI
+	HarmCategoryViolence HarmCategory = "violence"
+	HarmCategorySelfHarm HarmCategory = "self_harm"
+)
+
+type SafetyThreshold string
+
+const (
+	// SafetyThresholdOff turns the filter off and skips computing ratings for the category
+	SafetyThresholdOff                 SafetyThreshold = "off"
+	SafetyThresholdBlockNone           SafetyThreshold = "block_none"
+	SafetyThresholdBlockOnlyHigh       SafetyThreshold = "block_only_high"
+	SafetyThresholdBlockMediumAndAbove SafetyThreshold = "block_medium_and_above"
+	SafetyThresholdBlockLowAndAbove    SafetyThreshold = "block_low_and_above"
+)
+
+// SafetySettings configures content filtering. Only supported by Vertex.
+type SafetySettings struct {
+	// Default is the threshold of every category that isn't in Categories.
+	// If empty, those categories use the model's default threshold.
+	Default    SafetyThreshold
+	Categories map[HarmCategory]SafetyThreshold
+}
+
+// Threshold returns the threshold configured for a category
+func (s SafetySettings) Threshold(category HarmCategory) SafetyThreshold {
+	if threshold, ok := s.Categories[category]; ok {
+		return threshold
+	}
+	return s.Default
+}
+
+// SafetyRating is the provider's assessment of a prompt or reply for one harm category
+type SafetyRating struct {
+	Category HarmCategory
+	// Probability is the provider's likelihood label, e.g. "NEGLIGIBLE" or "HIGH"
+	Probability string
+	Blocked     bool
+}
@@ -10,9 +10,45 @@ const (
 	LargeThinkingBudget   ThinkingBudget = "large"
 )
 
+// DynamicThinkingTokens lets Gemini decide how many tokens to think for
+const DynamicThinkingTokens = -1
+
 type Settings struct {
-	ModelName       string
-	Temperature     *float64
-	ThinkingBudget  ThinkingBudget
+	ModelName   string
+	Temperature *float64
+	TopP        *float64
+	// TopK is not supported by OpenAI
+	TopK             *int
+	Seed             *int
+	StopSequences    []string
+	PresencePenalty  *float64
+	FrequencyPenalty *float64
+	// CandidateCount is the number of replies to generate. All of them are returned in Response.Candidates.
+	CandidateCount *int
+	// LogitBias maps token IDs to a bias between -100 and 100. Only supported by OpenAI.
+	LogitBias map[string]int
+	// Logprobs returns the log probability of every output token in Response.Logprobs
+	Logprobs bool
+	// TopLogprobs is the number of most likely alternatives returned for every token. Requires Logprobs.
+	TopLogprobs *int
+	// MaxOutputTokens limits the tokens generated in a reply, including reasoning tokens for OpenAI.
+	// If nil, OpenAI clients default to 16000 and Vertex uses the model default.
+	MaxOutputTokens *int
+	// ThinkingBudget is a shorthand for common thinking budgets. ThinkingTokens takes precedence if set.
+	ThinkingBudget ThinkingBudget
+	// ThinkingTokens is an explicit thinking budget. 0 disables thinking and DynamicThinkingTokens
+	// lets Gemini decide. OpenAI has no numeric budget, so it is mapped to the closest reasoning effort,
+	// and 0 is the minimal effort since OpenAI reasoning models can't turn reasoning off.
+	ThinkingTokens  *int
 	IsSearchEnabled bool
+	// Safety sets the content filter thresholds. If nil, Vertex turns every filter off.
+	// Only Vertex AI and Gemini support it, other clients reject it.
+	Safety *SafetySettings
+	// RepairAttempts is how many times a reply that doesn't match the response format
+	// is sent back to the model with the validation errors before giving up
+	RepairAttempts int
+	// IncludeReasoning returns the model's reasoning separately from the answer, if the provider exposes it
+	IncludeReasoning bool
+	// ProviderOptions are passed through to the matching provider, e.g. oai.Options or vertex.Options
+	ProviderOptions []ProviderOptions
 }
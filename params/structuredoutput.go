@@ -0,0 +1,13 @@
+package params
+
+// StructuredOutputMode describes how a backend supports structured output
+type StructuredOutputMode string
+
+const (
+	// StructuredOutputNative sends the JSON schema as the response format. This is the default.
+	StructuredOutputNative StructuredOutputMode = "native"
+	// StructuredOutputJSONObject requests JSON object mode and describes the schema in the system message
+	StructuredOutputJSONObject StructuredOutputMode = "json_object"
+	// StructuredOutputNone only describes the schema in the system message
+	StructuredOutputNone StructuredOutputMode = "none"
+)
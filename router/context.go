@@ -0,0 +1,131 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/models"
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// ContextFallbackMap lists, per preset, the presets to try in order when a prompt
+// doesn't fit in the context window of the preset's model
+type ContextFallbackMap map[string][]string
+
+// WithModelCatalog makes the router count the tokens of every prompt before sending it,
+// and fail fast with a *params.ContextLengthError if the prompt and the preset's MaxOutputTokens
+// don't fit in the model's context window. Models missing from the catalog, or served by clients
+// that can't count tokens, are not checked. Preset token settings are validated against the catalog.
+func WithModelCatalog(catalog models.Catalog) Option {
+	return func(r *Router) {
+		r.modelCatalog = catalog
+	}
+}
+
+// WithContextFallbacks routes prompts that are too large for a preset to the first fallback preset that fits.
+// It only takes effect together with WithModelCatalog.
+func WithContextFallbacks(fallbacks ContextFallbackMap) Option {
+	return func(r *Router) {
+		r.contextFallbacks = fallbacks
+	}
+}
+
+func validatePresetLimits(presetMap PresetMap, catalog models.Catalog) error {
+	for presetName, preset := range presetMap {
+		if err := catalog.Validate(preset); err != nil {
+			return fmt.Errorf("preset %s: %w", presetName, err)
+		}
+	}
+	return nil
+}
+
+func validateContextFallbacks(presetMap PresetMap, fallbacks ContextFallbackMap) error {
+	for presetName, fallbackNames := range fallbacks {
+		if _, exists := presetMap[presetName]; !exists {
+			return fmt.Errorf("preset %s defined in context fallbacks but not in preset map", presetName)
+		}
+		for _, fallbackName := range fallbackNames {
+			if _, exists := presetMap[fallbackName]; !exists {
+				return fmt.Errorf("fallback preset %s for preset %s not in preset map", fallbackName, presetName)
+			}
+		}
+	}
+	return nil
+}
+
+// selectPresetForContext returns the name, preset and client to use for the prompt. It is the requested
+// preset if the prompt fits, otherwise the first fallback that fits.
+func (r *Router) selectPresetForContext(ctx context.Context,
+	presetName string,
+	preset params.Settings,
+	llmClient *client.Client,
+	prompt params.Prompt) (string, params.Settings, *client.Client, error) {
+
+	err := r.checkContextWindow(ctx, presetName, preset, llmClient, prompt)
+	if err == nil {
+		return presetName, preset, llmClient, nil
+	}
+
+	var contextErr *params.ContextLengthError
+	if !errors.As(err, &contextErr) {
+		return "", params.Settings{}, nil, err
+	}
+
+	for _, fallbackName := range r.contextFallbacks[presetName] {
+		fallback := r.presetMap[fallbackName]
+		fallbackClient, err := r.GetClientForModelName(fallback.ModelName)
+		if err != nil {
+			return "", params.Settings{}, nil, fmt.Errorf("failed to get client for model %s: %w", fallback.ModelName, err)
+		}
+
+		err = r.checkContextWindow(ctx, fallbackName, fallback, fallbackClient, prompt)
+		if err == nil {
+			return fallbackName, fallback, fallbackClient, nil
+		}
+		if !errors.As(err, new(*params.ContextLengthError)) {
+			return "", params.Settings{}, nil, err
+		}
+	}
+
+	return "", params.Settings{}, nil, contextErr
+}
+
+func (r *Router) checkContextWindow(ctx context.Context,
+	presetName string,
+	preset params.Settings,
+	llmClient *client.Client,
+	prompt params.Prompt) error {
+
+	if r.modelCatalog == nil || !llmClient.SupportsTokenCounting() {
+		return nil
+	}
+
+	info, ok := r.modelCatalog.Lookup(preset.ModelName)
+	if !ok {
+		return nil
+	}
+
+	promptTokens, err := llmClient.CountTokens(ctx, prompt, preset)
+	if err != nil {
+		return fmt.Errorf("failed to count tokens for model %s: %w", preset.ModelName, err)
+	}
+
+	// Leave room for the reply if the preset sets an explicit output limit
+	outputTokens := 0
+	if preset.MaxOutputTokens != nil {
+		outputTokens = *preset.MaxOutputTokens
+	}
+
+	if promptTokens+outputTokens > info.ContextWindow {
+		return &params.ContextLengthError{
+			PresetName:    presetName,
+			ModelName:     preset.ModelName,
+			PromptTokens:  promptTokens,
+			OutputTokens:  outputTokens,
+			ContextWindow: info.ContextWindow,
+		}
+	}
+	return nil
+}
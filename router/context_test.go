@@ -0,0 +1,103 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/models"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+func newContextRouter(t *testing.T, provider *stubProvider, fallbacks router.ContextFallbackMap) *router.Router {
+	t.Helper()
+	counting := &client.Client{Provider: countingProvider{provider}, ClientType: client.ClientTypeOpenAI}
+	r, err := router.NewRouter(
+		router.ClientMap{"small-model": {counting}, "medium-model": {counting}, "large-model": {counting}},
+		router.PresetMap{
+			"small":  {ModelName: "small-model"},
+			"medium": {ModelName: "medium-model"},
+			"large":  {ModelName: "large-model"},
+		},
+		router.WithModelCatalog(models.Catalog{
+			"small-model":  {ContextWindow: 10},
+			"medium-model": {ContextWindow: 20},
+			"large-model":  {ContextWindow: 1000},
+		}),
+		router.WithContextFallbacks(fallbacks),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSendPromptRoutesToFirstFallbackThatFits(t *testing.T) {
+	provider := &stubProvider{reply: "ok"}
+	r := newContextRouter(t, provider, router.ContextFallbackMap{"small": {"medium", "large"}})
+
+	// 35 tokens fit neither small nor medium
+	if _, err := r.SendPrompt(context.Background(), "small", params.NewSimplePrompt("", "Summarize this rather long document")); err != nil {
+		t.Fatal(err)
+	}
+	provider.assertCallCount(t, 1)
+	if provider.settings[0].ModelName != "large-model" {
+		t.Errorf("expected the large model to answer, got %s", provider.settings[0].ModelName)
+	}
+}
+
+func TestSendPromptFailsFastWhenNoPresetFits(t *testing.T) {
+	tests := map[string]router.ContextFallbackMap{
+		"without fallbacks":       nil,
+		"with too small fallback": {"small": {"medium"}},
+	}
+
+	for name, fallbacks := range tests {
+		t.Run(name, func(t *testing.T) {
+			provider := &stubProvider{reply: "ok"}
+			r := newContextRouter(t, provider, fallbacks)
+
+			_, err := r.SendPrompt(context.Background(), "small", params.NewSimplePrompt("", "Summarize this rather long document"))
+			var contextErr *params.ContextLengthError
+			if !errors.As(err, &contextErr) {
+				t.Fatalf("expected a context length error, got %v", err)
+			}
+			// The error describes the requested preset, not the last fallback tried
+			if contextErr.PresetName != "small" || contextErr.ModelName != "small-model" ||
+				contextErr.PromptTokens != 35 || contextErr.ContextWindow != 10 {
+				t.Errorf("unexpected context length error %+v", contextErr)
+			}
+			provider.assertCallCount(t, 0)
+		})
+	}
+}
+
+func TestCountTokensKeepsRoundRobin(t *testing.T) {
+	first := &stubProvider{reply: "first"}
+	second := &stubProvider{reply: "second"}
+	r, err := router.NewRouter(router.ClientMap{
+		"model": {
+			{Provider: countingProvider{first}, ClientType: client.ClientTypeOpenAI},
+			{Provider: countingProvider{second}, ClientType: client.ClientTypeOpenAI},
+		},
+	}, router.PresetMap{"chat": {ModelName: "model"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"first", "second", "first"} {
+		// A preflight count doesn't change which client answers
+		if _, err := r.CountTokens(context.Background(), "model", params.NewSimplePrompt("", "Hi")); err != nil {
+			t.Fatal(err)
+		}
+		response, err := r.SendPrompt(context.Background(), "chat", params.NewSimplePrompt("", "Hi"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Content != expected {
+			t.Errorf("expected the %s client to answer, got %s", expected, response.Content)
+		}
+	}
+}
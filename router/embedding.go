@@ -0,0 +1,60 @@
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jamesleeht/llm-gopher/cache"
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+type EmbeddingPresetMap map[string]params.EmbeddingSettings
+
+// WithEmbeddingPresets adds presets for embedding models. Their models must be defined in the client map.
+func WithEmbeddingPresets(embeddingPresetMap EmbeddingPresetMap) Option {
+	return func(r *Router) {
+		r.embeddingPresetMap = embeddingPresetMap
+	}
+}
+
+func (r *Router) Embed(ctx context.Context,
+	presetName string,
+	texts []string) (*params.EmbeddingResponse, error) {
+	preset, exists := r.embeddingPresetMap[presetName]
+	if !exists {
+		return nil, fmt.Errorf("embedding preset %s not found", presetName)
+	}
+
+	client, err := r.GetClientForModelName(preset.ModelName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client for model %s: %w", preset.ModelName, err)
+	}
+
+	response, err := client.Embed(ctx, texts, preset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	return response, nil
+}
+
+// Embedder returns a cache.Embedder that embeds text through the given embedding preset
+func (r *Router) Embedder(presetName string) cache.Embedder {
+	return &presetEmbedder{router: r, presetName: presetName}
+}
+
+type presetEmbedder struct {
+	router     *Router
+	presetName string
+}
+
+func (e *presetEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	response, err := e.router.Embed(ctx, e.presetName, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Embeddings) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(response.Embeddings))
+	}
+	return response.Embeddings[0], nil
+}
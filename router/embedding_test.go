@@ -0,0 +1,93 @@
+package router_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+// stubEmbedder embeds each text as its length and records the settings it was called with
+type stubEmbedder struct {
+	stubProvider
+	settings []params.EmbeddingSettings
+}
+
+func (e *stubEmbedder) CreateEmbeddings(ctx context.Context, texts []string, settings params.EmbeddingSettings) (*params.EmbeddingResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.settings = append(e.settings, settings)
+
+	response := &params.EmbeddingResponse{TokenCount: len(texts)}
+	for _, text := range texts {
+		response.Embeddings = append(response.Embeddings, []float32{float32(len(text))})
+	}
+	return response, nil
+}
+
+func (e *stubEmbedder) client() *client.Client {
+	return &client.Client{Provider: e, ClientType: client.ClientTypeOpenAI}
+}
+
+func TestEmbedUsesPreset(t *testing.T) {
+	embedder := &stubEmbedder{}
+	r, err := router.NewRouter(router.ClientMap{
+		"text-embedding-3-small": {embedder.client()},
+	}, router.PresetMap{}, router.WithEmbeddingPresets(router.EmbeddingPresetMap{
+		"search": {ModelName: "text-embedding-3-small", Dimensions: 256},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := r.Embed(context.Background(), "search", []string{"a", "bb"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Embeddings) != 2 || response.Embeddings[0][0] != 1 || response.Embeddings[1][0] != 2 {
+		t.Errorf("expected the embeddings in input order, got %v", response.Embeddings)
+	}
+	if len(embedder.settings) != 1 || embedder.settings[0].Dimensions != 256 {
+		t.Errorf("expected the preset's settings, got %+v", embedder.settings)
+	}
+
+	// The router's embedder plugs into the semantic cache
+	vector, err := r.Embedder("search").Embed(context.Background(), "ccc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vector) != 1 || vector[0] != 3 {
+		t.Errorf("expected the embedding of ccc, got %v", vector)
+	}
+
+	if _, err := r.Embed(context.Background(), "missing", []string{"a"}); err == nil {
+		t.Error("expected an error for an unknown embedding preset")
+	}
+}
+
+func TestEmbedRequiresEmbeddingClient(t *testing.T) {
+	provider := &stubProvider{}
+	r, err := router.NewRouter(router.ClientMap{
+		"gpt-4o": {provider.client()},
+	}, router.PresetMap{}, router.WithEmbeddingPresets(router.EmbeddingPresetMap{
+		"search": {ModelName: "gpt-4o"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Embed(context.Background(), "search", []string{"a"}); err == nil {
+		t.Error("expected an error for a client that doesn't support embeddings")
+	}
+}
+
+func TestNewRouterRequiresEmbeddingPresetModels(t *testing.T) {
+	_, err := router.NewRouter(router.ClientMap{}, router.PresetMap{}, router.WithEmbeddingPresets(router.EmbeddingPresetMap{
+		"search": {ModelName: "text-embedding-3-small"},
+	}))
+	if err == nil {
+		t.Error("expected an error for an embedding preset whose model is not in the client map")
+	}
+}
@@ -3,32 +3,101 @@ package router
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
+	"github.com/jamesleeht/llm-gopher/cache"
 	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/models"
 	"github.com/jamesleeht/llm-gopher/params"
 )
 
 type Router struct {
-	clientMap ClientMap
-	presetMap PresetMap
+	clientMap          ClientMap
+	presetMap          PresetMap
+	embeddingPresetMap EmbeddingPresetMap
+	semanticCache      *cache.SemanticCache
+	cacheErrorHandler  func(error)
+	modelCatalog       models.Catalog
+	contextFallbacks   ContextFallbackMap
+
+	// next client index per model name, used to round-robin between clients
+	counters map[string]*atomic.Uint64
 }
 
 type ClientMap map[string][]*client.Client
 type PresetMap map[string]params.Settings
 
-func NewRouter(clients ClientMap, presetMap PresetMap) (*Router, error) {
-	err := validateAllModelsDefined(clients, presetMap)
-	if err != nil {
-		return nil, err
+// Option configures optional router behaviour
+type Option func(*Router)
+
+// WithSemanticCache makes the router answer prompts from the cache when a similar prompt
+// was already sent to the same preset
+func WithSemanticCache(semanticCache *cache.SemanticCache) Option {
+	return func(r *Router) {
+		r.semanticCache = semanticCache
 	}
+}
 
-	return &Router{
+// WithCacheErrorHandler sets a function that is called with semantic cache errors.
+// Failed lookups are treated as misses and failed stores are ignored, so these errors
+// are dropped without a handler.
+func WithCacheErrorHandler(handler func(error)) Option {
+	return func(r *Router) {
+		r.cacheErrorHandler = handler
+	}
+}
+
+func NewRouter(clients ClientMap, presetMap PresetMap, opts ...Option) (*Router, error) {
+	router := &Router{
 		clientMap: clients,
 		presetMap: presetMap,
-	}, nil
+		counters:  make(map[string]*atomic.Uint64),
+	}
+	for _, opt := range opts {
+		opt(router)
+	}
+
+	err := validateAllModelsDefined(clients, presetMap, router.embeddingPresetMap)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateContextFallbacks(presetMap, router.contextFallbacks)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validatePresetSettings(clients, presetMap)
+	if err != nil {
+		return nil, err
+	}
+
+	if router.modelCatalog != nil {
+		if err := validatePresetLimits(presetMap, router.modelCatalog); err != nil {
+			return nil, err
+		}
+	}
+
+	for modelName := range clients {
+		router.counters[modelName] = &atomic.Uint64{}
+	}
+	return router, nil
 }
 
-func validateAllModelsDefined(clientMap ClientMap, presetMap PresetMap) error {
+// validatePresetSettings checks every preset against every client of its model,
+// so settings a provider cannot send are rejected before the first request
+func validatePresetSettings(clientMap ClientMap, presetMap PresetMap) error {
+	for presetName, preset := range presetMap {
+		for _, c := range clientMap[preset.ModelName] {
+			if err := c.ValidateSettings(preset); err != nil {
+				return fmt.Errorf("preset %s is not supported by model %s: %w", presetName, preset.ModelName, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateAllModelsDefined(clientMap ClientMap, presetMap PresetMap, embeddingPresetMap EmbeddingPresetMap) error {
 	modelsFromClientMap := make(map[string]bool)
 	for modelName := range clientMap {
 		modelsFromClientMap[modelName] = true
@@ -38,6 +107,9 @@ func validateAllModelsDefined(clientMap ClientMap, presetMap PresetMap) error {
 	for _, preset := range presetMap {
 		modelsFromPresetMap[preset.ModelName] = true
 	}
+	for _, preset := range embeddingPresetMap {
+		modelsFromPresetMap[preset.ModelName] = true
+	}
 
 	for modelName := range modelsFromPresetMap {
 		if !modelsFromClientMap[modelName] {
@@ -55,20 +127,72 @@ func (r *Router) SendPrompt(ctx context.Context,
 		return nil, fmt.Errorf("preset %s not found", presetName)
 	}
 
+	if r.semanticCache != nil {
+		// A failed lookup, e.g. of a prompt the embedder can't embed, is treated as a miss
+		cached, ok, err := r.semanticCache.Lookup(ctx, presetName, prompt)
+		if err != nil {
+			r.handleCacheError(fmt.Errorf("failed to look up semantic cache: %w", err))
+		}
+		if err == nil && ok {
+			return cached, nil
+		}
+	}
+
 	client, err := r.GetClientForModelName(preset.ModelName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get client for model %s: %w", preset.ModelName, err)
 	}
 
+	answeringPresetName, preset, client, err := r.selectPresetForContext(ctx, presetName, preset, client, prompt)
+	if err != nil {
+		return nil, err
+	}
+
 	response, err := client.SendMessage(ctx, prompt, preset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send message: %w", err)
 	}
 
+	if r.semanticCache != nil {
+		// A failed cache write shouldn't discard a response we already paid for
+		// File the reply under the preset that answered, which is a fallback if the prompt didn't fit
+		if err := r.semanticCache.Store(ctx, answeringPresetName, prompt, response); err != nil {
+			r.handleCacheError(fmt.Errorf("failed to store response in semantic cache: %w", err))
+		}
+	}
+
 	return response, nil
 }
 
+func (r *Router) handleCacheError(err error) {
+	if r.cacheErrorHandler != nil {
+		r.cacheErrorHandler(err)
+	}
+}
+
 func (r *Router) GetClientForModelName(modelName string) (*client.Client, error) {
+	clients, err := r.clientsForModelName(modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-robin between the clients defined for the model
+	next := r.counters[modelName].Add(1) - 1
+	return clients[next%uint64(len(clients))], nil
+}
+
+// peekClientForModelName returns the client GetClientForModelName will return next, without moving the round-robin on
+func (r *Router) peekClientForModelName(modelName string) (*client.Client, error) {
+	clients, err := r.clientsForModelName(modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	next := r.counters[modelName].Load()
+	return clients[next%uint64(len(clients))], nil
+}
+
+func (r *Router) clientsForModelName(modelName string) ([]*client.Client, error) {
 	clients, exists := r.clientMap[modelName]
 	if !exists {
 		return nil, fmt.Errorf("model name: %s not defined in client map", modelName)
@@ -77,7 +201,28 @@ func (r *Router) GetClientForModelName(modelName string) (*client.Client, error)
 	if len(clients) == 0 {
 		return nil, fmt.Errorf("no clients found for model name: %s", modelName)
 	}
+	return clients, nil
+}
 
-	// TODO: load balance between different clients
-	return clients[0], nil
+// Preset returns the settings of a preset
+func (r *Router) Preset(presetName string) (params.Settings, bool) {
+	preset, exists := r.presetMap[presetName]
+	return preset, exists
+}
+
+// CountTokens counts the input tokens of the prompt using the client the next request for the model
+// will be sent to. Counting doesn't move the round-robin on, so it can be used as a preflight check.
+func (r *Router) CountTokens(ctx context.Context,
+	modelName string,
+	prompt params.Prompt) (int, error) {
+	client, err := r.peekClientForModelName(modelName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get client for model %s: %w", modelName, err)
+	}
+
+	count, err := client.CountTokens(ctx, prompt, params.Settings{ModelName: modelName})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+	return count, nil
 }
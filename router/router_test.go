@@ -0,0 +1,172 @@
+package router_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/cache"
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/models"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+// stubProvider answers every prompt with the same reply and counts the requests
+type stubProvider struct {
+	mu       sync.Mutex
+	reply    string
+	prompts  []params.Prompt
+	settings []params.Settings
+}
+
+func (p *stubProvider) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompts = append(p.prompts, prompt)
+	p.settings = append(p.settings, settings)
+	return &params.Response{Content: p.reply}, nil
+}
+
+func (p *stubProvider) StreamCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (<-chan params.StreamChunk, error) {
+	response, err := p.SendCompletionMessage(ctx, prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan params.StreamChunk, 2)
+	chunks <- params.StreamChunk{Content: response.Content}
+	chunks <- params.StreamChunk{Done: true}
+	close(chunks)
+	return chunks, nil
+}
+
+func (p *stubProvider) client() *client.Client {
+	return &client.Client{Provider: p, ClientType: client.ClientTypeOpenAI}
+}
+
+func (p *stubProvider) assertCallCount(t *testing.T, expected int) {
+	t.Helper()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.prompts) != expected {
+		t.Errorf("expected %d calls, got %d", expected, len(p.prompts))
+	}
+}
+
+func newCachedRouter(t *testing.T, provider *stubProvider, presetMap router.PresetMap, opts ...router.Option) *router.Router {
+	t.Helper()
+	semanticCache, err := cache.NewSemanticCache(cache.SemanticCacheConfig{
+		Embedder: cache.NewHashEmbedder(256),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientMap := router.ClientMap{}
+	for _, preset := range presetMap {
+		clientMap[preset.ModelName] = append(clientMap[preset.ModelName], provider.client())
+	}
+
+	r, err := router.NewRouter(clientMap, presetMap, append(opts, router.WithSemanticCache(semanticCache))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSendPromptCachesResponses(t *testing.T) {
+	ctx := context.Background()
+	provider := &stubProvider{reply: "Paris"}
+	r := newCachedRouter(t, provider, router.PresetMap{"fast": {ModelName: "gpt-4o-mini"}})
+
+	for range 2 {
+		response, err := r.SendPrompt(ctx, "fast", params.NewSimplePrompt("", "What is the capital of France?"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response.Content != "Paris" {
+			t.Fatalf("expected Paris, got %q", response.Content)
+		}
+	}
+	provider.assertCallCount(t, 1)
+}
+
+func TestSendPromptTreatsCacheLookupErrorAsMiss(t *testing.T) {
+	ctx := context.Background()
+	provider := &stubProvider{reply: "How can I help?"}
+	var cacheErrors []error
+	r := newCachedRouter(t, provider, router.PresetMap{"fast": {ModelName: "gpt-4o-mini"}},
+		router.WithCacheErrorHandler(func(err error) { cacheErrors = append(cacheErrors, err) }))
+
+	// The hash embedder can't embed text without letters or digits
+	response, err := r.SendPrompt(ctx, "fast", params.NewSimplePrompt("", "?"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "How can I help?" {
+		t.Errorf("expected the provider's reply, got %q", response.Content)
+	}
+	provider.assertCallCount(t, 1)
+
+	// Both the lookup and the store of the reply fail to embed the prompt
+	if len(cacheErrors) != 2 {
+		t.Errorf("expected the lookup and store errors to be handled, got %v", cacheErrors)
+	}
+}
+
+// countingProvider counts a token per byte, so context windows can be tested without a tokenizer
+type countingProvider struct {
+	*stubProvider
+}
+
+func (p countingProvider) CountTokens(_ context.Context, prompt params.Prompt, _ params.Settings) (int, error) {
+	count := len(prompt.SystemMessage)
+	for _, message := range prompt.Messages {
+		count += len(message.Content)
+	}
+	return count, nil
+}
+
+func TestSendPromptCachesFallbackUnderAnsweringPreset(t *testing.T) {
+	ctx := context.Background()
+	provider := &stubProvider{reply: "summary"}
+	counting := &client.Client{Provider: countingProvider{provider}, ClientType: client.ClientTypeOpenAI}
+
+	semanticCache, err := cache.NewSemanticCache(cache.SemanticCacheConfig{
+		Embedder: cache.NewHashEmbedder(256),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := router.NewRouter(
+		router.ClientMap{"small-model": {counting}, "large-model": {counting}},
+		router.PresetMap{"small": {ModelName: "small-model"}, "large": {ModelName: "large-model"}},
+		router.WithModelCatalog(models.Catalog{
+			"small-model": {ContextWindow: 10},
+			"large-model": {ContextWindow: 1000},
+		}),
+		router.WithContextFallbacks(router.ContextFallbackMap{"small": {"large"}}),
+		router.WithSemanticCache(semanticCache),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prompt := params.NewSimplePrompt("", "Summarize this rather long document")
+	if _, err := r.SendPrompt(ctx, "small", prompt); err != nil {
+		t.Fatal(err)
+	}
+
+	// The reply came from the large preset, so it is cached there
+	response, err := r.SendPrompt(ctx, "large", prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Content != "summary" {
+		t.Errorf("expected the cached summary, got %q", response.Content)
+	}
+	provider.assertCallCount(t, 1)
+	if provider.settings[0].ModelName != "large-model" {
+		t.Errorf("expected the large model to answer, got %s", provider.settings[0].ModelName)
+	}
+}
@@ -0,0 +1,30 @@
+package router
+
+import (
+	"context"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+// SendTyped sends the prompt to the preset with T as the response format and returns the parsed value.
+// A fresh T is allocated for every call, so the same prompt can be reused concurrently.
+// Any ResponseFormat already set on the prompt is replaced.
+func SendTyped[T any](ctx context.Context,
+	r *Router,
+	presetName string,
+	prompt params.Prompt) (T, *params.Response, error) {
+	var zero T
+
+	typedPrompt, value, err := client.WithTypedResponseFormat[T](prompt)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	response, err := r.SendPrompt(ctx, presetName, typedPrompt)
+	if err != nil {
+		return zero, nil, err
+	}
+
+	return *value, response, nil
+}
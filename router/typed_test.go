@@ -0,0 +1,87 @@
+package router_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+// jsonProvider unmarshals the reply into the response format like the provider clients do
+type jsonProvider struct {
+	*stubProvider
+}
+
+func (p jsonProvider) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	response, err := p.stubProvider.SendCompletionMessage(ctx, prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(response.Content), prompt.ResponseFormat); err != nil {
+		return nil, err
+	}
+	response.Parsed = prompt.ResponseFormat
+	return response, nil
+}
+
+type capital struct {
+	Country string `json:"country"`
+	City    string `json:"city"`
+}
+
+func newTypedRouter(t *testing.T, provider *stubProvider) *router.Router {
+	t.Helper()
+	r, err := router.NewRouter(
+		router.ClientMap{"model": {{Provider: jsonProvider{provider}, ClientType: client.ClientTypeOpenAI}}},
+		router.PresetMap{"chat": {ModelName: "model"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestSendTypedAllocatesValuePerCall(t *testing.T) {
+	provider := &stubProvider{reply: `{"country":"France","city":"Paris"}`}
+	r := newTypedRouter(t, provider)
+	prompt := params.NewSimplePrompt("", "Capital of France?")
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			value, _, err := router.SendTyped[capital](context.Background(), r, "chat", prompt)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if value != (capital{Country: "France", City: "Paris"}) {
+				t.Errorf("expected the parsed capital, got %+v", value)
+			}
+		})
+	}
+	wg.Wait()
+
+	// Every call is sent with its own value to unmarshal into
+	seen := map[any]bool{}
+	for _, sent := range provider.prompts {
+		seen[sent.ResponseFormat] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("expected 4 distinct response formats, got %d", len(seen))
+	}
+}
+
+func TestSendTypedRejectsNonStructTypes(t *testing.T) {
+	provider := &stubProvider{reply: `"Paris"`}
+	r := newTypedRouter(t, provider)
+
+	_, _, err := router.SendTyped[string](context.Background(), r, "chat", params.NewSimplePrompt("", "Capital of France?"))
+	if err == nil {
+		t.Error("expected an error for a string type")
+	}
+	provider.assertCallCount(t, 0)
+}
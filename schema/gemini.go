@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// String formats supported by Gemini, other formats are removed
+var geminiFormats = []string{"date-time", "date", "time"}
+
+// ForGemini generates the schema of a type for Gemini's ResponseJsonSchema.
+// $refs are inlined and keywords that Gemini rejects are removed.
+func ForGemini(t reflect.Type) *jsonschema.Schema {
+	schema := Generate(t)
+	definitions := schema.Definitions
+	schema.Definitions = nil
+
+	schema = inlineRefs(schema, definitions, map[string]bool{})
+
+	walk(schema, func(s *jsonschema.Schema) {
+		if len(s.OneOf) > 0 {
+			s.AnyOf = append(s.AnyOf, s.OneOf...)
+			s.OneOf = nil
+		}
+		if s.Const != nil {
+			s.Enum = []any{s.Const}
+			s.Const = nil
+		}
+		if s.Format != "" && !slices.Contains(geminiFormats, s.Format) {
+			s.Format = ""
+		}
+
+		s.Version = ""
+		s.ID = ""
+		s.Anchor = ""
+		s.Comments = ""
+		s.DynamicRef = ""
+		s.Definitions = nil
+		s.AllOf = nil
+		s.Not = nil
+		s.If = nil
+		s.Then = nil
+		s.Else = nil
+		s.DependentSchemas = nil
+		s.DependentRequired = nil
+		s.PatternProperties = nil
+		s.PropertyNames = nil
+		s.Contains = nil
+		s.MinContains = nil
+		s.MaxContains = nil
+		s.UniqueItems = false
+		s.MultipleOf = ""
+		s.ExclusiveMinimum = ""
+		s.ExclusiveMaximum = ""
+		s.Pattern = ""
+		s.MinProperties = nil
+		s.MaxProperties = nil
+		s.ContentEncoding = ""
+		s.ContentMediaType = ""
+		s.ContentSchema = nil
+		s.Default = nil
+		s.Examples = nil
+		s.Deprecated = false
+		s.ReadOnly = false
+		s.WriteOnly = false
+		s.Extras = nil
+	})
+
+	return schema
+}
+
+// inlineRefs replaces $refs with the definition they point to. Recursive types can't
+// be inlined, so a reference back into a definition being inlined becomes a free-form object.
+func inlineRefs(s *jsonschema.Schema, definitions jsonschema.Definitions, inlining map[string]bool) *jsonschema.Schema {
+	if s == nil || s == jsonschema.TrueSchema || s == jsonschema.FalseSchema {
+		return s
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/$defs/")
+		def, exists := definitions[name]
+		if !exists || inlining[name] {
+			return &jsonschema.Schema{Type: "object", Description: s.Description}
+		}
+
+		inlining[name] = true
+		inlined := inlineRefs(deepCopy(def), definitions, inlining)
+		delete(inlining, name)
+
+		if s.Description != "" {
+			inlined.Description = s.Description
+		}
+		return inlined
+	}
+
+	if s.Properties != nil {
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			s.Properties.Set(pair.Key, inlineRefs(pair.Value, definitions, inlining))
+		}
+	}
+	for i := range s.AnyOf {
+		s.AnyOf[i] = inlineRefs(s.AnyOf[i], definitions, inlining)
+	}
+	for i := range s.OneOf {
+		s.OneOf[i] = inlineRefs(s.OneOf[i], definitions, inlining)
+	}
+	for i := range s.AllOf {
+		s.AllOf[i] = inlineRefs(s.AllOf[i], definitions, inlining)
+	}
+	for i := range s.PrefixItems {
+		s.PrefixItems[i] = inlineRefs(s.PrefixItems[i], definitions, inlining)
+	}
+	s.Items = inlineRefs(s.Items, definitions, inlining)
+	s.AdditionalProperties = inlineRefs(s.AdditionalProperties, definitions, inlining)
+	return s
+}
@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/invopop/jsonschema"
+)
+
+// ForOpenAI generates the schema of a type for OpenAI structured outputs.
+//
+// Strict mode requires every property to be listed as required, so optional properties
+// are made nullable instead. Free-form maps can't be expressed in strict mode, so strict
+// is false if the type contains one and the schema is sent as a best effort.
+// It returns an error if the type isn't an object, since OpenAI requires one at the root.
+func ForOpenAI(t reflect.Type) (schema *jsonschema.Schema, strict bool, err error) {
+	schema = Generate(t)
+	if schema.Type != "object" {
+		return nil, false, fmt.Errorf("response format %v must be an object for OpenAI structured outputs, got type %q", t, schema.Type)
+	}
+	schema.Version = ""
+	schema.ID = ""
+	strict = true
+
+	walk(schema, func(s *jsonschema.Schema) {
+		// oneOf is not supported, anyOf is equivalent for the disjoint types generated from Go
+		if len(s.OneOf) > 0 {
+			s.AnyOf = append(s.AnyOf, s.OneOf...)
+			s.OneOf = nil
+		}
+
+		if s.Type == "object" && s.Properties == nil && s.AdditionalProperties != jsonschema.FalseSchema {
+			strict = false
+		}
+
+		if s.Properties == nil {
+			return
+		}
+
+		required := []string{}
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			if !slices.Contains(s.Required, pair.Key) {
+				s.Properties.Set(pair.Key, nullable(pair.Value))
+			}
+			required = append(required, pair.Key)
+		}
+		s.Required = required
+		s.AdditionalProperties = jsonschema.FalseSchema
+	})
+
+	return schema, strict, nil
+}
+
+func nullable(s *jsonschema.Schema) *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Description: s.Description,
+		AnyOf:       []*jsonschema.Schema{s, {Type: "null"}},
+	}
+}
@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Generate creates the JSON schema of a type, dereferencing pointer types.
+//
+// Fields are customized with the jsonschema struct tag, for example:
+//
+//	Rating int    `json:"rating" jsonschema:"description=Rating out of 5,minimum=1,maximum=5"`
+//	Color  string `json:"color" jsonschema:"enum=red,enum=green,enum=blue"`
+//	Date   string `json:"date" jsonschema:"format=date"`
+//
+// Fields tagged with omitempty are optional, every other field is required.
+// A new schema is returned on every call, so callers may modify it.
+func Generate(t reflect.Type) *jsonschema.Schema {
+	// Structured Outputs uses a subset of JSON schema
+	// These flags are necessary to comply with the subset
+	reflector := jsonschema.Reflector{
+		AllowAdditionalProperties: false,
+		Anonymous:                 true,
+	}
+
+	// If it's a pointer type, get the element type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Named types are generated as $defs so recursive types terminate.
+	// Providers require the root to be the object itself rather than a $ref, so copy its definition up.
+	root := reflector.ReflectFromType(t)
+	if name, ok := strings.CutPrefix(root.Ref, "#/$defs/"); ok {
+		if def, exists := root.Definitions[name]; exists {
+			definitions := root.Definitions
+			root = deepCopy(def)
+			root.Definitions = definitions
+		}
+	}
+	root.Version = ""
+	return root
+}
+
+// OpenAI limits schema names to 64 characters
+const maxNameLength = 64
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// Name returns a schema name for the type that is accepted by every provider.
+// Generic and anonymous types get their invalid characters replaced.
+func Name(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	name := invalidNameChars.ReplaceAllString(t.Name(), "_")
+	if name == "" {
+		return "response"
+	}
+	return name[:min(len(name), maxNameLength)]
+}
+
+// walk calls fn on the schema and every schema nested in it
+func walk(s *jsonschema.Schema, fn func(*jsonschema.Schema)) {
+	if s == nil || s == jsonschema.TrueSchema || s == jsonschema.FalseSchema {
+		return
+	}
+
+	fn(s)
+
+	if s.Properties != nil {
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			walk(pair.Value, fn)
+		}
+	}
+	for _, def := range s.Definitions {
+		walk(def, fn)
+	}
+	for _, sub := range s.AnyOf {
+		walk(sub, fn)
+	}
+	for _, sub := range s.OneOf {
+		walk(sub, fn)
+	}
+	for _, sub := range s.AllOf {
+		walk(sub, fn)
+	}
+	for _, sub := range s.PrefixItems {
+		walk(sub, fn)
+	}
+	walk(s.Items, fn)
+	walk(s.AdditionalProperties, fn)
+}
+
+func deepCopy(s *jsonschema.Schema) *jsonschema.Schema {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return s
+	}
+	copied := &jsonschema.Schema{}
+	if err := json.Unmarshal(data, copied); err != nil {
+		return s
+	}
+	return copied
+}
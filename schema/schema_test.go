@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+type address struct {
+	Street string `json:"street"`
+	City   string `json:"city" jsonschema:"description=City name"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type person struct {
+	Name    string   `json:"name"`
+	Address address  `json:"address"`
+	Billing *address `json:"billing,omitempty"`
+}
+
+type lineItem struct {
+	SKU      string `json:"sku"`
+	Quantity int    `json:"quantity" jsonschema:"minimum=1"`
+}
+
+type order struct {
+	Items []lineItem `json:"items"`
+	Tags  []string   `json:"tags,omitempty"`
+}
+
+type inventory struct {
+	Warehouse string         `json:"warehouse"`
+	Stock     map[string]int `json:"stock"`
+}
+
+type review struct {
+	Sentiment string `json:"sentiment" jsonschema:"enum=positive,enum=neutral,enum=negative"`
+	Stars     int    `json:"stars" jsonschema:"enum=1,enum=2,enum=3,enum=4,enum=5"`
+	Date      string `json:"date,omitempty" jsonschema:"format=date"`
+	Email     string `json:"email,omitempty" jsonschema:"format=email"`
+}
+
+var goldenTypes = map[string]reflect.Type{
+	"nested": reflect.TypeOf(person{}),
+	"slices": reflect.TypeOf(&order{}),
+	"map":    reflect.TypeOf(inventory{}),
+	"enum":   reflect.TypeOf(review{}),
+}
+
+// assertGolden compares value as indented JSON with testdata/name.json. Run with -update to rewrite it.
+func assertGolden(t *testing.T, name string, value any) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".json")
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s does not match the golden file\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestForOpenAIGolden(t *testing.T) {
+	for name, typ := range goldenTypes {
+		t.Run(name, func(t *testing.T) {
+			schema, strict, err := ForOpenAI(typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t, "openai_"+name, map[string]any{
+				"strict": strict,
+				"schema": schema,
+			})
+		})
+	}
+}
+
+func TestForOpenAIStrict(t *testing.T) {
+	for name, typ := range goldenTypes {
+		t.Run(name, func(t *testing.T) {
+			// Free-form maps can't be expressed in strict mode, so the schema is sent without it
+			_, strict, err := ForOpenAI(typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := name != "map"; strict != want {
+				t.Errorf("expected strict %v, got %v", want, strict)
+			}
+		})
+	}
+}
+
+func TestForOpenAIRejectsNonObjects(t *testing.T) {
+	for _, typ := range []reflect.Type{reflect.TypeOf([]lineItem{}), reflect.TypeOf(new(string))} {
+		if _, _, err := ForOpenAI(typ); err == nil {
+			t.Errorf("expected an error for %v", typ)
+		}
+	}
+}
+
+func TestForGeminiGolden(t *testing.T) {
+	for name, typ := range goldenTypes {
+		t.Run(name, func(t *testing.T) {
+			assertGolden(t, "gemini_"+name, ForGemini(typ))
+		})
+	}
+}
+
+func TestForGeminiInlinesRefs(t *testing.T) {
+	for name, typ := range goldenTypes {
+		t.Run(name, func(t *testing.T) {
+			data, err := json.Marshal(ForGemini(typ))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(data, []byte(`"$ref"`)) || bytes.Contains(data, []byte(`"$defs"`)) {
+				t.Errorf("expected refs to be inlined, got %s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type fileSession struct {
+	Record Record `json:"record"`
+	Turns  []Turn `json:"turns"`
+}
+
+// FileStore keeps every session in its own JSON file in a directory
+type FileStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create session directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) CreateSession(ctx context.Context, record Record, turns []Turn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(record.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return ErrSessionExists
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check session file: %w", err)
+	}
+	return s.write(fileSession{Record: record, Turns: turns})
+}
+
+func (s *FileStore) GetSession(ctx context.Context, id string) (Record, []Turn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.read(id)
+	if err != nil {
+		return Record{}, nil, err
+	}
+	return session.Record, session.Turns, nil
+}
+
+func (s *FileStore) AppendTurns(ctx context.Context, sessionID string, turns ...Turn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, err := s.read(sessionID)
+	if err != nil {
+		return err
+	}
+	session.Turns = append(session.Turns, turns...)
+	return s.write(session)
+}
+
+func (s *FileStore) DeleteSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete session file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) read(id string) (fileSession, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return fileSession{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fileSession{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return fileSession{}, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	var session fileSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return fileSession{}, fmt.Errorf("failed to unmarshal session file: %w", err)
+	}
+	return session, nil
+}
+
+// write replaces the session file atomically, so a crash never leaves a half-written session
+func (s *FileStore) write(session fileSession) error {
+	path, err := s.path(session.Record.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".session-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace session file: %w", err)
+	}
+	return nil
+}
+
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || id != filepath.Base(id) || id == "." || id == ".." {
+		return "", fmt.Errorf("invalid session id: %q", id)
+	}
+	return filepath.Join(s.dir, id+".json"), nil
+}
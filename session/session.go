@@ -0,0 +1,288 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+)
+
+type Config struct {
+	// ID defaults to a random ID if empty
+	ID            string
+	SystemMessage string
+	// PresetName is the router preset used to generate assistant turns
+	PresetName string
+}
+
+// Session is a persisted conversation. Every user and assistant turn is saved to the store
+// as it happens. A session is safe for concurrent use, turns are applied one at a time.
+type Session struct {
+	// sendMu orders calls to the preset, mu guards the turns and is never held across one
+	sendMu sync.Mutex
+	mu     sync.Mutex
+	router *router.Router
+	store  Store
+	record Record
+	turns  []Turn
+}
+
+func New(ctx context.Context, r *router.Router, store Store, config Config) (*Session, error) {
+	if config.PresetName == "" {
+		return nil, fmt.Errorf("session requires a preset name")
+	}
+
+	id := config.ID
+	if id == "" {
+		id = newID()
+	}
+
+	record := Record{
+		ID:            id,
+		SystemMessage: config.SystemMessage,
+		PresetName:    config.PresetName,
+		CreatedAt:     time.Now(),
+	}
+	if err := store.CreateSession(ctx, record, nil); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return &Session{
+		router: r,
+		store:  store,
+		record: record,
+	}, nil
+}
+
+// Load restores a session from the store
+func Load(ctx context.Context, r *router.Router, store Store, id string) (*Session, error) {
+	record, turns, err := store.GetSession(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", id, err)
+	}
+
+	return &Session{
+		router: r,
+		store:  store,
+		record: record,
+		turns:  turns,
+	}, nil
+}
+
+func (s *Session) ID() string {
+	return s.record.ID
+}
+
+func (s *Session) Record() Record {
+	return s.record
+}
+
+// Turns returns a copy of the turns so far
+func (s *Session) Turns() []Turn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Turn{}, s.turns...)
+}
+
+// Prompt returns the session as a prompt that can be sent to any preset
+func (s *Session) Prompt() params.Prompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.prompt()
+}
+
+// Send appends a user turn, sends the conversation to the session's preset and records the reply.
+// If the preset call fails, the user turn stays recorded and Generate can be used to retry.
+func (s *Session) Send(ctx context.Context, content string) (*params.Response, error) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	userTurn := Turn{
+		ID: newID(),
+		Message: params.Message{
+			Role:    params.MessageRoleUser,
+			Content: content,
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.Append(ctx, userTurn); err != nil {
+		return nil, err
+	}
+
+	return s.generate(ctx)
+}
+
+// Generate sends the conversation as it is and records the reply. Used to regenerate
+// an answer after forking a session at the user turn before it.
+func (s *Session) Generate(ctx context.Context) (*params.Response, error) {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	return s.generate(ctx)
+}
+
+// Append records turns produced outside of Send, e.g. tool calls handled by the caller
+func (s *Session) Append(ctx context.Context, turns ...Turn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range turns {
+		if turns[i].ID == "" {
+			turns[i].ID = newID()
+		}
+		if turns[i].CreatedAt.IsZero() {
+			turns[i].CreatedAt = time.Now()
+		}
+	}
+	return s.append(ctx, turns...)
+}
+
+// AppendToolCalls records an assistant turn with tool calls handled by the caller and their results.
+// The calls are also written into the turn's message, so later replies can use the results.
+func (s *Session) AppendToolCalls(ctx context.Context, calls ...ToolCall) error {
+	if len(calls) == 0 {
+		return fmt.Errorf("no tool calls to record")
+	}
+
+	var content strings.Builder
+	for i, call := range calls {
+		if i > 0 {
+			content.WriteString("\n")
+		}
+		fmt.Fprintf(&content, "Called tool %s with %s.", call.Name, call.Arguments)
+		if call.Result != "" {
+			fmt.Fprintf(&content, " Result: %s", call.Result)
+		}
+	}
+
+	return s.Append(ctx, Turn{
+		Message: params.Message{
+			Role:    params.MessageRoleAssistant,
+			Content: content.String(),
+		},
+		PresetName: s.record.PresetName,
+		ToolCalls:  calls,
+	})
+}
+
+// Fork creates a new session with the turns up to and including turnID. An empty turnID
+// forks the session without any turns. The original session is not changed.
+func (s *Session) Fork(ctx context.Context, turnID string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	end := 0
+	if turnID != "" {
+		end = -1
+		for i, turn := range s.turns {
+			if turn.ID == turnID {
+				end = i + 1
+				break
+			}
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("turn %s not found in session %s", turnID, s.record.ID)
+		}
+	}
+
+	record := Record{
+		ID:               newID(),
+		SystemMessage:    s.record.SystemMessage,
+		PresetName:       s.record.PresetName,
+		ParentID:         s.record.ID,
+		ForkedFromTurnID: turnID,
+		CreatedAt:        time.Now(),
+	}
+	turns := append([]Turn{}, s.turns[:end]...)
+
+	if err := s.store.CreateSession(ctx, record, turns); err != nil {
+		return nil, fmt.Errorf("failed to create forked session: %w", err)
+	}
+
+	return &Session{
+		router: s.router,
+		store:  s.store,
+		record: record,
+		turns:  turns,
+	}, nil
+}
+
+// generate sends a snapshot of the turns, so other methods aren't blocked while the preset answers
+func (s *Session) generate(ctx context.Context) (*params.Response, error) {
+	response, err := s.router.SendPrompt(ctx, s.record.PresetName, s.Prompt())
+	if err != nil {
+		return nil, err
+	}
+
+	assistantTurn := Turn{
+		ID: newID(),
+		Message: params.Message{
+			Role:             params.MessageRoleAssistant,
+			Content:          response.Content,
+			ThoughtSignature: response.ThoughtSignature,
+		},
+		PresetName: s.record.PresetName,
+		Reasoning:  response.Reasoning,
+		Metadata:   responseMetadata(response),
+		CreatedAt:  time.Now(),
+	}
+	if err := s.Append(ctx, assistantTurn); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// responseMetadata describes a response for its turn, or returns nil if there is nothing to record
+func responseMetadata(response *params.Response) map[string]string {
+	metadata := map[string]string{}
+	if response.ResponseID != "" {
+		metadata[MetadataResponseID] = response.ResponseID
+	}
+	if response.Grounding != nil {
+		if data, err := json.Marshal(response.Grounding); err == nil {
+			metadata[MetadataGrounding] = string(data)
+		}
+	}
+	if len(response.SafetyRatings) > 0 {
+		if data, err := json.Marshal(response.SafetyRatings); err == nil {
+			metadata[MetadataSafetyRatings] = string(data)
+		}
+	}
+
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+func (s *Session) append(ctx context.Context, turns ...Turn) error {
+	if err := s.store.AppendTurns(ctx, s.record.ID, turns...); err != nil {
+		return fmt.Errorf("failed to save turns: %w", err)
+	}
+	s.turns = append(s.turns, turns...)
+	return nil
+}
+
+func (s *Session) prompt() params.Prompt {
+	messages := make([]params.Message, len(s.turns))
+	for i, turn := range s.turns {
+		messages[i] = turn.Message
+	}
+	return params.NewPrompt(s.record.SystemMessage, messages, nil)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,328 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/client"
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/router"
+
+	_ "modernc.org/sqlite"
+)
+
+// stubProvider answers every prompt with the same reply and records the prompts
+type stubProvider struct {
+	mu      sync.Mutex
+	reply   string
+	prompts []params.Prompt
+}
+
+func (p *stubProvider) SendCompletionMessage(_ context.Context, prompt params.Prompt, _ params.Settings) (*params.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prompts = append(p.prompts, prompt)
+	return &params.Response{Content: p.reply}, nil
+}
+
+func (p *stubProvider) StreamCompletionMessage(context.Context, params.Prompt, params.Settings) (<-chan params.StreamChunk, error) {
+	return nil, errors.New("streaming is not supported")
+}
+
+// lastPrompt returns the text of every message of the last prompt
+func (p *stubProvider) lastPrompt() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.prompts) == 0 {
+		return ""
+	}
+	var text strings.Builder
+	for _, message := range p.prompts[len(p.prompts)-1].Messages {
+		text.WriteString(message.Content)
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// blockingProvider answers once release is closed and reports each call on started
+type blockingProvider struct {
+	stubProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingProvider) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	p.started <- struct{}{}
+	<-p.release
+	return p.stubProvider.SendCompletionMessage(ctx, prompt, settings)
+}
+
+// groundedProvider answers like stubProvider, with a response ID, grounding and safety ratings
+type groundedProvider struct {
+	stubProvider
+}
+
+func (p *groundedProvider) SendCompletionMessage(ctx context.Context, prompt params.Prompt, settings params.Settings) (*params.Response, error) {
+	response, err := p.stubProvider.SendCompletionMessage(ctx, prompt, settings)
+	if err != nil {
+		return nil, err
+	}
+	response.ResponseID = "resp-1"
+	response.Grounding = &params.Grounding{Sources: []params.Source{{URL: "https://example.com", Title: "Example"}}}
+	response.SafetyRatings = []params.SafetyRating{{Category: params.HarmCategoryHarassment, Probability: "NEGLIGIBLE"}}
+	return response, nil
+}
+
+func newTestRouter(t *testing.T, provider client.ProviderClient) *router.Router {
+	t.Helper()
+	r, err := router.NewRouter(
+		router.ClientMap{"model": {{Provider: provider, ClientType: client.ClientTypeOpenAI}}},
+		router.PresetMap{"chat": {ModelName: "model"}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func testStores(t *testing.T) map[string]Store {
+	fileStore, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	sqliteStore, err := NewSQLiteStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"file":   fileStore,
+		"sqlite": sqliteStore,
+	}
+}
+
+func TestCreateSessionRejectsExistingID(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			record := Record{ID: "chat-1", PresetName: "chat"}
+			if err := store.CreateSession(ctx, record, []Turn{{ID: "turn-1"}}); err != nil {
+				t.Fatal(err)
+			}
+
+			err := store.CreateSession(ctx, record, nil)
+			if !errors.Is(err, ErrSessionExists) {
+				t.Fatalf("expected ErrSessionExists, got %v", err)
+			}
+
+			// The existing session is left as it was
+			_, turns, err := store.GetSession(ctx, "chat-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(turns) != 1 {
+				t.Errorf("expected the original turn, got %d turns", len(turns))
+			}
+		})
+	}
+}
+
+func TestSendPersistsTurns(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			r := newTestRouter(t, &stubProvider{reply: "Paris"})
+
+			chat, err := New(ctx, r, store, Config{ID: "chat-1", PresetName: "chat"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := chat.Send(ctx, "Capital of France?"); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := Load(ctx, r, store, "chat-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			turns := loaded.Turns()
+			if len(turns) != 2 {
+				t.Fatalf("expected 2 turns, got %d", len(turns))
+			}
+			if turns[1].Message.Content != "Paris" || turns[1].PresetName != "chat" {
+				t.Errorf("expected the reply from preset chat, got %+v", turns[1])
+			}
+
+			if _, err := New(ctx, r, store, Config{ID: "chat-1", PresetName: "chat"}); !errors.Is(err, ErrSessionExists) {
+				t.Errorf("expected ErrSessionExists, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSendRecordsResponseMetadata(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			r := newTestRouter(t, &groundedProvider{stubProvider{reply: "Paris"}})
+
+			chat, err := New(ctx, r, store, Config{ID: "chat-1", PresetName: "chat"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := chat.Send(ctx, "Capital of France?"); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := Load(ctx, r, store, "chat-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			turns := loaded.Turns()
+			if turns[0].Metadata != nil {
+				t.Errorf("expected no metadata on the user turn, got %v", turns[0].Metadata)
+			}
+			metadata := turns[1].Metadata
+			if metadata[MetadataResponseID] != "resp-1" {
+				t.Errorf("expected response ID resp-1, got %q", metadata[MetadataResponseID])
+			}
+			if !strings.Contains(metadata[MetadataGrounding], "https://example.com") {
+				t.Errorf("expected grounding in metadata, got %q", metadata[MetadataGrounding])
+			}
+			if !strings.Contains(metadata[MetadataSafetyRatings], "NEGLIGIBLE") {
+				t.Errorf("expected safety ratings in metadata, got %q", metadata[MetadataSafetyRatings])
+			}
+		})
+	}
+}
+
+func TestAppendToolCalls(t *testing.T) {
+	ctx := context.Background()
+	provider := &stubProvider{reply: "It is sunny."}
+	r := newTestRouter(t, provider)
+
+	chat, err := New(ctx, r, NewMemoryStore(), Config{PresetName: "chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := chat.Append(ctx, Turn{Message: params.Message{Role: params.MessageRoleUser, Content: "Weather in Paris?"}}); err != nil {
+		t.Fatal(err)
+	}
+	err = chat.AppendToolCalls(ctx, ToolCall{ID: "call-1", Name: "get_weather", Arguments: `{"city":"Paris"}`, Result: "sunny"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chat.Generate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	turns := chat.Turns()
+	if len(turns[1].ToolCalls) != 1 || turns[1].ToolCalls[0].Name != "get_weather" {
+		t.Errorf("expected the tool call on the turn, got %+v", turns[1].ToolCalls)
+	}
+	if !strings.Contains(provider.lastPrompt(), `Called tool get_weather with {"city":"Paris"}. Result: sunny`) {
+		t.Errorf("expected the tool call in the prompt, got %q", provider.lastPrompt())
+	}
+}
+
+func TestForkCopiesTurnsUpToTurn(t *testing.T) {
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			r := newTestRouter(t, &stubProvider{reply: "Paris"})
+
+			chat, err := New(ctx, r, store, Config{SystemMessage: "Be brief.", PresetName: "chat"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, question := range []string{"Capital of France?", "And of Italy?"} {
+				if _, err := chat.Send(ctx, question); err != nil {
+					t.Fatal(err)
+				}
+			}
+			turns := chat.Turns()
+
+			fork, err := chat.Fork(ctx, turns[2].ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			record := fork.Record()
+			if record.ParentID != chat.ID() || record.ForkedFromTurnID != turns[2].ID || record.SystemMessage != "Be brief." {
+				t.Errorf("expected a fork of %s at turn %s, got %+v", chat.ID(), turns[2].ID, record)
+			}
+
+			// The fork is persisted and regenerates the answer to the last question
+			loaded, err := Load(ctx, r, store, fork.ID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(loaded.Turns()); got != 3 {
+				t.Fatalf("expected 3 turns in the fork, got %d", got)
+			}
+			if _, err := loaded.Generate(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(chat.Turns()); got != 4 {
+				t.Errorf("expected the original session to keep 4 turns, got %d", got)
+			}
+
+			empty, err := chat.Fork(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(empty.Turns()); got != 0 {
+				t.Errorf("expected no turns in an empty fork, got %d", got)
+			}
+
+			if _, err := chat.Fork(ctx, "missing"); err == nil {
+				t.Error("expected an error for an unknown turn")
+			}
+		})
+	}
+}
+
+func TestSendDoesNotBlockReads(t *testing.T) {
+	ctx := context.Background()
+	provider := &blockingProvider{
+		stubProvider: stubProvider{reply: "Paris"},
+		started:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+
+	chat, err := New(ctx, newTestRouter(t, provider), NewMemoryStore(), Config{PresetName: "chat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := make(chan error)
+	go func() {
+		_, err := chat.Send(ctx, "Capital of France?")
+		errs <- err
+	}()
+
+	// The session can be read while the preset is answering
+	<-provider.started
+	if got := len(chat.Turns()); got != 1 {
+		t.Errorf("expected the user turn while waiting for the reply, got %d turns", got)
+	}
+	close(provider.release)
+
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if got := len(chat.Turns()); got != 2 {
+		t.Errorf("expected 2 turns after the reply, got %d", got)
+	}
+}
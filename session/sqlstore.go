@@ -0,0 +1,179 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS llm_sessions (
+	id TEXT PRIMARY KEY,
+	system_message TEXT NOT NULL,
+	preset_name TEXT NOT NULL,
+	parent_id TEXT NOT NULL DEFAULT '',
+	forked_from_turn_id TEXT NOT NULL DEFAULT '',
+	created_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS llm_session_turns (
+	session_id TEXT NOT NULL REFERENCES llm_sessions(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	turn TEXT NOT NULL,
+	PRIMARY KEY (session_id, position)
+);`
+
+// SQLiteStore keeps sessions in a SQLite database. It works with any database/sql SQLite driver
+// (e.g. modernc.org/sqlite or github.com/mattn/go-sqlite3), which the caller opens and registers.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore creates the session tables if they don't exist yet
+func NewSQLiteStore(ctx context.Context, db *sql.DB) (*SQLiteStore, error) {
+	if _, err := db.ExecContext(ctx, sqliteSchema); err != nil {
+		return nil, fmt.Errorf("failed to create session tables: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) CreateSession(ctx context.Context, record Record, turns []Turn) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Checked up front, since drivers report the primary key violation differently
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM llm_sessions WHERE id = ?`, record.ID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to query session: %w", err)
+	}
+	if exists > 0 {
+		return ErrSessionExists
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO llm_sessions (id, system_message, preset_name, parent_id, forked_from_turn_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		record.ID, record.SystemMessage, record.PresetName, record.ParentID, record.ForkedFromTurnID,
+		record.CreatedAt.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	if err := insertTurns(ctx, tx, record.ID, 0, turns); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) GetSession(ctx context.Context, id string) (Record, []Turn, error) {
+	var record Record
+	var createdAt string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, system_message, preset_name, parent_id, forked_from_turn_id, created_at
+		FROM llm_sessions WHERE id = ?`, id).
+		Scan(&record.ID, &record.SystemMessage, &record.PresetName, &record.ParentID, &record.ForkedFromTurnID, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return Record{}, nil, fmt.Errorf("failed to query session: %w", err)
+	}
+	if record.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return Record{}, nil, fmt.Errorf("failed to parse session creation time: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT turn FROM llm_session_turns WHERE session_id = ? ORDER BY position`, id)
+	if err != nil {
+		return Record{}, nil, fmt.Errorf("failed to query turns: %w", err)
+	}
+	defer rows.Close()
+
+	turns := []Turn{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return Record{}, nil, fmt.Errorf("failed to scan turn: %w", err)
+		}
+
+		var turn Turn
+		if err := json.Unmarshal([]byte(data), &turn); err != nil {
+			return Record{}, nil, fmt.Errorf("failed to unmarshal turn: %w", err)
+		}
+		turns = append(turns, turn)
+	}
+	if err := rows.Err(); err != nil {
+		return Record{}, nil, fmt.Errorf("failed to read turns: %w", err)
+	}
+
+	return record, turns, nil
+}
+
+func (s *SQLiteStore) AppendTurns(ctx context.Context, sessionID string, turns ...Turn) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM llm_sessions WHERE id = ?`, sessionID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to query session: %w", err)
+	}
+	if exists == 0 {
+		return ErrSessionNotFound
+	}
+
+	var next int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(position) + 1, 0) FROM llm_session_turns WHERE session_id = ?`, sessionID).Scan(&next)
+	if err != nil {
+		return fmt.Errorf("failed to query turn position: %w", err)
+	}
+
+	if err := insertTurns(ctx, tx, sessionID, next, turns); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStore) DeleteSession(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Foreign keys are off by default in SQLite, so don't rely on the cascade
+	if _, err := tx.ExecContext(ctx, `DELETE FROM llm_session_turns WHERE session_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete turns: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM llm_sessions WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return tx.Commit()
+}
+
+func insertTurns(ctx context.Context, tx *sql.Tx, sessionID string, start int, turns []Turn) error {
+	for i, turn := range turns {
+		data, err := json.Marshal(turn)
+		if err != nil {
+			return fmt.Errorf("failed to marshal turn: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO llm_session_turns (session_id, position, turn) VALUES (?, ?, ?)`,
+			sessionID, start+i, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to insert turn: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrSessionExists is returned when creating a session with the ID of an existing one
+	ErrSessionExists = errors.New("session already exists")
+)
+
+// Metadata keys of assistant turns generated by a session
+const (
+	MetadataResponseID = "response_id"
+	// MetadataGrounding and MetadataSafetyRatings hold the JSON of Response.Grounding and Response.SafetyRatings
+	MetadataGrounding     = "grounding"
+	MetadataSafetyRatings = "safety_ratings"
+)
+
+// ToolCall is a tool invocation made by the model during a turn, with the result that was sent back
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result,omitempty"`
+}
+
+// Turn is a single message in a session along with what produced it
+type Turn struct {
+	ID      string         `json:"id"`
+	Message params.Message `json:"message"`
+	// PresetName is the preset that generated an assistant turn
+	PresetName string `json:"preset_name,omitempty"`
+	// Reasoning is the model's thinking for an assistant turn, if the preset includes reasoning
+	Reasoning string `json:"reasoning,omitempty"`
+	// ToolCalls are recorded with Session.AppendToolCalls
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// Metadata describes the response of a generated turn, keyed by the Metadata constants
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Record is the persisted state of a session, excluding its turns
+type Record struct {
+	ID            string `json:"id"`
+	SystemMessage string `json:"system_message"`
+	PresetName    string `json:"preset_name"`
+	// ParentID and ForkedFromTurnID are set when the session was forked from another session
+	ParentID         string    `json:"parent_id,omitempty"`
+	ForkedFromTurnID string    `json:"forked_from_turn_id,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Store persists sessions. Implementations must be safe for concurrent use.
+// CreateSession returns ErrSessionExists if a session with the ID already exists.
+type Store interface {
+	CreateSession(ctx context.Context, record Record, turns []Turn) error
+	GetSession(ctx context.Context, id string) (Record, []Turn, error)
+	AppendTurns(ctx context.Context, sessionID string, turns ...Turn) error
+	DeleteSession(ctx context.Context, id string) error
+}
+
+type memorySession struct {
+	record Record
+	turns  []Turn
+}
+
+// MemoryStore keeps sessions in memory. Sessions are lost when the process exits.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*memorySession
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: make(map[string]*memorySession),
+	}
+}
+
+func (s *MemoryStore) CreateSession(ctx context.Context, record Record, turns []Turn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.sessions[record.ID]; exists {
+		return ErrSessionExists
+	}
+	s.sessions[record.ID] = &memorySession{
+		record: record,
+		turns:  append([]Turn{}, turns...),
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetSession(ctx context.Context, id string) (Record, []Turn, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, exists := s.sessions[id]
+	if !exists {
+		return Record{}, nil, ErrSessionNotFound
+	}
+	return session.record, append([]Turn{}, session.turns...), nil
+}
+
+func (s *MemoryStore) AppendTurns(ctx context.Context, sessionID string, turns ...Turn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, exists := s.sessions[sessionID]
+	if !exists {
+		return ErrSessionNotFound
+	}
+	session.turns = append(session.turns, turns...)
+	return nil
+}
+
+func (s *MemoryStore) DeleteSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,56 @@
+package structured
+
+import "strings"
+
+// ExtractJSON returns the first JSON object or array in the content. It tolerates markdown
+// code fences and any text before or after the JSON. If no complete JSON value is found,
+// the trimmed content is returned unchanged so the parse error points at the real output.
+func ExtractJSON(content string) string {
+	trimmed := strings.TrimSpace(content)
+
+	start := strings.IndexAny(trimmed, "{[")
+	if start < 0 {
+		return trimmed
+	}
+
+	if end := matchingBracket(trimmed, start); end >= 0 {
+		return trimmed[start : end+1]
+	}
+	return trimmed
+}
+
+// matchingBracket returns the index of the bracket closing the one at start, or -1 if the value is incomplete
+func matchingBracket(s string, start int) int {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(s); i++ {
+		c := s[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,27 @@
+package structured
+
+import "testing"
+
+func TestExtractJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "plain", content: ` {"a":1} `, expected: `{"a":1}`},
+		{name: "fenced", content: "```json\n{\"a\":1}\n```", expected: `{"a":1}`},
+		{name: "prose", content: `Here is the result: {"a":"}"} Let me know if you need more.`, expected: `{"a":"}"}`},
+		{name: "escaped quote", content: `{"a":"say \"}\""} done`, expected: `{"a":"say \"}\""}`},
+		{name: "array", content: "Sure!\n[1, [2, 3]]", expected: `[1, [2, 3]]`},
+		{name: "incomplete", content: `Result: {"a":`, expected: `Result: {"a":`},
+		{name: "no json", content: " no json here ", expected: "no json here"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExtractJSON(test.content); got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+)
+
+// WithSchemaInstruction returns a copy of the prompt whose system message asks for a reply
+// matching the schema of the response format. It is used for backends without native structured output.
+func WithSchemaInstruction(prompt params.Prompt) (params.Prompt, error) {
+	if prompt.ResponseFormat == nil {
+		return prompt, nil
+	}
+
+	data, err := json.MarshalIndent(schema.Generate(reflect.TypeOf(prompt.ResponseFormat)), "", "  ")
+	if err != nil {
+		return params.Prompt{}, fmt.Errorf("failed to marshal response format schema: %w", err)
+	}
+
+	instruction := "Reply with a single JSON object that matches this JSON schema. " +
+		"Do not add any explanation or markdown formatting.\n\n" + string(data)
+
+	if prompt.SystemMessage == "" {
+		prompt.SystemMessage = instruction
+	} else {
+		prompt.SystemMessage = prompt.SystemMessage + "\n\n" + instruction
+	}
+	return prompt, nil
+}
@@ -0,0 +1,41 @@
+package structured
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+func TestWithSchemaInstruction(t *testing.T) {
+	prompt := params.NewSimplePrompt("Be brief.", "Where is order 1?")
+	prompt.ResponseFormat = &order{}
+
+	instructed, err := WithSchemaInstruction(prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(instructed.SystemMessage, "Be brief.\n\nReply with a single JSON object") {
+		t.Errorf("expected the instruction after the system message, got %q", instructed.SystemMessage)
+	}
+	if !strings.Contains(instructed.SystemMessage, `"quantity"`) || !strings.Contains(instructed.SystemMessage, `"minimum": 1`) {
+		t.Errorf("expected the schema in the system message, got %q", instructed.SystemMessage)
+	}
+	if prompt.SystemMessage != "Be brief." {
+		t.Errorf("expected the original prompt to be left unchanged, got %q", prompt.SystemMessage)
+	}
+
+	prompt.SystemMessage = ""
+	instructed, err = WithSchemaInstruction(prompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(instructed.SystemMessage, "Reply with a single JSON object") {
+		t.Errorf("expected the instruction as the system message, got %q", instructed.SystemMessage)
+	}
+
+	plain := params.NewSimplePrompt("Be brief.", "Hello")
+	if instructed, _ := WithSchemaInstruction(plain); instructed.SystemMessage != "Be brief." {
+		t.Errorf("expected no instruction without a response format, got %q", instructed.SystemMessage)
+	}
+}
@@ -0,0 +1,64 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+	"github.com/jamesleeht/llm-gopher/schema"
+)
+
+// Parse extracts the JSON from the model's reply, validates it against the schema of the target's type
+// and unmarshals it into target, which must be a non-nil pointer.
+// It returns a *params.StructuredOutputError if the reply is not valid JSON or doesn't match the schema.
+func Parse(content string, target any) error {
+	t := reflect.TypeOf(target)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return fmt.Errorf("response format must be a pointer, got %v", t)
+	}
+
+	data := []byte(ExtractJSON(content))
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return &params.StructuredOutputError{Content: content, Attempts: 1, Err: err}
+	}
+
+	if violations := Validate(schema.Generate(t), value); len(violations) > 0 {
+		return &params.StructuredOutputError{Content: content, Violations: violations, Attempts: 1}
+	}
+
+	if err := json.Unmarshal(data, target); err != nil {
+		return &params.StructuredOutputError{Content: content, Attempts: 1, Err: err}
+	}
+	return nil
+}
+
+// RepairPrompt returns a copy of the prompt with the invalid reply and the validation errors appended,
+// asking the model to answer again with JSON that matches the schema
+func RepairPrompt(prompt params.Prompt, outputErr *params.StructuredOutputError) params.Prompt {
+	var problems string
+	if outputErr.Err != nil {
+		problems = "- the reply is not valid JSON: " + outputErr.Err.Error()
+	} else {
+		problems = "- " + strings.Join(outputErr.Violations, "\n- ")
+	}
+
+	messages := append([]params.Message{}, prompt.Messages...)
+	messages = append(messages,
+		params.Message{Role: params.MessageRoleAssistant, Content: outputErr.Content},
+		params.Message{
+			Role: params.MessageRoleUser,
+			Content: "Your previous reply does not match the required JSON schema:\n" + problems +
+				"\n\nReply again with only the corrected JSON object.",
+		},
+	)
+
+	prompt.Messages = messages
+	return prompt
+}
@@ -0,0 +1,69 @@
+package structured
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+func TestParse(t *testing.T) {
+	var result order
+	content := "```json\n{\"id\":1,\"status\":\"open\",\"tags\":[],\"address\":{\"city\":\"Paris\"},\"quantity\":2}\n```"
+	if err := Parse(content, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ID != 1 || result.Address.City != "Paris" {
+		t.Errorf("expected the parsed order, got %+v", result)
+	}
+
+	if err := Parse(content, result); err == nil {
+		t.Error("expected an error for a non-pointer target")
+	}
+}
+
+func TestParseReturnsStructuredOutputError(t *testing.T) {
+	var outputErr *params.StructuredOutputError
+
+	err := Parse(`{"id":1`, &order{})
+	if !errors.As(err, &outputErr) || outputErr.Err == nil {
+		t.Fatalf("expected a JSON error, got %v", err)
+	}
+
+	err = Parse(`{"id":1,"status":"open","tags":[],"address":{"city":"Paris"}}`, &order{})
+	if !errors.As(err, &outputErr) || outputErr.Err != nil {
+		t.Fatalf("expected a schema error, got %v", err)
+	}
+	if len(outputErr.Violations) != 1 || outputErr.Attempts != 1 {
+		t.Errorf("expected one violation after one attempt, got %+v", outputErr)
+	}
+}
+
+func TestRepairPrompt(t *testing.T) {
+	prompt := params.NewSimplePrompt("Reply with JSON.", "Order 1?")
+
+	repaired := RepairPrompt(prompt, &params.StructuredOutputError{
+		Content:    `{"id":"1"}`,
+		Violations: []string{"$.id: must be of type integer, got string", `$: missing required property "status"`},
+	})
+	if len(prompt.Messages) != 1 {
+		t.Errorf("expected the original prompt to be left unchanged, got %d messages", len(prompt.Messages))
+	}
+	if len(repaired.Messages) != 3 {
+		t.Fatalf("expected the reply and a repair request to be appended, got %d messages", len(repaired.Messages))
+	}
+	if reply := repaired.Messages[1]; reply.Role != params.MessageRoleAssistant || reply.Content != `{"id":"1"}` {
+		t.Errorf("expected the invalid reply as an assistant message, got %+v", reply)
+	}
+	request := repaired.Messages[2]
+	if request.Role != params.MessageRoleUser ||
+		!strings.Contains(request.Content, "- $.id: must be of type integer, got string\n- $: missing required property \"status\"") {
+		t.Errorf("expected every violation in the repair request, got %q", request.Content)
+	}
+
+	invalid := RepairPrompt(prompt, &params.StructuredOutputError{Content: "{", Err: errors.New("unexpected EOF")})
+	if !strings.Contains(invalid.Messages[2].Content, "the reply is not valid JSON: unexpected EOF") {
+		t.Errorf("expected the JSON error in the repair request, got %q", invalid.Messages[2].Content)
+	}
+}
@@ -0,0 +1,188 @@
+package structured
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+type partialFrame struct {
+	closer    byte
+	expectKey bool
+}
+
+// CompletePartialJSON turns the prefix of a JSON object or array into valid JSON by closing
+// any open strings, arrays and objects. Incomplete keys, numbers and literals are dropped
+// so they don't show up with a wrong value. It returns false if there is nothing to parse yet.
+func CompletePartialJSON(content string) (string, bool) {
+	start := strings.IndexAny(content, "{[")
+	if start < 0 {
+		return "", false
+	}
+	s := content[start:]
+
+	var stack []partialFrame
+	safeEnd := -1
+	var safeStack []partialFrame
+	markSafe := func(end int) {
+		safeEnd = end
+		safeStack = append(safeStack[:0], stack...)
+	}
+
+	inString := false
+	isKey := false
+	escaped := false
+	// escapeStart is where the last escape sequence in a string starts, and unicodeDigits
+	// the number of hex digits of a \u escape that haven't been received yet
+	escapeStart := 0
+	unicodeDigits := 0
+
+	i := 0
+scan:
+	for i < len(s) {
+		c := s[i]
+
+		if inString {
+			switch {
+			case unicodeDigits > 0:
+				unicodeDigits--
+			case escaped:
+				escaped = false
+				if c == 'u' {
+					unicodeDigits = 4
+				}
+			case c == '\\':
+				escaped = true
+				escapeStart = i
+			case c == '"':
+				inString = false
+				if !isKey {
+					markSafe(i + 1)
+				}
+			}
+			i++
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+			isKey = len(stack) > 0 && stack[len(stack)-1].expectKey
+		case '{':
+			stack = append(stack, partialFrame{closer: '}', expectKey: true})
+			markSafe(i + 1)
+		case '[':
+			stack = append(stack, partialFrame{closer: ']'})
+			markSafe(i + 1)
+		case '}', ']':
+			if len(stack) == 0 {
+				return "", false
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				// The value is complete, ignore anything after it
+				return s[:i+1], true
+			}
+			markSafe(i + 1)
+		case ':':
+			if len(stack) > 0 {
+				stack[len(stack)-1].expectKey = false
+			}
+		case ',':
+			if len(stack) > 0 && stack[len(stack)-1].closer == '}' {
+				stack[len(stack)-1].expectKey = true
+			}
+		case ' ', '\t', '\r', '\n':
+		default:
+			// A number or literal is only complete once a delimiter follows it
+			end := i
+			for end < len(s) && !strings.ContainsRune(",}] \t\r\n", rune(s[end])) {
+				end++
+			}
+			if end == len(s) {
+				break scan
+			}
+			markSafe(end)
+			i = end
+			continue
+		}
+		i++
+	}
+
+	// Show string values as they are being written
+	if inString && !isKey {
+		partial := s
+		if escaped || unicodeDigits > 0 {
+			partial = partial[:escapeStart]
+		}
+		candidate := partial + `"` + closers(stack)
+		if json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+
+	if safeEnd < 0 {
+		return "", false
+	}
+	return s[:safeEnd] + closers(safeStack), true
+}
+
+func closers(stack []partialFrame) string {
+	var b strings.Builder
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteByte(stack[i].closer)
+	}
+	return b.String()
+}
+
+// StreamPartial fills in Partial on every chunk with a new value of the target's type, populated
+// from the JSON received so far. On the final chunk the full reply is validated and unmarshalled
+// into target, which is set as Parsed, or Error is set to a *params.StructuredOutputError.
+func StreamPartial(chunks <-chan params.StreamChunk, target any) <-chan params.StreamChunk {
+	out := make(chan params.StreamChunk)
+
+	go func() {
+		defer close(out)
+
+		targetType := reflect.TypeOf(target)
+		var content strings.Builder
+		lastPartial := ""
+
+		for chunk := range chunks {
+			if chunk.Kind == params.StreamChunkKindReasoning {
+				out <- chunk
+				continue
+			}
+			content.WriteString(chunk.Content)
+
+			if chunk.Done {
+				if chunk.Error == nil {
+					if err := Parse(content.String(), target); err != nil {
+						chunk.Error = err
+					} else {
+						chunk.Parsed = target
+					}
+				}
+				out <- chunk
+				continue
+			}
+
+			if targetType == nil || targetType.Kind() != reflect.Ptr {
+				out <- chunk
+				continue
+			}
+			if completed, ok := CompletePartialJSON(content.String()); ok && completed != lastPartial {
+				value := reflect.New(targetType.Elem())
+				if err := json.Unmarshal([]byte(completed), value.Interface()); err == nil {
+					chunk.Partial = value.Interface()
+					lastPartial = completed
+				}
+			}
+			out <- chunk
+		}
+	}()
+
+	return out
+}
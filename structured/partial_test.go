@@ -0,0 +1,122 @@
+package structured
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/params"
+)
+
+func TestCompletePartialJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{name: "open string", content: `{"a":"hel`, expected: `{"a":"hel"}`},
+		{name: "dangling escape", content: `{"a":"x\`, expected: `{"a":"x"}`},
+		{name: "truncated unicode escape", content: `{"a":"x\u00`, expected: `{"a":"x"}`},
+		{name: "complete escapes", content: `{"a":"say \"hi\" \\`, expected: `{"a":"say \"hi\" \\"}`},
+		{name: "non-ascii", content: `{"a":"café`, expected: `{"a":"café"}`},
+		{name: "dangling key", content: `{"a":1,"b`, expected: `{"a":1}`},
+		{name: "dangling closed key", content: `{"a":1,"b"`, expected: `{"a":1}`},
+		{name: "dangling colon", content: `{"a":1,"b":`, expected: `{"a":1}`},
+		{name: "incomplete number", content: `{"a":1,"b": 2`, expected: `{"a":1}`},
+		{name: "incomplete literal", content: `{"a":{"b":{"c":tr`, expected: `{"a":{"b":{}}}`},
+		{name: "nested array", content: `{"a":[1,2`, expected: `{"a":[1]}`},
+		{name: "nested objects", content: `{"a":[{"b":"c"},{"d":`, expected: `{"a":[{"b":"c"},{}]}`},
+		{name: "root array", content: `[{"a":[1,`, expected: `[{"a":[1]}]`},
+		{name: "prose before", content: `Sure: {"a":"x",`, expected: `{"a":"x"}`},
+		{name: "complete value", content: `{"a":"x"} trailing`, expected: `{"a":"x"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			completed, ok := CompletePartialJSON(test.content)
+			if !ok || completed != test.expected {
+				t.Errorf("expected %s, got %s (%v)", test.expected, completed, ok)
+			}
+		})
+	}
+
+	if _, ok := CompletePartialJSON("no json yet"); ok {
+		t.Error("expected nothing to parse without an object or array")
+	}
+}
+
+func streamOf(chunks ...params.StreamChunk) <-chan params.StreamChunk {
+	stream := make(chan params.StreamChunk, len(chunks))
+	for _, chunk := range chunks {
+		stream <- chunk
+	}
+	close(stream)
+	return stream
+}
+
+func collect(stream <-chan params.StreamChunk) []params.StreamChunk {
+	var chunks []params.StreamChunk
+	for chunk := range stream {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func TestStreamPartial(t *testing.T) {
+	target := &address{}
+	chunks := collect(StreamPartial(streamOf(
+		params.StreamChunk{Content: `{"ci`},
+		params.StreamChunk{Content: `ty":"Par`},
+		params.StreamChunk{Content: `is"}`},
+		params.StreamChunk{Done: true},
+	), target))
+
+	if len(chunks) != 4 {
+		t.Fatalf("expected every chunk to be forwarded, got %d", len(chunks))
+	}
+	if partial, ok := chunks[0].Partial.(*address); !ok || partial.City != "" {
+		t.Errorf("expected an empty partial before the key is complete, got %+v", chunks[0].Partial)
+	}
+	if partial, ok := chunks[1].Partial.(*address); !ok || partial.City != "Par" {
+		t.Errorf("expected the partial city, got %+v", chunks[1].Partial)
+	}
+	if chunks[1].Partial == target {
+		t.Error("expected partials to be new values, not the target")
+	}
+
+	final := chunks[3]
+	if final.Error != nil || final.Parsed != target || target.City != "Paris" {
+		t.Errorf("expected the parsed target on the final chunk, got %+v (%v)", target, final.Error)
+	}
+}
+
+func TestStreamPartialValidatesFinalChunk(t *testing.T) {
+	chunks := collect(StreamPartial(streamOf(
+		params.StreamChunk{Content: `{"id":"1"}`},
+		params.StreamChunk{Done: true},
+	), &order{}))
+
+	final := chunks[len(chunks)-1]
+	var outputErr *params.StructuredOutputError
+	if !errors.As(final.Error, &outputErr) || len(outputErr.Violations) == 0 {
+		t.Fatalf("expected a structured output error, got %v", final.Error)
+	}
+	if final.Parsed != nil {
+		t.Errorf("expected no parsed value, got %+v", final.Parsed)
+	}
+}
+
+func TestStreamPartialKeepsStreamError(t *testing.T) {
+	errStream := errors.New("connection reset")
+	chunks := collect(StreamPartial(streamOf(
+		params.StreamChunk{Content: `{"city":"Pa`},
+		params.StreamChunk{Done: true, Error: errStream},
+	), &address{}))
+
+	final := chunks[len(chunks)-1]
+	if !errors.Is(final.Error, errStream) {
+		t.Errorf("expected the stream error, got %v", final.Error)
+	}
+	if final.Parsed != nil {
+		t.Errorf("expected the incomplete reply not to be parsed, got %+v", final.Parsed)
+	}
+}
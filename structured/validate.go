@@ -0,0 +1,254 @@
+package structured
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/invopop/jsonschema"
+)
+
+// Validate checks the decoded JSON value against the schema and returns one message per violation.
+// The value must be decoded with json.Decoder.UseNumber so numbers keep their precision.
+// It covers types, required and additional properties, enums, const, numeric bounds, lengths and patterns.
+func Validate(schema *jsonschema.Schema, value any) []string {
+	v := &validator{definitions: schema.Definitions}
+	v.validate(schema, value, "$")
+	return v.errors
+}
+
+type validator struct {
+	definitions jsonschema.Definitions
+	errors      []string
+}
+
+func (v *validator) addError(path string, format string, args ...any) {
+	v.errors = append(v.errors, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *validator) validate(schema *jsonschema.Schema, value any, path string) {
+	if schema == nil || reflect.DeepEqual(schema, jsonschema.TrueSchema) {
+		return
+	}
+	if isFalseSchema(schema) {
+		v.addError(path, "is not allowed")
+		return
+	}
+
+	if schema.Ref != "" {
+		if def, exists := v.definitions[strings.TrimPrefix(schema.Ref, "#/$defs/")]; exists {
+			v.validate(def, value, path)
+		}
+		return
+	}
+
+	if len(schema.AnyOf) > 0 && !v.matchesAny(schema.AnyOf, value) {
+		v.addError(path, "does not match any of the allowed schemas")
+	}
+	if len(schema.OneOf) > 0 && !v.matchesAny(schema.OneOf, value) {
+		v.addError(path, "does not match any of the allowed schemas")
+	}
+	for _, sub := range schema.AllOf {
+		v.validate(sub, value, path)
+	}
+
+	if schema.Type != "" && !matchesType(schema.Type, value) {
+		v.addError(path, "must be of type %s, got %s", schema.Type, typeName(value))
+		return
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(allowed any) bool {
+		return jsonEqual(allowed, value)
+	}) {
+		v.addError(path, "must be one of %s", mustMarshal(schema.Enum))
+	}
+	if schema.Const != nil && !jsonEqual(schema.Const, value) {
+		v.addError(path, "must be %s", mustMarshal(schema.Const))
+	}
+
+	switch typed := value.(type) {
+	case map[string]any:
+		v.validateObject(schema, typed, path)
+	case []any:
+		v.validateArray(schema, typed, path)
+	case string:
+		v.validateString(schema, typed, path)
+	case json.Number:
+		v.validateNumber(schema, typed, path)
+	}
+}
+
+func (v *validator) matchesAny(schemas []*jsonschema.Schema, value any) bool {
+	for _, sub := range schemas {
+		branch := &validator{definitions: v.definitions}
+		branch.validate(sub, value, "$")
+		if len(branch.errors) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) validateObject(schema *jsonschema.Schema, object map[string]any, path string) {
+	for _, name := range schema.Required {
+		if _, exists := object[name]; !exists {
+			v.addError(path, "missing required property %q", name)
+		}
+	}
+
+	// Sort keys so errors come out in a stable order
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		propertyPath := path + "." + key
+		if schema.Properties != nil {
+			if propertySchema, exists := schema.Properties.Get(key); exists {
+				// Optional properties are sent as nullable to providers that require every property
+				if object[key] == nil && !slices.Contains(schema.Required, key) {
+					continue
+				}
+				v.validate(propertySchema, object[key], propertyPath)
+				continue
+			}
+		}
+		if schema.AdditionalProperties != nil {
+			if isFalseSchema(schema.AdditionalProperties) {
+				v.addError(path, "unexpected property %q", key)
+				continue
+			}
+			v.validate(schema.AdditionalProperties, object[key], propertyPath)
+		}
+	}
+}
+
+func (v *validator) validateArray(schema *jsonschema.Schema, array []any, path string) {
+	if schema.MinItems != nil && uint64(len(array)) < *schema.MinItems {
+		v.addError(path, "must have at least %d items", *schema.MinItems)
+	}
+	if schema.MaxItems != nil && uint64(len(array)) > *schema.MaxItems {
+		v.addError(path, "must have at most %d items", *schema.MaxItems)
+	}
+	for i, item := range array {
+		v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (v *validator) validateString(schema *jsonschema.Schema, s string, path string) {
+	length := uint64(utf8.RuneCountInString(s))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.addError(path, "must be at least %d characters", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.addError(path, "must be at most %d characters", *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+			v.addError(path, "must match pattern %s", schema.Pattern)
+		}
+	}
+}
+
+func (v *validator) validateNumber(schema *jsonschema.Schema, n json.Number, path string) {
+	value, ok := new(big.Float).SetString(n.String())
+	if !ok {
+		v.addError(path, "is not a valid number")
+		return
+	}
+
+	check := func(bound json.Number, ok func(cmp int) bool, message string) {
+		if bound == "" {
+			return
+		}
+		limit, valid := new(big.Float).SetString(bound.String())
+		if valid && !ok(value.Cmp(limit)) {
+			v.addError(path, message, bound)
+		}
+	}
+	check(schema.Minimum, func(cmp int) bool { return cmp >= 0 }, "must be >= %s")
+	check(schema.Maximum, func(cmp int) bool { return cmp <= 0 }, "must be <= %s")
+	check(schema.ExclusiveMinimum, func(cmp int) bool { return cmp > 0 }, "must be > %s")
+	check(schema.ExclusiveMaximum, func(cmp int) bool { return cmp < 0 }, "must be < %s")
+}
+
+// isFalseSchema reports whether the schema rejects every value. Schemas are compared by value,
+// since decoding or copying a schema doesn't keep the jsonschema.FalseSchema pointer.
+func isFalseSchema(schema *jsonschema.Schema) bool {
+	if reflect.DeepEqual(schema, jsonschema.FalseSchema) {
+		return true
+	}
+	return schema != nil && schema.Not != nil && reflect.DeepEqual(*schema.Not, jsonschema.Schema{})
+}
+
+func matchesType(schemaType string, value any) bool {
+	switch schemaType {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, valid := new(big.Float).SetString(n.String())
+		return valid && f.IsInt()
+	}
+	return true
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual compares values by their JSON encoding, so 1 and json.Number("1") are equal
+func jsonEqual(a any, b any) bool {
+	var left, right any
+	if json.Unmarshal([]byte(mustMarshal(a)), &left) != nil || json.Unmarshal([]byte(mustMarshal(b)), &right) != nil {
+		return false
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+func mustMarshal(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
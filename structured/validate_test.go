@@ -0,0 +1,124 @@
+package structured
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jamesleeht/llm-gopher/schema"
+
+	"github.com/invopop/jsonschema"
+)
+
+type address struct {
+	City string `json:"city"`
+}
+
+type order struct {
+	ID       int      `json:"id"`
+	Status   string   `json:"status" jsonschema:"enum=open,enum=closed"`
+	Tags     []string `json:"tags"`
+	Address  address  `json:"address"`
+	Quantity int      `json:"quantity" jsonschema:"minimum=1"`
+}
+
+func decode(t *testing.T, data string) any {
+	t.Helper()
+	decoder := json.NewDecoder(bytes.NewReader([]byte(data)))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestValidate(t *testing.T) {
+	orderSchema := schema.Generate(reflect.TypeFor[order]())
+
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			name:  "valid",
+			value: `{"id":1,"status":"open","tags":["a"],"address":{"city":"Paris"},"quantity":2}`,
+		},
+		{
+			name:     "wrong type",
+			value:    `{"id":"1","status":"open","tags":["a"],"address":{"city":"Paris"},"quantity":2}`,
+			expected: []string{"$.id: must be of type integer, got string"},
+		},
+		{
+			name:     "missing required",
+			value:    `{"id":1,"status":"open","tags":["a"],"address":{"city":"Paris"}}`,
+			expected: []string{`$: missing required property "quantity"`},
+		},
+		{
+			name:     "enum",
+			value:    `{"id":1,"status":"pending","tags":["a"],"address":{"city":"Paris"},"quantity":2}`,
+			expected: []string{`$.status: must be one of ["open","closed"]`},
+		},
+		{
+			name:     "minimum",
+			value:    `{"id":1,"status":"open","tags":["a"],"address":{"city":"Paris"},"quantity":0}`,
+			expected: []string{"$.quantity: must be >= 1"},
+		},
+		{
+			name:  "nested",
+			value: `{"id":1,"status":"open","tags":[2],"address":{},"quantity":2}`,
+			expected: []string{
+				`$.address: missing required property "city"`,
+				"$.tags[0]: must be of type string, got number",
+			},
+		},
+		{
+			name:  "unknown properties",
+			value: `{"id":1,"status":"open","tags":[],"address":{"city":"Paris","zip":"75001"},"quantity":2,"note":"x"}`,
+			expected: []string{
+				`$.address: unexpected property "zip"`,
+				`$: unexpected property "note"`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			violations := Validate(orderSchema, decode(t, test.value))
+			if !slices.Equal(violations, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, violations)
+			}
+		})
+	}
+}
+
+// A decoded or copied schema doesn't keep the jsonschema.FalseSchema pointer,
+// so unknown properties must still be rejected at the root and in nested objects
+func TestValidateRejectsUnknownPropertiesInDecodedSchema(t *testing.T) {
+	data, err := json.Marshal(schema.Generate(reflect.TypeFor[order]()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded jsonschema.Schema
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	value := decode(t, `{"id":1,"status":"open","tags":[],"address":{"city":"Paris","zip":"75001"},"quantity":2,"note":"x"}`)
+	expected := []string{
+		`$.address: unexpected property "zip"`,
+		`$: unexpected property "note"`,
+	}
+	if violations := Validate(&decoded, value); !slices.Equal(violations, expected) {
+		t.Errorf("expected %q, got %q", expected, violations)
+	}
+
+	// A "not": {} schema rejects every value as well
+	notSchema := &jsonschema.Schema{Type: "object", AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}}}
+	if violations := Validate(notSchema, decode(t, `{"note":"x"}`)); len(violations) != 1 {
+		t.Errorf("expected the unknown property to be rejected, got %q", violations)
+	}
+}
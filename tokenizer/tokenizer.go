@@ -0,0 +1,70 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+const (
+	EncodingO200kBase  = "o200k_base"
+	EncodingCL100kBase = "cl100k_base"
+)
+
+// Models using the o200k_base encoding. Every other model, including non-OpenAI models
+// served through OpenAI compatible endpoints, is approximated with cl100k_base.
+var o200kModelPrefixes = []string{
+	"gpt-4o",
+	"gpt-4.1",
+	"gpt-4.5",
+	"gpt-5",
+	"chatgpt-4o",
+	"o1",
+	"o3",
+	"o4",
+}
+
+var (
+	loaderOnce sync.Once
+	encodings  sync.Map
+)
+
+// EncodingForModel returns the name of the BPE encoding used by an OpenAI model
+func EncodingForModel(modelName string) string {
+	for _, prefix := range o200kModelPrefixes {
+		if strings.HasPrefix(modelName, prefix) {
+			return EncodingO200kBase
+		}
+	}
+	return EncodingCL100kBase
+}
+
+// Count returns the number of tokens in the text using the encoding of the model.
+// The BPE ranks are embedded in the binary, so no network access is needed.
+func Count(modelName string, text string) (int, error) {
+	encoding, err := getEncoding(EncodingForModel(modelName))
+	if err != nil {
+		return 0, err
+	}
+	return len(encoding.EncodeOrdinary(text)), nil
+}
+
+func getEncoding(name string) (*tiktoken.Tiktoken, error) {
+	loaderOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+	})
+
+	if encoding, ok := encodings.Load(name); ok {
+		return encoding.(*tiktoken.Tiktoken), nil
+	}
+
+	encoding, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encoding %s: %w", name, err)
+	}
+	encodings.Store(name, encoding)
+	return encoding, nil
+}
@@ -0,0 +1,44 @@
+package tokenizer
+
+import "testing"
+
+func TestEncodingForModel(t *testing.T) {
+	tests := map[string]string{
+		"gpt-4o":                     EncodingO200kBase,
+		"gpt-4o-mini-2024-07-18":     EncodingO200kBase,
+		"gpt-4.1-nano":               EncodingO200kBase,
+		"o3-mini":                    EncodingO200kBase,
+		"gpt-4":                      EncodingCL100kBase,
+		"gpt-3.5-turbo":              EncodingCL100kBase,
+		"deepseek/deepseek-v3-turbo": EncodingCL100kBase,
+	}
+	for modelName, expected := range tests {
+		if got := EncodingForModel(modelName); got != expected {
+			t.Errorf("%s: expected %s, got %s", modelName, expected, got)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		modelName string
+		text      string
+		expected  int
+	}{
+		{"gpt-4", "", 0},
+		{"gpt-4", "Hello, world!", 4},
+		{"gpt-4o", "Hello, world!", 4},
+		// Japanese is encoded more compactly by o200k_base
+		{"gpt-4", "こんにちは世界", 4},
+		{"gpt-4o", "こんにちは世界", 2},
+	}
+	for _, tt := range tests {
+		got, err := Count(tt.modelName, tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s %q: expected %d tokens, got %d", tt.modelName, tt.text, tt.expected, got)
+		}
+	}
+}